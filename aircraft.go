@@ -21,6 +21,8 @@ type Aircraft struct {
 	FlightPlan          *FlightPlan
 	ForceQLControllers  []string
 	PointOutHistory     []string
+	// Controllers that a virtual controller has pointed the aircraft out to.
+	VirtualPointOuts []string
 
 	// STARS-related state that is globally visible
 	TrackingController        string // Who has the radar track
@@ -39,20 +41,36 @@ type Aircraft struct {
 	// nil -> unset/unspecified.
 	Nav Nav
 
+	// Instructions to be carried out once their trigger fires.
+	ConditionalClearances []ConditionalClearance
+
+	// Traffic advisories: the traffic the pilot is looking for, the
+	// traffic the pilot has in sight, and the traffic the pilot has been
+	// told to maintain visual separation from.
+	TrafficLookout   *TrafficLookout
+	TrafficInSight   []string
+	VisualSeparation string
+
 	// Departure related state
 	Exit                       string
+	DepartureRunway            string
 	DepartureContactAltitude   float32
 	DepartureContactController string
 
 	// Arrival-related state
 	STAR              string
-	GoAroundDistance  *float32
 	ArrivalGroup      string
 	ArrivalGroupIndex int
 	GotContactTower   bool
 
 	// Who to try to hand off to at a waypoint with /ho
 	WaypointHandoffController string
+
+	// Set by the instructor: the aircraft can't hear or talk to ATC.
+	NORDO bool
+
+	// Flown by a pseudo-pilot, who must respond to control instructions.
+	PseudoPilot bool
 }
 
 type RedirectedHandoff struct {
@@ -165,31 +183,31 @@ func (ac *Aircraft) Update(w *World, ep EventPoster, simlg *Logger) *Waypoint {
 		}
 	}
 
-	if ac.GoAroundDistance != nil {
-		if d, err := ac.Nav.distanceToEndOfApproach(); err == nil && d < *ac.GoAroundDistance {
-			lg.Info("randomly going around")
-			ac.GoAroundDistance = nil // only go around once
-			rt := ac.GoAround()
-			ac.ControllingController = w.DepartureController(ac)
-			PostRadioEvents(ac.Callsign, rt, ep)
-
-			// If it was handed off to tower, hand it back to us
-			if ac.TrackingController != "" && ac.TrackingController != ac.ApproachController {
-				ac.HandoffTrackController = w.DepartureController(ac)
-				if ac.HandoffTrackController == "" {
-					ac.HandoffTrackController = ac.ApproachController
-				}
-				ep.PostEvent(Event{
-					Type:           OfferedHandoffEvent,
-					Callsign:       ac.Callsign,
-					FromController: ac.TrackingController,
-					ToController:   ac.ApproachController,
-				})
-			}
+	return passedWaypoint
+}
+
+// SentAround is called when the tower sends the aircraft around; control
+// and the track go back to the departure controller or, if there isn't
+// one, the approach controller.
+func (ac *Aircraft) SentAround(w *World, ep EventPoster, reason string) {
+	rt := ac.GoAround()
+	rt[0].Message += ", " + reason
+	ac.ControllingController = w.DepartureController(ac)
+	PostRadioEvents(ac.Callsign, rt, ep)
+
+	// If it was handed off to tower, hand it back to us
+	if ac.TrackingController != "" && ac.TrackingController != ac.ApproachController {
+		ac.HandoffTrackController = w.DepartureController(ac)
+		if ac.HandoffTrackController == "" {
+			ac.HandoffTrackController = ac.ApproachController
 		}
+		ep.PostEvent(Event{
+			Type:           OfferedHandoffEvent,
+			Callsign:       ac.Callsign,
+			FromController: ac.TrackingController,
+			ToController:   ac.ApproachController,
+		})
 	}
-
-	return passedWaypoint
 }
 
 func (ac *Aircraft) GoAround() []RadioTransmission {
@@ -206,6 +224,45 @@ func (ac *Aircraft) AssignAltitude(altitude int, afterSpeed bool) []RadioTransmi
 	return ac.transmitResponse(response)
 }
 
+func (ac *Aircraft) AssignAltimeter(alt float32) []RadioTransmission {
+	return ac.transmitResponse(ac.Nav.AssignAltimeter(alt))
+}
+
+// SayAltitude, SayHeading, and SaySpeed report what the pilot sees in
+// the cockpit, which is useful for checking Mode C and for verifying
+// that instructions were understood.
+func (ac *Aircraft) SayAltitude() []RadioTransmission {
+	indicated := ac.Nav.FlightState.Altitude
+	alt := 100 * float32(int((indicated+50)/100))
+	target := ac.Nav.Altitude.Assigned
+	if target == nil {
+		target = ac.Nav.Altitude.Cleared
+	}
+	if target != nil && abs(*target-indicated) >= 100 {
+		return ac.readback("leaving %s for %s", FormatAltitude(alt), FormatAltitude(*target))
+	}
+	return ac.readback("level %s", FormatAltitude(alt))
+}
+
+func (ac *Aircraft) SayHeading() []RadioTransmission {
+	hdg := int(ac.Heading() + 0.5)
+	if hdg == 0 {
+		hdg = 360
+	}
+	if assigned, ok := ac.Nav.AssignedHeading(); ok && headingDifference(assigned, ac.Heading()) > 2 {
+		return ac.readback("heading %03d, turning to %03d", hdg, int(assigned))
+	}
+	return ac.readback("heading %03d", hdg)
+}
+
+func (ac *Aircraft) SaySpeed() []RadioTransmission {
+	ias := int(ac.IAS() + 0.5)
+	if assigned := ac.Nav.Speed.Assigned; assigned != nil && abs(*assigned-ac.IAS()) > 5 {
+		return ac.readback("%d knots, %s to %.0f", ias, Select(*assigned < ac.IAS(), "slowing", "increasing"), *assigned)
+	}
+	return ac.readback("%d knots", ias)
+}
+
 func (ac *Aircraft) AssignSpeed(speed int, afterAltitude bool) []RadioTransmission {
 	resp := ac.Nav.AssignSpeed(float32(speed), afterAltitude)
 	return ac.transmitResponse(resp)
@@ -390,7 +447,7 @@ func (ac *Aircraft) InterceptLocalizer(w *World) []RadioTransmission {
 }
 
 func (ac *Aircraft) InitializeArrival(w *World, arrivalGroup string,
-	arrivalGroupIndex int, arrivalHandoffController string, goAround bool) error {
+	arrivalGroupIndex int, arrivalHandoffController string) error {
 	arr := &w.ArrivalGroups[arrivalGroup][arrivalGroupIndex]
 	ac.STAR = arr.STAR
 	ac.ArrivalGroup = arrivalGroup
@@ -418,11 +475,6 @@ func (ac *Aircraft) InitializeArrival(w *World, arrivalGroup string,
 		ac.FlightPlan.Route = "/. " + arr.STAR
 	}
 
-	if goAround {
-		d := 0.1 + .6*rand.Float32()
-		ac.GoAroundDistance = &d
-	}
-
 	nav := MakeArrivalNav(w, arr, *ac.FlightPlan, perf)
 	if nav == nil {
 		return fmt.Errorf("error initializing Nav")
@@ -461,6 +513,7 @@ func (ac *Aircraft) InitializeDeparture(w *World, ap *Airport, departureAirport
 	}
 	ac.SecondaryScratchpad = dep.SecondaryScratchpad
 	ac.Exit = dep.Exit
+	ac.DepartureRunway = runway
 
 	if dep.Altitude == 0 {
 		ac.FlightPlan.Altitude = PlausibleFinalAltitude(w, ac.FlightPlan, perf)
@@ -502,7 +555,16 @@ func (ac *Aircraft) InitializeDeparture(w *World, ap *Airport, departureAirport
 }
 
 func (ac *Aircraft) NavSummary() string {
-	return ac.Nav.Summary(*ac.FlightPlan)
+	s := ac.Nav.Summary(*ac.FlightPlan)
+	for _, cc := range ac.ConditionalClearances {
+		s += "\n" + cc.String()
+	}
+	if ac.VisualSeparation != "" {
+		s += "\nMaintaining visual separation from " + ac.VisualSeparation
+	} else if len(ac.TrafficInSight) > 0 {
+		s += "\nTraffic in sight: " + strings.Join(ac.TrafficInSight, ", ")
+	}
+	return s
 }
 
 func (ac *Aircraft) ContactMessage(reportingPoints []ReportingPoint) string {
@@ -528,8 +590,26 @@ func (ac *Aircraft) Position() Point2LL {
 	return ac.Nav.FlightState.Position
 }
 
+// Altitude returns the aircraft's true altitude; see Nav.TrueAltitude.
 func (ac *Aircraft) Altitude() float32 {
-	return ac.Nav.FlightState.Altitude
+	return ac.Nav.TrueAltitude()
+}
+
+// PressureAltitude returns the altitude reported by the aircraft's
+// transponder.
+func (ac *Aircraft) PressureAltitude() float32 {
+	return ac.Nav.PressureAltitude()
+}
+
+// IndicatedAltitude returns the altitude that assigned altitudes and
+// altitude restrictions refer to: what the aircraft's altimeter shows
+// below the transition altitude and its pressure altitude at or above
+// it.
+func (ac *Aircraft) IndicatedAltitude() float32 {
+	if alt := ac.Nav.FlightState.Altitude; alt < TransitionAltitude {
+		return alt
+	}
+	return ac.PressureAltitude()
 }
 
 func (ac *Aircraft) Heading() float32 {
@@ -96,6 +96,19 @@ func (ar *ApproachRegion) referenceLinePoint(dist, nmPerLongitude, magneticVaria
 	return add2f(pref, scale2f(v, dist))
 }
 
+// setRunwayDefaults sets the reference point and heading to the runway
+// threshold and the runway heading if they weren't specified. As with the
+// heading given in scenario files, the reference line extends from the
+// threshold in the opposite direction.
+func (ar *ApproachRegion) setRunwayDefaults(r Runway) {
+	if ar.ReferencePoint.IsZero() {
+		ar.ReferencePoint = r.Threshold
+	}
+	if ar.ReferenceLineHeading == 0 {
+		ar.ReferenceLineHeading = r.Heading
+	}
+}
+
 func (ar *ApproachRegion) NearPoint(nmPerLongitude, magneticVariation float32) [2]float32 {
 	return ar.referenceLinePoint(ar.NearDistance, nmPerLongitude, magneticVariation)
 }
@@ -336,6 +349,18 @@ func (ap *Airport) PostDeserialize(icao string, sg *ScenarioGroup, e *ErrorLogge
 			e.ErrorString("Must have \"runway\" in approach's \"full_name\"")
 		}
 
+		// ILS and localizer approaches use the localizer course (and
+		// glideslope, for ILSs) from the CIFP rather than the line
+		// through the final fixes.
+		if appr.Type == ILSApproach && (appr.Id == "" || appr.Id[0] == 'I' || appr.Id[0] == 'L') {
+			if loc, ok := database.Airports[icao].Localizers[cleanRunway(appr.Runway)]; ok {
+				if appr.Id != "" && appr.Id[0] == 'L' {
+					loc.GlideslopeAngle = 0
+				}
+				appr.Localizer = &loc
+			}
+		}
+
 		if appr.TowerController == "" {
 			appr.TowerController = icao[1:] + "_TWR"
 			if _, ok := sg.ControlPositions[appr.TowerController]; !ok {
@@ -479,21 +504,15 @@ func (ap *Airport) PostDeserialize(icao string, sg *ScenarioGroup, e *ErrorLogge
 
 		sawExit := false
 		for _, fix := range strings.Fields(dep.Route) {
-			sawExit = sawExit || fix == depExit
-			wp := []Waypoint{Waypoint{Fix: fix}}
-			// Best effort only to find waypoint locations; this will fail
-			// for airways, international ones not in the FAA database,
-			// latlongs in the flight plan, etc.
 			if fix == depExit {
-				sg.InitializeWaypointLocations(wp, e)
-			} else {
-				// nil here so errors aren't logged if it's not the actual exit.
-				sg.InitializeWaypointLocations(wp, nil)
-			}
-			if !wp[0].Location.IsZero() {
-				ap.Departures[i].RouteWaypoints = append(ap.Departures[i].RouteWaypoints, wp[0])
+				sawExit = true
+				sg.InitializeWaypointLocations([]Waypoint{Waypoint{Fix: fix}}, e)
 			}
 		}
+		// Best effort only to find the waypoints; this will fail for
+		// international fixes not in the FAA database, airways that
+		// aren't in the CIFP, etc.
+		ap.Departures[i].RouteWaypoints, _ = ExpandRoute(dep.Route, icao, dep.Destination, sg.locate)
 		if !sawExit {
 			e.ErrorString("exit not found in departure route")
 		}
@@ -510,9 +529,11 @@ func (ap *Airport) PostDeserialize(icao string, sg *ScenarioGroup, e *ErrorLogge
 		e.Push(rwy + " region")
 		def.Runway = rwy
 
-		if _, ok := LookupRunway(icao, rwy); !ok {
+		if r, ok := LookupRunway(icao, rwy); !ok {
 			e.ErrorString("runway \"%s\" is unknown. Options: %s", rwy,
 				database.Airports[icao].ValidRunways())
+		} else {
+			def.setRunwayDefaults(r)
 		}
 
 		if !slices.ContainsFunc(ap.ConvergingRunways,
@@ -595,7 +616,12 @@ func (ap *Airport) PostDeserialize(icao string, sg *ScenarioGroup, e *ErrorLogge
 
 		if vol.Threshold.IsZero() { // the location is set directly for default volumes
 			if vol.ThresholdString == "" {
-				e.ErrorString("\"runway_threshold\" not specified.")
+				// Default to the runway's threshold from the CIFP.
+				if r, ok := LookupRunway(icao, rwy); ok {
+					vol.Threshold = r.Threshold
+				} else {
+					e.ErrorString("\"runway_threshold\" not specified.")
+				}
 			} else {
 				var ok bool
 				if vol.Threshold, ok = sg.locate(vol.ThresholdString); !ok {
@@ -603,6 +629,11 @@ func (ap *Airport) PostDeserialize(icao string, sg *ScenarioGroup, e *ErrorLogge
 				}
 			}
 		}
+		if vol.Heading == 0 {
+			if r, ok := LookupRunway(icao, rwy); ok {
+				vol.Heading = r.Heading
+			}
+		}
 
 		// Defaults if things are not specified
 		if vol.MaxHeadingDeviation == 0 {
@@ -706,17 +737,46 @@ type Approach struct {
 	Runway          string          `json:"runway"`
 	Waypoints       []WaypointArray `json:"waypoints"`
 	TowerController string          `json:"tower_controller"`
+	Localizer       *Localizer      `json:"-"` // set during deserialization
 }
 
 func (ap *Approach) Line() [2]Point2LL {
 	// assume we have at least one set of waypoints and that it has >= 2 waypoints!
 	wp := ap.Waypoints[0]
+	n := len(wp)
+
+	if loc := ap.Localizer; loc != nil {
+		// Follow the localizer course, ending at the point on it abeam
+		// the threshold; offset localizers don't pass through it.
+		nmPerLongitude := nmPerLatitude * cos(radians(loc.Location[1]))
+		dir := [2]float32{sin(radians(loc.TrueCourse)), cos(radians(loc.TrueCourse))}
+		p := ll2nm(loc.Location, nmPerLongitude)
+		thr := ll2nm(wp[n-1].Location, nmPerLongitude)
+		p1 := add2f(p, scale2f(dir, dot(sub2f(thr, p), dir)))
+		p0 := sub2f(p1, scale2f(dir, 10))
+		return [2]Point2LL{nm2ll(p0, nmPerLongitude), nm2ll(p1, nmPerLongitude)}
+	}
 
 	// use the last two waypoints of the approach
-	n := len(wp)
 	return [2]Point2LL{wp[n-2].Location, wp[n-1].Location}
 }
 
+// GlideslopeAltitude returns the altitude of the approach's glideslope at
+// the given point, if it has one.
+func (ap *Approach) GlideslopeAltitude(p Point2LL) (float32, bool) {
+	loc := ap.Localizer
+	if loc == nil || loc.GlideslopeAngle == 0 {
+		return 0, false
+	}
+	nmPerLongitude := nmPerLatitude * cos(radians(loc.Location[1]))
+	line := ap.Line()
+	p0, p1 := ll2nm(line[0], nmPerLongitude), ll2nm(line[1], nmPerLongitude)
+	// Distance to the threshold along the localizer course.
+	dist := dot(sub2f(p1, ll2nm(p, nmPerLongitude)), normalize2f(sub2f(p1, p0)))
+	return float32(loc.ThresholdElevation+loc.ThresholdCrossingHeight) +
+		dist*NauticalMilesToFeet*tan(radians(loc.GlideslopeAngle)), true
+}
+
 func (ap *Approach) Heading(nmPerLongitude, magneticVariation float32) float32 {
 	p := ap.Line()
 	return headingp2ll(p[0], p[1], nmPerLongitude, magneticVariation)
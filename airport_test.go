@@ -0,0 +1,50 @@
+// airport_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+)
+
+func TestApproachRegionRunwayDefaults(t *testing.T) {
+	const nmPerLongitude, magneticVariation = 45, 13
+	rwy := Runway{Id: "31L", Heading: 310, Threshold: Point2LL{-73.76, 40.64}}
+
+	region := func(ref Point2LL, hdg float32) *ApproachRegion {
+		return &ApproachRegion{
+			Runway:               "31L",
+			ReferencePoint:       ref,
+			ReferenceLineHeading: hdg,
+			ReferenceLineLength:  12,
+			NearDistance:         1,
+			NearHalfWidth:        0.5,
+			FarHalfWidth:         2,
+			RegionLength:         10,
+		}
+	}
+	explicit := region(rwy.Threshold, 310)
+	defaulted := region(Point2LL{}, 0)
+	defaulted.setRunwayDefaults(rwy)
+
+	eline, equad := explicit.GetLateralGeometry(nmPerLongitude, magneticVariation)
+	dline, dquad := defaulted.GetLateralGeometry(nmPerLongitude, magneticVariation)
+	for i := range eline {
+		if d := nmdistance2ll(eline[i], dline[i]); d > 0.01 {
+			t.Errorf("reference line point %d differs by %.2fnm", i, d)
+		}
+	}
+	for i := range equad {
+		if d := nmdistance2ll(equad[i], dquad[i]); d > 0.01 {
+			t.Errorf("region corner %d differs by %.2fnm", i, d)
+		}
+	}
+
+	// The region is on the approach side of the threshold: flying the
+	// runway heading from its far end leads to the threshold.
+	far := nm2ll(defaulted.FarPoint(nmPerLongitude, magneticVariation), nmPerLongitude)
+	if hdg := headingp2ll(far, rwy.Threshold, nmPerLongitude, magneticVariation); headingDifference(hdg, 310) > 1 {
+		t.Errorf("heading from the far end of the region to the threshold is %.1f, expected 310", hdg)
+	}
+}
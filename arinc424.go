@@ -40,6 +40,21 @@ func parseAltitude(s []byte) int {
 	return parseInt(s)
 }
 
+// parseOptionalInt returns zero for blank fields.
+func parseOptionalInt(s []byte) int {
+	if empty(s) {
+		return 0
+	}
+	return parseInt([]byte(strings.TrimSpace(string(s))))
+}
+
+func parseOptionalAltitude(s []byte) int {
+	if empty(s) {
+		return 0
+	}
+	return parseAltitude([]byte(strings.TrimSpace(string(s))))
+}
+
 func printColumnHeader() {
 	for i := 0; i < ARINC424LineLength/10; i++ {
 		fmt.Printf("         |")
@@ -47,12 +62,16 @@ func printColumnHeader() {
 	fmt.Printf("\n")
 }
 
-func ParseARINC424(file []byte) (map[string]FAAAirport, map[string]Navaid, map[string]Fix) {
+func ParseARINC424(file []byte) (map[string]FAAAirport, map[string]Navaid, map[string]Fix, map[string][]Airway, map[string][]Hold) {
 	start := time.Now()
 
 	airports := make(map[string]FAAAirport)
 	navaids := make(map[string]Navaid)
 	fixes := make(map[string]Fix)
+	airways := make(map[string][]Airway)
+	holds := make(map[string][]Hold)
+	// Airways that are in the middle of a continuous sequence of fixes.
+	continuingAirways := make(map[string]bool)
 
 	parseLLDigits := func(d, m, s []byte) float32 {
 		deg, err := strconv.Atoi(string(d))
@@ -189,8 +208,46 @@ func ParseARINC424(file []byte) (map[string]FAAAirport, map[string]Navaid, map[s
 					Id:       id,
 					Location: parseLatLong(line[32:41], line[41:51]),
 				}
+
+			case 'R': // enroute airway 4.1.6
+				continuation := line[38]
+				if continuation != '0' && continuation != '1' {
+					break
+				}
+				id := strings.TrimSpace(string(line[13:18]))
+				fix := strings.TrimSpace(string(line[29:34]))
+
+				if !continuingAirways[id] {
+					airways[id] = append(airways[id], Airway{Name: id})
+				}
+				n := len(airways[id])
+				airways[id][n-1].Fixes = append(airways[id][n-1].Fixes, fix)
+				// Waypoint description code, 5.17: an "E" in the second
+				// column marks the end of a continuous airway.
+				continuingAirways[id] = line[40] != 'E'
+
+			case 'P': // holding pattern 4.1.5
+				continuation := line[38]
+				if continuation != '0' && continuation != '1' {
+					break
+				}
+				fix := strings.TrimSpace(string(line[29:34]))
+				hold := Hold{
+					Fix:             fix,
+					InboundCourse:   float32(parseOptionalInt(line[39:43])) / 10,
+					TurnDirection:   Select[TurnMethod](line[43] == 'L', TurnLeft, TurnRight),
+					LegLengthNM:     float32(parseOptionalInt(line[44:47])) / 10,
+					LegMinutes:      float32(parseOptionalInt(line[47:49])) / 10,
+					MinimumAltitude: parseOptionalAltitude(line[49:54]),
+					MaximumAltitude: parseOptionalAltitude(line[54:59]),
+					Speed:           parseOptionalInt(line[59:62]),
+				}
+				if hold.LegLengthNM == 0 && hold.LegMinutes == 0 {
+					// Standard one-minute legs below 14,000'.
+					hold.LegMinutes = Select[float32](hold.MinimumAltitude > 14000, 1.5, 1)
+				}
+				holds[fix] = append(holds[fix], hold)
 			}
-			// TODO: holding patterns, airways, etc...
 
 		case 'H': // Heliports
 			subsection := line[12]
@@ -227,6 +284,16 @@ func ParseARINC424(file []byte) (map[string]FAAAirport, map[string]Navaid, map[s
 				fixes[id] = Fix{Id: id, Location: location}
 
 			case 'D': // SID 4.1.9
+				recs := matchingSSARecs(line)
+				id := recs[0].id
+				if sid := parseSID(recs); sid != nil {
+					if airports[icao].SIDs == nil {
+						ap := airports[icao]
+						ap.SIDs = make(map[string]SID)
+						airports[icao] = ap
+					}
+					airports[icao].SIDs[id] = *sid
+				}
 
 			case 'E': // STAR 4.1.9
 				recs := matchingSSARecs(line)
@@ -281,24 +348,133 @@ func ParseARINC424(file []byte) (map[string]FAAAirport, map[string]Navaid, map[s
 				rwy = strings.TrimPrefix(rwy, "0")
 				rwy = strings.TrimSpace(rwy)
 
-				ap := airports[icao]
-				ap.Runways = append(ap.Runways, Runway{
+				r := Runway{
 					Id:        rwy,
-					Heading:   float32(parseInt(line[27:31])) / 10,
 					Threshold: parseLatLong(line[32:41], line[41:51]),
 					Elevation: parseInt(line[66:71]),
-				})
+					Length:    parseOptionalInt(line[22:27]),
+				}
+				if line[30] == 'T' {
+					// Runways in areas of unreliable magnetic variation
+					// have true bearings, e.g. "045T".
+					r.TrueHeading = float32(parseOptionalInt(line[27:30]))
+					r.Heading = r.TrueHeading
+				} else {
+					r.Heading = float32(parseInt(line[27:31])) / 10
+				}
+
+				ap := airports[icao]
+				ap.Runways = append(ap.Runways, r)
+				airports[icao] = ap
+
+			case 'I': // localizer and glideslope 4.1.11
+				continuation := line[21]
+				if continuation != '0' && continuation != '1' {
+					continue
+				}
+				rwy := strings.TrimSpace(string(line[27:32]))
+				rwy = strings.TrimPrefix(strings.TrimPrefix(rwy, "RW"), "0")
+
+				loc := Localizer{
+					Id:       strings.TrimSpace(string(line[13:17])),
+					Runway:   rwy,
+					Location: parseLatLong(line[32:41], line[41:51]),
+				}
+				// Station declination, e.g. "W0130"; easterly variation
+				// is added to the magnetic course to get the true course.
+				decl := float32(parseOptionalInt(line[91:95])) / 10
+				if line[90] == 'W' {
+					decl = -decl
+				}
+				if line[54] == 'T' {
+					loc.TrueCourse = float32(parseOptionalInt(line[51:54]))
+					loc.Course = NormalizeHeading(loc.TrueCourse - decl)
+				} else {
+					loc.Course = float32(parseOptionalInt(line[51:55])) / 10
+					loc.TrueCourse = NormalizeHeading(loc.Course + decl)
+				}
+				if !empty(line[55:74]) {
+					loc.GlideslopeLocation = parseLatLong(line[55:64], line[64:74])
+					loc.GlideslopeAngle = float32(parseOptionalInt(line[87:90])) / 100
+					loc.ThresholdCrossingHeight = parseOptionalInt(line[95:97])
+				}
+
+				ap := airports[icao]
+				if ap.Localizers == nil {
+					ap.Localizers = make(map[string]Localizer)
+				}
+				ap.Localizers[rwy] = loc
+				airports[icao] = ap
+
+			case 'S': // MSA 4.1.20
+				continuation := line[38]
+				if continuation != '0' && continuation != '1' {
+					continue
+				}
+				msa := MSA{Fix: strings.TrimSpace(string(line[13:18]))}
+				// Up to seven sectors, each with the bearings that bound
+				// it, its altitude in hundreds of feet, and its radius.
+				for i := 42; i+11 <= 119; i += 11 {
+					if empty(line[i : i+11]) {
+						break
+					}
+					msa.Sectors = append(msa.Sectors, MSASector{
+						FromBearing: float32(parseOptionalInt(line[i : i+3])),
+						ToBearing:   float32(parseOptionalInt(line[i+3 : i+6])),
+						Altitude:    100 * parseOptionalInt(line[i+6:i+9]),
+						Radius:      float32(parseOptionalInt(line[i+9 : i+11])),
+					})
+				}
+				ap := airports[icao]
+				ap.MSAs = append(ap.MSAs, msa)
 				airports[icao] = ap
 			}
 		}
 
 	}
 
+	for icao, ap := range airports {
+		// The CIFP only has magnetic bearings for most runways, so find
+		// the true heading using the opposite runway's threshold.
+		for i, r := range ap.Runways {
+			if r.TrueHeading != 0 {
+				continue
+			}
+			if opp, ok := oppositeRunway(ap.Runways, r.Id); ok {
+				nmPerLongitude := nmPerLatitude * cos(radians(r.Threshold[1]))
+				ap.Runways[i].TrueHeading = headingp2ll(r.Threshold, opp.Threshold, nmPerLongitude, 0)
+			}
+		}
+
+		for rwy, loc := range ap.Localizers {
+			if idx := slices.IndexFunc(ap.Runways, func(r Runway) bool { return r.Id == rwy }); idx != -1 {
+				loc.ThresholdElevation = ap.Runways[idx].Elevation
+				ap.Localizers[rwy] = loc
+			}
+		}
+
+		for i, msa := range ap.MSAs {
+			if rwy, ok := strings.CutPrefix(msa.Fix, "RW"); ok {
+				rwy = strings.TrimPrefix(rwy, "0")
+				if idx := slices.IndexFunc(ap.Runways, func(r Runway) bool { return r.Id == rwy }); idx != -1 {
+					ap.MSAs[i].Location = ap.Runways[idx].Threshold
+				}
+			} else if n, ok := navaids[msa.Fix]; ok {
+				ap.MSAs[i].Location = n.Location
+			} else if f, ok := fixes[msa.Fix]; ok {
+				ap.MSAs[i].Location = f.Location
+			} else if msa.Fix == icao || msa.Fix == strings.TrimPrefix(icao, "K") {
+				ap.MSAs[i].Location = ap.Location
+			}
+		}
+		airports[icao] = ap
+	}
+
 	if false {
 		fmt.Printf("parsed ARINC242 in %s\n", time.Since(start))
 	}
 
-	return airports, navaids, fixes
+	return airports, navaids, fixes, airways, holds
 }
 
 func tidyFAAApproachId(id string) string {
@@ -586,6 +762,45 @@ func parseSTAR(recs []ssaRecord) *STAR {
 	return star
 }
 
+// parseSID only records the fixes along the SID's common route and
+// enroute transitions; SIDs' initial legs are generally headings and
+// altitudes that are specified in the scenarios.
+func parseSID(recs []ssaRecord) *SID {
+	transitions := make(map[string]WaypointArray)
+	for _, r := range recs {
+		if (r.continuation != '0' && r.continuation != '1') || r.fix == "" {
+			continue
+		}
+		if n := len(transitions[r.transition]); n > 0 && transitions[r.transition][n-1].Fix == r.fix {
+			continue
+		}
+		transitions[r.transition] = append(transitions[r.transition], Waypoint{Fix: r.fix})
+	}
+
+	common, ok := transitions[""]
+	if !ok {
+		common = transitions["ALL"]
+	}
+
+	sid := &SID{Transitions: make(map[string]WaypointArray)}
+	sid.Transitions[""] = common
+	for t, wps := range transitions {
+		if t == "" || t == "ALL" || (len(t) > 3 && t[:2] == "RW" && t[2] >= '0' && t[2] <= '9') {
+			// Skip the common route and runway transitions
+			continue
+		}
+		if n := len(common); n > 0 && common[n-1].Fix == wps[0].Fix {
+			sid.Transitions[t] = append(DuplicateSlice(common), wps[1:]...)
+		} else {
+			sid.Transitions[t] = append(DuplicateSlice(common), wps...)
+		}
+	}
+	if len(sid.Transitions) == 1 && len(common) == 0 {
+		return nil
+	}
+	return sid
+}
+
 func spliceTransition(tr WaypointArray, base WaypointArray) WaypointArray {
 	idx := slices.IndexFunc(base, func(wp Waypoint) bool { return wp.Fix == tr[len(tr)-1].Fix })
 	if idx == -1 {
@@ -53,6 +53,15 @@ type AudioEngine struct {
 
 	effects [AudioNumTypes]AudioEffect
 
+	// Synthesized speech, played one utterance at a time.
+	speech       [][]byte
+	speechOffset int
+
+	// Scratch buffers for audioCallback, kept so that it doesn't
+	// allocate each time it is called.
+	mixBuf   []byte
+	accumBuf []int
+
 	mu sync.Mutex
 }
 
@@ -101,19 +110,33 @@ func (a *AudioEngine) StopPlayContinuous(e AudioType) {
 	a.mu.Unlock()
 }
 
+// PlaySpeech queues the given 16-bit mono PCM to be played after any
+// speech that is already queued has finished.
+func (a *AudioEngine) PlaySpeech(pcm []byte) {
+	a.mu.Lock()
+	a.speech = append(a.speech, pcm[:len(pcm)&^1])
+	a.mu.Unlock()
+}
+
 //export audioCallback
 func audioCallback(user unsafe.Pointer, ptr *C.uint8, size C.int) {
 	n := int(size)
 	out := unsafe.Slice(ptr, n)
 	a := &globalConfig.Audio
 
-	accum := make([]int, n/2)
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	if len(a.mixBuf) < n {
+		a.mixBuf = make([]byte, n)
+		a.accumBuf = make([]int, n/2)
+	}
+	buf, accum := a.mixBuf[:n], a.accumBuf[:n/2]
+	clear(accum)
+
 	for i := range a.effects {
 		e := &a.effects[i]
-		buf := make([]byte, n)
+		clear(buf)
 		bread := buf
 		for len(bread) > 0 && (e.playContinuous || e.playOnceCount > 0) {
 			nc := copy(bread, e.pcm[e.playOffset:])
@@ -133,6 +156,21 @@ func audioCallback(user unsafe.Pointer, ptr *C.uint8, size C.int) {
 		}
 	}
 
+	clear(buf)
+	for bread := buf; len(bread) > 0 && len(a.speech) > 0; {
+		nc := copy(bread, a.speech[0][a.speechOffset:])
+		a.speechOffset += nc
+		bread = bread[nc:]
+
+		if a.speechOffset == len(a.speech[0]) {
+			a.speech = a.speech[1:]
+			a.speechOffset = 0
+		}
+	}
+	for i := 0; i < len(buf)/2; i++ {
+		accum[i] += int(int16(buf[2*i]) | int16(buf[2*i+1])<<8)
+	}
+
 	for i := 0; i < n/2; i++ {
 		v := int16(clamp(accum[i], -32768, 32767))
 		out[2*i] = C.uint8(v & 0xff)
@@ -186,9 +224,10 @@ func (a *AudioEngine) DrawUI() {
 
 	uiStartDisable(!a.AudioEnabled)
 	// Not all of the ones available in the engine are used, so only offer these up:
-	for _, i := range []AudioType{AudioConflictAlert, AudioInboundHandoff, AudioHandoffAccepted, AudioCommandError} {
+	for _, i := range []AudioType{AudioConflictAlert, AudioModeCIntruder, AudioInboundHandoff, AudioHandoffAccepted,
+		AudioCommandError} {
 		if imgui.Checkbox(AudioType(i).String(), &a.EffectEnabled[i]) && a.EffectEnabled[i] {
-			n := Select(i == AudioConflictAlert, 5, 1)
+			n := Select(i == AudioConflictAlert || i == AudioModeCIntruder, 5, 1)
 			for j := 0; j < n; j++ {
 				a.PlayOnce(i)
 			}
@@ -0,0 +1,289 @@
+// autoapproach.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// When a multi-controller sim is started with AutomateApproach set,
+// approach positions in the split that aren't staffed by a human are
+// worked by the code here rather than being covered by their backup
+// controller: arrivals are told which approach to expect, vectored onto
+// final and spaced using speed control and extended downwinds, cleared
+// for the approach, and then sent to the tower.
+
+// How often the automation revisits each aircraft it's working.
+const approachAutomationInterval = 5 * time.Second
+
+// isAutomatablePosition returns true if the given multi-controller
+// position only works arrivals and thus can be run by the approach
+// automation if nobody is signed in to it.
+func isAutomatablePosition(mc *MultiUserController) bool {
+	return mc != nil && !mc.Primary && len(mc.Arrivals) > 0 && len(mc.Departures) == 0
+}
+
+// isAutomated returns true if the given position is currently being
+// worked by the approach automation.
+func (s *Sim) isAutomated(callsign string) bool {
+	if !s.AutomateApproach || s.World == nil || s.controllerIsSignedIn(callsign) {
+		return false
+	}
+	mc, ok := s.World.MultiControllers[callsign]
+	return ok && isAutomatablePosition(mc)
+}
+
+// restoreAutomatedPosition is called when a human controller signs off
+// from the given position; if it can be automated, it's added back to
+// the world's controllers as a virtual controller.
+func (s *Sim) restoreAutomatedPosition(callsign string) {
+	if ctrl, ok := s.SignOnPositions[callsign]; ok && s.isAutomated(callsign) {
+		vctrl := *ctrl
+		vctrl.IsHuman = false
+		s.World.Controllers[callsign] = &vctrl
+	}
+}
+
+// headingCommand converts the given heading to the integer heading in
+// [1,360] that a controller would issue.
+func headingCommand(h float32) int {
+	if hdg := int(NormalizeHeading(h) + 0.5); hdg == 0 {
+		return 360
+	} else {
+		return min(hdg, 360)
+	}
+}
+
+// runApproachAutomation is called once a second from updateState.
+func (s *Sim) runApproachAutomation() {
+	if !s.AutomateApproach {
+		return
+	}
+	if s.automationNextAction == nil {
+		s.automationNextAction = make(map[string]time.Time)
+	}
+
+	for callsign, ac := range s.World.Aircraft {
+		if ac.IsDeparture() || ac.GotContactTower || !s.isAutomated(ac.ControllingController) {
+			continue
+		}
+		if t, ok := s.automationNextAction[callsign]; ok && s.SimTime.Before(t) {
+			continue
+		}
+		s.automationNextAction[callsign] = s.SimTime.Add(approachAutomationInterval)
+
+		if rt := s.automateArrival(ac); len(rt) > 0 {
+			PostRadioEvents(callsign, rt, s)
+		}
+	}
+
+	for callsign := range s.automationNextAction {
+		if _, ok := s.World.Aircraft[callsign]; !ok {
+			delete(s.automationNextAction, callsign)
+		}
+	}
+}
+
+// chooseApproach returns the id of the approach that the automation will
+// use for the given arrival: an approach to one of the scenario's
+// arrival runways at its airport, preferring an ILS.
+func (s *Sim) chooseApproach(ac *Aircraft) string {
+	ap := s.World.GetAirport(ac.FlightPlan.ArrivalAirport)
+	if ap == nil {
+		return ""
+	}
+
+	best, bestScore := "", -1
+	for _, id := range SortedMapKeys(ap.Approaches) {
+		appr := ap.Approaches[id]
+		if len(appr.Waypoints) == 0 || len(appr.Waypoints[0]) < 2 {
+			continue
+		}
+		score := 0
+		if slices.ContainsFunc(s.World.ArrivalRunways, func(r ScenarioGroupArrivalRunway) bool {
+			return r.Airport == ac.FlightPlan.ArrivalAirport && r.Runway == appr.Runway
+		}) {
+			score += 2
+		}
+		if appr.Type == ILSApproach {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = id, score
+		}
+	}
+	return best
+}
+
+// interceptAltitude returns the altitude at which aircraft should be
+// established on the approach's final course.
+func interceptAltitude(appr *Approach, elevation float32) float32 {
+	for _, wp := range appr.Waypoints[0] {
+		if wp.FAF && wp.AltitudeRestriction != nil {
+			if r := wp.AltitudeRestriction.Range; r[0] != 0 {
+				return r[0]
+			} else if r[1] != 0 {
+				return r[1]
+			}
+		}
+	}
+	return 100 * float32(int(elevation+2500+99)/100)
+}
+
+// distanceToThreshold returns an estimate of how far the aircraft has to
+// fly to land.
+func distanceToThreshold(ac *Aircraft) float32 {
+	if d, err := ac.Nav.distanceToEndOfApproach(); err == nil {
+		return d
+	}
+	line := ac.Nav.Approach.Assigned.Line()
+	return nmdistance2ll(ac.Position(), line[1])
+}
+
+// needsSpacing returns true if the given aircraft would be too close to
+// the aircraft preceding it to the same runway if it turned onto final
+// with the given distance left to fly.
+func (s *Sim) needsSpacing(ac *Aircraft, dist float32) bool {
+	rwy := ac.Nav.Approach.Assigned.Runway
+	for _, other := range s.World.Aircraft {
+		if other == ac || other.IsDeparture() || other.Nav.Approach.Assigned == nil ||
+			other.FlightPlan.ArrivalAirport != ac.FlightPlan.ArrivalAirport ||
+			other.Nav.Approach.Assigned.Runway != rwy {
+			continue
+		}
+
+		// Only consider aircraft that are established, or about to be.
+		if !other.Nav.Approach.Cleared && !other.GotContactTower {
+			continue
+		}
+
+		od := distanceToThreshold(other)
+		if od > dist {
+			continue
+		}
+
+		sep := max(CWTApproachSeparation(other.Nav.Perf.Category.CWT, ac.Nav.Perf.Category.CWT), 3)
+		// Leave a buffer so that the tower doesn't end up sending it around.
+		if dist-od < sep+1.5 {
+			return true
+		}
+	}
+	return false
+}
+
+// automateArrival decides what to tell the given arrival and returns the
+// resulting radio transmissions.
+func (s *Sim) automateArrival(ac *Aircraft) []RadioTransmission {
+	w := s.World
+	nav := &ac.Nav
+	lg := s.lg.With(slog.String("callsign", ac.Callsign))
+
+	if nav.Approach.Assigned == nil {
+		id := s.chooseApproach(ac)
+		if id == "" {
+			return nil
+		}
+		lg.Info("automation: expect approach", slog.String("approach", id))
+		return ac.ExpectApproach(id, w, lg)
+	}
+	appr := nav.Approach.Assigned
+
+	if nav.Approach.Cleared {
+		// Send it to the tower once it's on final.
+		if d, err := nav.distanceToEndOfApproach(); err == nil && d < 8 {
+			lg.Info("automation: contact tower")
+			return ac.ContactTower(w)
+		}
+		return nil
+	}
+
+	var rt []RadioTransmission
+
+	// Descend to the intercept altitude when getting close.
+	elevation := ac.ArrivalAirportElevation()
+	thresholdDist := nmdistance2ll(ac.Position(), appr.Line()[1])
+	alt := interceptAltitude(appr, elevation)
+	if thresholdDist > 20 {
+		alt = max(alt, 1000*float32(int(elevation+4000+999)/1000))
+	}
+	if (nav.Altitude.Assigned == nil && nav.Heading.Assigned != nil) ||
+		(nav.Altitude.Assigned != nil && *nav.Altitude.Assigned > alt) {
+		rt = append(rt, ac.AssignAltitude(int(alt), false)...)
+	}
+
+	// If the aircraft's route joins the approach, let it fly it; clear
+	// it once the next fix is on the approach.
+	_, onHeading := nav.AssignedHeading()
+	approachFix := func(fix string) bool {
+		return slices.ContainsFunc(appr.Waypoints, func(wpa WaypointArray) bool {
+			return slices.ContainsFunc(wpa, func(wp Waypoint) bool { return wp.Fix == fix })
+		})
+	}
+	if !onHeading && slices.ContainsFunc(nav.Waypoints, func(wp Waypoint) bool { return approachFix(wp.Fix) }) {
+		if approachFix(nav.Waypoints[0].Fix) {
+			lg.Info("automation: cleared approach on route")
+			return append(rt, ac.ClearedApproach(nav.Approach.AssignedId, w)...)
+		}
+		return rt
+	}
+
+	// Otherwise vector it. Work in nm coordinates with the threshold at
+	// the origin.
+	nmPerLongitude, magvar := nav.FlightState.NmPerLongitude, nav.FlightState.MagneticVariation
+	line := appr.Line()
+	p1 := ll2nm(line[1], nmPerLongitude)
+	dir := normalize2f(sub2f(p1, ll2nm(line[0], nmPerLongitude)))
+	perp := [2]float32{-dir[1], dir[0]}
+	rel := sub2f(ll2nm(ac.Position(), nmPerLongitude), p1)
+	along, cross := dot(rel, dir), dot(rel, perp)
+	side := Select[float32](cross < 0, -1, 1)
+	finalHeading := appr.Heading(nmPerLongitude, magvar)
+
+	// Distance it would fly if it turned onto final now.
+	dist := -along + abs(cross)
+
+	if along < -8 && abs(cross) < 6 {
+		// In position to turn onto final.
+		if s.needsSpacing(ac, dist) {
+			// Extend the downwind until there's room.
+			if hdg, ok := nav.AssignedHeading(); !ok || headingDifference(hdg, OppositeHeading(finalHeading)) > 5 {
+				lg.Info("automation: extending for spacing")
+				rt = append(rt, ac.AssignHeading(headingCommand(OppositeHeading(finalHeading)), TurnClosest)...)
+			}
+			return rt
+		}
+
+		hdg := NormalizeHeading(finalHeading + side*30)
+		lg.Info("automation: turning onto final", slog.Float64("heading", float64(hdg)))
+		rt = append(rt, ac.AssignHeading(headingCommand(hdg), TurnClosest)...)
+		rt = append(rt, ac.ClearedApproach(nav.Approach.AssignedId, w)...)
+		return rt
+	}
+
+	// Vector towards a gate 12nm out and 4nm to the side of the final
+	// course that the aircraft is already on.
+	gate := add2f(add2f(p1, scale2f(dir, -12)), scale2f(perp, 4*side))
+	hdg := headingp2ll(ac.Position(), nm2ll(gate, nmPerLongitude), nmPerLongitude, magvar)
+	if cur, ok := nav.AssignedHeading(); !ok || headingDifference(cur, hdg) > 10 {
+		lg.Info("automation: vectoring to final", slog.Float64("heading", float64(hdg)))
+		rt = append(rt, ac.AssignHeading(headingCommand(hdg), TurnClosest)...)
+	}
+
+	// Slow down as it gets closer, or earlier if it'll need spacing.
+	spd := 0
+	if thresholdDist < 15 || s.needsSpacing(ac, dist) {
+		spd = 180
+	} else if thresholdDist < 30 {
+		spd = 210
+	}
+	if spd != 0 && float32(spd) >= nav.Perf.Speed.Landing &&
+		(nav.Speed.Assigned == nil || *nav.Speed.Assigned > float32(spd)) {
+		rt = append(rt, ac.AssignSpeed(spd, false)...)
+	}
+
+	return rt
+}
@@ -0,0 +1,86 @@
+// autoapproach_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+)
+
+func TestAutomatablePosition(t *testing.T) {
+	for _, test := range []struct {
+		mc          *MultiUserController
+		automatable bool
+	}{
+		{mc: nil, automatable: false},
+		{mc: &MultiUserController{Arrivals: []string{"A"}}, automatable: true},
+		{mc: &MultiUserController{Primary: true, Arrivals: []string{"A"}}, automatable: false},
+		{mc: &MultiUserController{Arrivals: []string{"A"}, Departures: []string{"KJFK"}}, automatable: false},
+		{mc: &MultiUserController{Departures: []string{"KJFK"}}, automatable: false},
+	} {
+		if a := isAutomatablePosition(test.mc); a != test.automatable {
+			t.Errorf("%+v: got automatable %v, expected %v", test.mc, a, test.automatable)
+		}
+	}
+
+	for hdg, cmd := range map[float32]int{0: 360, 359.6: 360, 360.4: 360, 90.2: 90, -10: 350} {
+		if c := headingCommand(hdg); c != cmd {
+			t.Errorf("heading %.1f: got %d, expected %d", hdg, c, cmd)
+		}
+	}
+}
+
+func TestNewWorldAutomatedControllers(t *testing.T) {
+	saved := *server
+	*server = true
+	defer func() { *server = saved }()
+
+	sg := &ScenarioGroup{
+		ControlPositions: map[string]*Controller{
+			"APP": &Controller{Callsign: "APP"},
+			"FIN": &Controller{Callsign: "FIN"},
+			"DEP": &Controller{Callsign: "DEP"},
+			"CTR": &Controller{Callsign: "CTR"},
+		},
+	}
+	sc := &Scenario{
+		SplitConfigurations: SplitConfigurationSet{
+			"default": SplitConfiguration{
+				"APP": &MultiUserController{Primary: true, Arrivals: []string{"A"}},
+				"FIN": &MultiUserController{Arrivals: []string{"A"}},
+				"DEP": &MultiUserController{Departures: []string{"KJFK"}},
+			},
+		},
+		VirtualControllers: []string{"CTR"},
+	}
+	ssc := NewSimConfiguration{Scenario: &SimScenarioConfiguration{SelectedSplit: "default"}}
+
+	s := &Sim{AutomateApproach: true}
+	s.World = newWorld(ssc, s, sg, sc)
+
+	for _, callsign := range []string{"CTR", "FIN"} {
+		if ctrl, ok := s.World.Controllers[callsign]; !ok {
+			t.Errorf("%s: missing from the world's controllers", callsign)
+		} else if ctrl == sg.ControlPositions[callsign] {
+			t.Errorf("%s: world shares the scenario group's controller", callsign)
+		}
+	}
+	for _, callsign := range []string{"APP", "DEP"} {
+		if _, ok := s.World.Controllers[callsign]; ok {
+			t.Errorf("%s: unexpectedly in the world's controllers", callsign)
+		}
+	}
+	if !s.isAutomated("FIN") || s.isAutomated("APP") || s.isAutomated("DEP") {
+		t.Errorf("wrong positions automated")
+	}
+
+	// Changes made for one sim shouldn't be visible in another.
+	s.World.Controllers["FIN"].IsHuman = true
+	s.World.Controllers["FIN"].Frequency = 123450
+	s2 := &Sim{AutomateApproach: true}
+	s2.World = newWorld(ssc, s2, sg, sc)
+	if ctrl := s2.World.Controllers["FIN"]; ctrl.IsHuman || ctrl.Frequency != 0 {
+		t.Errorf("changes to one sim's controller leaked into another: %+v", ctrl)
+	}
+}
@@ -32,6 +32,9 @@ type FAAAirport struct {
 	Runways    []Runway
 	Approaches map[string][]WaypointArray
 	STARs      map[string]STAR
+	SIDs       map[string]SID
+	MSAs       []MSA
+	Localizers map[string]Localizer // runway -> localizer
 }
 
 type TRACON struct {
@@ -129,6 +132,35 @@ func (s STAR) GetWaypointsFrom(fix string) WaypointArray {
 	return nil
 }
 
+// SID records the fixes along a SID's enroute transitions, each of which
+// includes the common route before it; Transitions[""] is the common
+// route alone.
+type SID struct {
+	Transitions map[string]WaypointArray
+}
+
+// Airway is a continuous sequence of fixes along an airway; airways with
+// gaps are represented by multiple Airways with the same name.
+type Airway struct {
+	Name  string
+	Fixes []string
+}
+
+// FixesBetween returns the fixes along the airway after the entry fix up
+// to and including the exit fix, in order, if both are on it.
+func (a Airway) FixesBetween(entry, exit string) ([]string, bool) {
+	i, j := slices.Index(a.Fixes, entry), slices.Index(a.Fixes, exit)
+	if i == -1 || j == -1 || i == j {
+		return nil, false
+	}
+	if i < j {
+		return DuplicateSlice(a.Fixes[i+1 : j+1]), true
+	}
+	fixes := DuplicateSlice(a.Fixes[j:i])
+	slices.Reverse(fixes)
+	return fixes, true
+}
+
 func MakeSTAR() *STAR {
 	return &STAR{
 		Transitions:     make(map[string]WaypointArray),
@@ -146,10 +178,72 @@ func (s STAR) Print(name string) {
 }
 
 type Runway struct {
-	Id        string
-	Heading   float32
-	Threshold Point2LL
-	Elevation int
+	Id          string
+	Heading     float32 // magnetic
+	TrueHeading float32
+	Threshold   Point2LL
+	Elevation   int
+	Length      int // feet
+}
+
+// Localizer describes an ILS localizer and glideslope. The localizer
+// course may be offset from the runway centerline and the glideslope
+// angle may not be 3 degrees.
+type Localizer struct {
+	Id                      string
+	Runway                  string
+	Location                Point2LL // localizer antenna
+	Course                  float32  // magnetic
+	TrueCourse              float32
+	GlideslopeLocation      Point2LL // zero if there's no glideslope
+	GlideslopeAngle         float32  // degrees; zero if there's no glideslope
+	ThresholdCrossingHeight int      // feet above the threshold
+	ThresholdElevation      int
+}
+
+// Hold is a published holding pattern.
+type Hold struct {
+	Fix             string
+	InboundCourse   float32 // magnetic
+	TurnDirection   TurnMethod
+	LegLengthNM     float32 // zero if the legs are timed
+	LegMinutes      float32 // zero if the legs are given by distance
+	MinimumAltitude int
+	MaximumAltitude int
+	Speed           int // maximum holding speed, if published
+}
+
+// MSA is a minimum safe altitude: the lowest altitude in each sector
+// around its center that provides 1,000' of obstacle clearance.
+type MSA struct {
+	Fix      string // the center of the MSA; may be a runway, e.g. "RW04R".
+	Location Point2LL
+	Sectors  []MSASector
+}
+
+type MSASector struct {
+	FromBearing, ToBearing float32 // magnetic, from the center
+	Altitude               int
+	Radius                 float32 // nm
+}
+
+// Altitude returns the minimum safe altitude at the point given by its
+// magnetic bearing and distance from the MSA's center, if it's inside one
+// of its sectors.
+func (m MSA) Altitude(bearing, dist float32) (int, bool) {
+	for _, s := range m.Sectors {
+		if dist > s.Radius {
+			continue
+		}
+		from, to := s.FromBearing, s.ToBearing
+		if to <= from {
+			to += 360
+		}
+		if b := bearing; (b >= from && b < to) || (b+360 >= from && b+360 < to) {
+			return s.Altitude, true
+		}
+	}
+	return 0, false
 }
 
 type METAR struct {
@@ -160,6 +254,7 @@ type METAR struct {
 	Weather     string
 	Altimeter   string
 	Rmk         string
+	Temperature float32 // degrees C
 }
 
 func (m METAR) String() string {
@@ -170,6 +265,89 @@ func (m METAR) String() string {
 	return strings.Join([]string{m.AirportICAO, m.Time, auto, m.Wind, m.Weather, m.Altimeter, m.Rmk}, " ")
 }
 
+// AltimeterSetting returns the METAR's altimeter setting in inches of
+// mercury; standard pressure is returned if it can't be parsed.
+func (m METAR) AltimeterSetting() float32 {
+	if alt, err := ParseAltimeter(m.Altimeter); err == nil {
+		return alt
+	}
+	return StandardAltimeter
+}
+
+// Visibility returns the METAR's prevailing visibility in statute miles;
+// 10 is returned if it isn't reported.
+func (m METAR) Visibility() float32 {
+	f := strings.Fields(m.Weather)
+	for i, s := range f {
+		if !strings.HasSuffix(s, "SM") {
+			continue
+		}
+		s = strings.TrimLeft(strings.TrimSuffix(s, "SM"), "MP")
+		vis := float32(0)
+		if num, den, ok := strings.Cut(s, "/"); ok {
+			n, err0 := strconv.Atoi(num)
+			d, err1 := strconv.Atoi(den)
+			if err0 != nil || err1 != nil || d == 0 {
+				return 10
+			}
+			vis = float32(n) / float32(d)
+			// Whole miles are given separately, as in "1 1/2SM".
+			if i > 0 {
+				if w, err := strconv.Atoi(f[i-1]); err == nil {
+					vis += float32(w)
+				}
+			}
+		} else if v, err := strconv.Atoi(s); err == nil {
+			vis = float32(v)
+		} else {
+			return 10
+		}
+		return vis
+	}
+	return 10
+}
+
+// Ceiling returns the height above ground level in feet of the lowest
+// broken or overcast layer (or the vertical visibility into an obscured
+// sky), if there is one.
+func (m METAR) Ceiling() (int, bool) {
+	for _, s := range strings.Fields(m.Weather) {
+		for _, cover := range []string{"BKN", "OVC", "VV"} {
+			if h, ok := strings.CutPrefix(s, cover); ok && len(h) >= 3 {
+				if v, err := strconv.Atoi(h[:3]); err == nil {
+					return 100 * v, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// ParseAltimeter parses an altimeter setting given either as in a METAR
+// ("A2992" in inches, "Q1013" in hectopascals) or as a bare four-digit
+// number of hundredths of an inch ("2992"). The setting is returned in
+// inches of mercury.
+func ParseAltimeter(s string) (float32, error) {
+	if len(s) == 5 && s[0] == 'Q' {
+		if hpa, err := strconv.Atoi(s[1:]); err != nil {
+			return 0, err
+		} else {
+			return float32(hpa) * 0.02953, nil
+		}
+	}
+
+	s = strings.TrimPrefix(s, "A")
+	if len(s) != 4 {
+		return 0, ErrInvalidAltimeter
+	} else if v, err := strconv.Atoi(s); err != nil {
+		return 0, err
+	} else if v < 2700 || v > 3200 {
+		return 0, ErrInvalidAltimeter
+	} else {
+		return float32(v) / 100, nil
+	}
+}
+
 func ParseMETAR(str string) (*METAR, error) {
 	fields := strings.Fields(str)
 	if len(fields) < 3 {
@@ -251,6 +429,8 @@ type Controller struct {
 	FacilityIdentifier string    `json:"facility_id"`     // For example the "N" in "N4P" showing the N90 TRACON
 	ERAMFacility       bool      `json:"eram_facility"`   // To weed out N56 and N4P being the same fac
 	DefaultAirport     string    `json:"default_airport"` // only required if CRDA is a thing
+
+	Behavior *VirtualControllerBehavior `json:"virtual_behavior,omitempty"`
 }
 
 type FlightRules int
@@ -362,6 +542,46 @@ func FormatAltitude(falt float32) string {
 	}
 }
 
+const (
+	// TransitionAltitude is the altitude at which pilots climbing set
+	// their altimeters to StandardAltimeter; it's also the transition
+	// level at which they go back to the local setting when descending.
+	TransitionAltitude = 18000
+	StandardAltimeter  = 29.92
+)
+
+// PressureAltitude returns the pressure altitude--what Mode C
+// reports--for an aircraft with the given indicated altitude and
+// altimeter setting.
+func PressureAltitude(indicated, altimeter float32) float32 {
+	return indicated + (StandardAltimeter-altimeter)*1000
+}
+
+// IndicatedAltitude returns the altitude that an altimeter with the
+// given setting shows at the given pressure altitude. This is also the
+// correction that radar systems apply to Mode C reports below the
+// transition altitude.
+func IndicatedAltitude(pressure, altimeter float32) float32 {
+	return pressure - (StandardAltimeter-altimeter)*1000
+}
+
+// LowestUsableFlightLevel returns the lowest usable flight level (in
+// feet) given the local altimeter setting, following the table in 14
+// CFR 91.121: each half inch below 29.92 raises it by 500'.
+func LowestUsableFlightLevel(altimeter float32) float32 {
+	hundredths := 2992 - int(altimeter*100+0.5)
+	if hundredths <= 0 {
+		return TransitionAltitude
+	}
+	return TransitionAltitude + 500*float32((hundredths+49)/50)
+}
+
+// FormatAltimeter returns the altimeter setting as four digits, e.g.,
+// "2992".
+func FormatAltimeter(alt float32) string {
+	return fmt.Sprintf("%04d", int(alt*100+0.5))
+}
+
 type TransponderMode int
 
 const (
@@ -1350,6 +1570,8 @@ type StaticDatabase struct {
 	Navaids             map[string]Navaid
 	Airports            map[string]FAAAirport
 	Fixes               map[string]Fix
+	Airways             map[string][]Airway
+	Holds               map[string][]Hold // fix -> holds
 	Callsigns           map[string]string // 3 letter -> callsign
 	AircraftTypeAliases map[string]string
 	AircraftPerformance map[string]AircraftPerformance
@@ -1405,6 +1627,56 @@ type AircraftPerformance struct {
 	} `json:"speed"`
 }
 
+// CWTApproachSeparation returns the required in-trail separation in
+// nautical miles between two aircraft on final approach, given their
+// consolidated wake turbulence (CWT) categories. Zero is returned if
+// only minimum radar separation is required.
+func CWTApproachSeparation(front, back string) float32 {
+	cwtClass := func(cwt string) int {
+		if len(cwt) == 0 {
+			return 9
+		}
+		switch cwt[0] {
+		case 'I':
+			return 0
+		case 'H':
+			return 1
+		case 'G':
+			return 2
+		case 'F':
+			return 3
+		case 'E':
+			return 4
+		case 'D':
+			return 5
+		case 'C':
+			return 6
+		case 'B':
+			return 7
+		case 'A':
+			return 8
+		default:
+			return 9
+		}
+	}
+
+	// 7110.126B TBL 5-5-2
+	// 0 value means minimum radar separation
+	cwtOnApproachLookUp := [10][10]float32{ // [front][back]
+		{0, 0, 0, 0, 0, 0, 0, 0, 0, 10},          // Behind I
+		{0, 0, 0, 0, 0, 0, 0, 0, 0, 10},          // Behind H
+		{0, 0, 0, 0, 0, 0, 0, 0, 0, 10},          // Behind G
+		{4, 0, 0, 0, 0, 0, 0, 0, 0, 10},          // Behind F
+		{4, 0, 0, 0, 0, 0, 0, 0, 0, 10},          // Behind E
+		{6, 6, 5, 5, 5, 4, 4, 3, 0, 10},          // Behind D
+		{6, 5, 3.5, 3.5, 3.5, 0, 0, 0, 0, 10},    // Behind C
+		{6, 5, 5, 5, 5, 4, 4, 3, 0, 10},          // Behind B
+		{8, 8, 7, 7, 7, 6, 6, 5, 0, 10},          // Behind A
+		{10, 10, 10, 10, 10, 10, 10, 10, 10, 10}, // Behind NOWGT (No weight: 7110.762)
+	}
+	return cwtOnApproachLookUp[cwtClass(front)][cwtClass(back)]
+}
+
 type Airline struct {
 	ICAO     string `json:"icao"`
 	Name     string `json:"name"`
@@ -1434,7 +1706,7 @@ func InitializeStaticDatabase() *StaticDatabase {
 	go func() { db.Airlines, db.Callsigns = parseAirlines(); wg.Done() }()
 	var airports map[string]FAAAirport
 	wg.Add(1)
-	go func() { airports, db.Navaids, db.Fixes = parseCIFP(); wg.Done() }()
+	go func() { airports, db.Navaids, db.Fixes, db.Airways, db.Holds = parseCIFP(); wg.Done() }()
 	wg.Add(1)
 	go func() { db.MagneticGrid = parseMagneticGrid(); wg.Done() }()
 	wg.Add(1)
@@ -1624,7 +1896,7 @@ func parseAirlines() (map[string]Airline, map[string]string) {
 
 // FAA Coded Instrument Flight Procedures (CIFP)
 // https://www.faa.gov/air_traffic/flight_info/aeronav/digital_products/cifp/download/
-func parseCIFP() (map[string]FAAAirport, map[string]Navaid, map[string]Fix) {
+func parseCIFP() (map[string]FAAAirport, map[string]Navaid, map[string]Fix, map[string][]Airway, map[string][]Hold) {
 	cifp, err := fs.ReadFile(resourcesFS, "FAACIFP18.zst")
 	if err != nil {
 		panic(err)
@@ -1988,46 +2260,54 @@ func LookupOppositeRunway(icao, rwy string) (Runway, bool) {
 	if ap, ok := database.Airports[icao]; !ok {
 		return Runway{}, false
 	} else {
-		rwy = cleanRunway(rwy)
+		return oppositeRunway(ap.Runways, rwy)
+	}
+}
 
-		// Break runway into number and optional extension and swap
-		// left/right.
-		n := len(rwy)
-		num, ext := "", ""
-		switch rwy[n-1] {
-		case 'R':
-			ext = "L"
-			num = rwy[:n-1]
-		case 'L':
-			ext = "R"
-			num = rwy[:n-1]
-		case 'C':
-			ext = "C"
-			num = rwy[:n-1]
-		default:
-			num = rwy
-		}
+// oppositeRunway returns the runway at the other end of the given one.
+func oppositeRunway(runways []Runway, rwy string) (Runway, bool) {
+	rwy = cleanRunway(rwy)
 
-		// Extract the number so we can get the opposite heading
-		v, err := strconv.Atoi(num)
-		if err != nil {
-			return Runway{}, false
-		}
+	// Break runway into number and optional extension and swap
+	// left/right.
+	n := len(rwy)
+	if n == 0 {
+		return Runway{}, false
+	}
+	num, ext := "", ""
+	switch rwy[n-1] {
+	case 'R':
+		ext = "L"
+		num = rwy[:n-1]
+	case 'L':
+		ext = "R"
+		num = rwy[:n-1]
+	case 'C':
+		ext = "C"
+		num = rwy[:n-1]
+	default:
+		num = rwy
+	}
 
-		// The (v+18)%36 below would give us 0 for runway 36, so handle 18
-		// specially.
-		if v == 18 {
-			rwy = "36" + ext
-		} else {
-			rwy = fmt.Sprintf("%d", (v+18)%36) + ext
-		}
+	// Extract the number so we can get the opposite heading
+	v, err := strconv.Atoi(num)
+	if err != nil {
+		return Runway{}, false
+	}
 
-		idx := slices.IndexFunc(ap.Runways, func(r Runway) bool { return r.Id == rwy })
-		if idx == -1 {
-			return Runway{}, false
-		}
-		return ap.Runways[idx], true
+	// The (v+18)%36 below would give us 0 for runway 36, so handle 18
+	// specially.
+	if v == 18 {
+		rwy = "36" + ext
+	} else {
+		rwy = fmt.Sprintf("%d", (v+18)%36) + ext
 	}
+
+	idx := slices.IndexFunc(runways, func(r Runway) bool { return r.Id == rwy })
+	if idx == -1 {
+		return Runway{}, false
+	}
+	return runways[idx], true
 }
 
 func (ap FAAAirport) ValidRunways() string {
@@ -2048,6 +2328,38 @@ func DensityRatioAtAltitude(alt float32) float32 {
 	return exp(-g0 * M_air * altm / (R * T_b))
 }
 
+// ISATemperature returns the temperature in degrees C at the given
+// altitude in the International Standard Atmosphere.
+func ISATemperature(alt float32) float32 {
+	// The lapse rate is 1.98C per thousand feet up to the tropopause,
+	// above which the temperature is constant.
+	return 15 - 1.98*min(alt, 36089)/1000
+}
+
+// SpeedOfSound returns the speed of sound in knots at the given
+// temperature, given in degrees C.
+func SpeedOfSound(tempC float32) float32 {
+	return 38.967854 * sqrt(tempC+273.15)
+}
+
+// MachToTAS returns the true airspeed corresponding to the given mach
+// number at the given altitude, where isaDeviation is the difference in
+// degrees C between the actual temperature and the ISA temperature.
+func MachToTAS(mach, altitude, isaDeviation float32) float32 {
+	return mach * SpeedOfSound(ISATemperature(altitude)+isaDeviation)
+}
+
+func TASToMach(tas, altitude, isaDeviation float32) float32 {
+	return tas / SpeedOfSound(ISATemperature(altitude)+isaDeviation)
+}
+
+// DensityAltitude returns the density altitude for the given pressure
+// altitude and deviation from the ISA temperature, using the usual
+// rule of thumb of 120' per degree C.
+func DensityAltitude(pressureAltitude, isaDeviation float32) float32 {
+	return pressureAltitude + 120*isaDeviation
+}
+
 func IASToTAS(ias, altitude float32) float32 {
 	return ias / sqrt(DensityRatioAtAltitude(altitude))
 }
@@ -63,3 +63,153 @@ func TestParseAltitudeRestriction(t *testing.T) {
 		}
 	}
 }
+
+func TestAltimeter(t *testing.T) {
+	for _, s := range []string{"A299", "2992A", "A1992", "Qabcd"} {
+		if _, err := ParseAltimeter(s); err == nil {
+			t.Errorf("Expected error return value for invalid altimeter %q", s)
+		}
+	}
+	for s, alt := range map[string]float32{"A2992": 29.92, "2950": 29.5, "A3041": 30.41} {
+		if a, err := ParseAltimeter(s); err != nil {
+			t.Errorf("%v: Unexpected error return value for valid altimeter %q", err, s)
+		} else if abs(a-alt) > 0.001 {
+			t.Errorf("Parsing altimeter %q gave %f; expected %f", s, a, alt)
+		}
+	}
+
+	for alt, fl := range map[float32]float32{30.12: 18000, 29.92: 18000, 29.91: 18500, 29.42: 18500,
+		29.41: 19000, 28.92: 19000, 28.91: 19500} {
+		if l := LowestUsableFlightLevel(alt); l != fl {
+			t.Errorf("Lowest usable flight level for %.2f: got %.0f, expected %.0f", alt, l, fl)
+		}
+	}
+
+	if pa := PressureAltitude(5000, 29.42); abs(pa-5500) > 0.1 {
+		t.Errorf("Pressure altitude at 5000 with 29.42: got %.0f, expected 5500", pa)
+	}
+	if ia := IndicatedAltitude(PressureAltitude(7000, 30.12), 30.12); abs(ia-7000) > 0.1 {
+		t.Errorf("Indicated altitude doesn't invert pressure altitude: got %.1f, expected 7000", ia)
+	}
+
+	// With the altimeter set 0.2" high, the pilot sees 200' more than the
+	// aircraft's true altitude. Switching to standard pressure changes
+	// the indicated altitude but nothing else.
+	var nav Nav
+	nav.FlightState.LocalAltimeter, nav.FlightState.Altimeter = 29.42, 29.62
+	nav.FlightState.Altitude = 5000
+	for i := 0; i < 2; i++ {
+		if ta := nav.TrueAltitude(); abs(ta-4800) > 0.1 {
+			t.Errorf("True altitude with altimeter %.2f: got %.1f, expected 4800", nav.FlightState.Altimeter, ta)
+		}
+		if pa := nav.PressureAltitude(); abs(pa-5300) > 0.1 {
+			t.Errorf("Pressure altitude with altimeter %.2f: got %.1f, expected 5300", nav.FlightState.Altimeter, pa)
+		}
+		nav.setAltimeter(StandardAltimeter)
+	}
+
+	// Aircraft spawn at their true altitude, whether or not they're
+	// above the transition altitude.
+	w := &World{METAR: map[string]*METAR{"KJFK": &METAR{Altimeter: "A2970"}}}
+	for _, alt := range []float32{5000, 24000} {
+		var nav Nav
+		nav.FlightState.Altitude = alt
+		nav.initializeWeather(w, "KJFK", 13)
+		if ta := nav.TrueAltitude(); abs(ta-alt) > 0.1 {
+			t.Errorf("True altitude after spawning at %.0f: got %.1f", alt, ta)
+		}
+	}
+}
+
+func TestMETARWeather(t *testing.T) {
+	for _, test := range []struct {
+		weather string
+		vis     float32
+		ceiling int
+	}{
+		{"10SM FEW250", 10, 0},
+		{"1 1/2SM BR SCT008 OVC015", 1.5, 1500},
+		{"M1/4SM FG VV002", 0.25, 200},
+		{"P6SM BKN030CB OVC080", 6, 3000},
+		{"", 10, 0},
+	} {
+		m := METAR{Weather: test.weather}
+		if vis := m.Visibility(); vis != test.vis {
+			t.Errorf("%q: got visibility %f, expected %f", test.weather, vis, test.vis)
+		}
+		if c, ok := m.Ceiling(); c != test.ceiling || ok != (test.ceiling != 0) {
+			t.Errorf("%q: got ceiling %d (%v), expected %d", test.weather, c, ok, test.ceiling)
+		}
+	}
+}
+
+func TestMSAAltitude(t *testing.T) {
+	msa := MSA{Fix: "RW04R", Sectors: []MSASector{
+		MSASector{FromBearing: 270, ToBearing: 90, Altitude: 3000, Radius: 25},
+		MSASector{FromBearing: 90, ToBearing: 270, Altitude: 2000, Radius: 25},
+	}}
+	for _, test := range []struct {
+		bearing, dist float32
+		alt           int
+		ok            bool
+	}{
+		{bearing: 0, dist: 10, alt: 3000, ok: true},
+		{bearing: 300, dist: 10, alt: 3000, ok: true},
+		{bearing: 90, dist: 24, alt: 2000, ok: true},
+		{bearing: 180, dist: 10, alt: 2000, ok: true},
+		{bearing: 180, dist: 30},
+	} {
+		if alt, ok := msa.Altitude(test.bearing, test.dist); alt != test.alt || ok != test.ok {
+			t.Errorf("bearing %.0f dist %.0f: got %d %v, expected %d %v", test.bearing, test.dist,
+				alt, ok, test.alt, test.ok)
+		}
+	}
+}
+
+func TestApproachLocalizer(t *testing.T) {
+	// Runway 9 with the threshold at the origin and the localizer antenna
+	// 2nm past its far end.
+	nmPerLongitude := nmPerLatitude * cos(radians(40))
+	ll := func(x, y float32) Point2LL {
+		p := nm2ll([2]float32{x, y}, nmPerLongitude)
+		return Point2LL{p[0] - 74, p[1] + 40}
+	}
+	threshold := ll(0, 0)
+	appr := Approach{
+		Waypoints: []WaypointArray{WaypointArray{
+			Waypoint{Fix: "FAF", Location: ll(-5, 0)},
+			Waypoint{Fix: "9", Location: threshold},
+		}},
+		Localizer: &Localizer{
+			Location:                ll(4, 0),
+			TrueCourse:              90,
+			GlideslopeAngle:         3,
+			ThresholdCrossingHeight: 50,
+			ThresholdElevation:      100,
+		},
+	}
+
+	line := appr.Line()
+	if d := nmdistance2ll(line[1], threshold); d > 0.01 {
+		t.Errorf("localizer line ends %.2fnm from the threshold", d)
+	}
+	if alt, ok := appr.GlideslopeAltitude(ll(-3, 0)); !ok || abs(alt-1105) > 5 {
+		t.Errorf("glideslope altitude at 3nm: got %.0f, expected 1105", alt)
+	}
+
+	// Offset localizer: the course passes 0.5nm north of the threshold.
+	appr.Localizer.Location = ll(4, 0.5)
+	line = appr.Line()
+	if d := nmdistance2ll(line[1], ll(0, 0.5)); d > 0.01 {
+		t.Errorf("offset localizer line ends %.2fnm from the point abeam the threshold", d)
+	}
+	if d := nmdistance2ll(line[0], ll(-10, 0.5)); d > 0.01 {
+		t.Errorf("offset localizer line starts %.2fnm from the expected point", d)
+	}
+
+	// Approaches without localizers don't have glideslopes.
+	appr.Localizer = nil
+	if _, ok := appr.GlideslopeAltitude(ll(-3, 0)); ok {
+		t.Errorf("unexpected glideslope for approach without a localizer")
+	}
+}
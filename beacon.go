@@ -0,0 +1,224 @@
+// beacon.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// BeaconCodeCategory distinguishes the banks of beacon codes that are
+// allocated to aircraft.
+type BeaconCodeCategory int
+
+const (
+	// Codes for IFR flight plans that are created locally and stay within
+	// the facility.
+	InternalBeaconCode BeaconCodeCategory = iota
+	// Codes for IFR flight plans from the NAS, e.g. arrivals from the
+	// ARTCC and departures to other facilities.
+	ExternalBeaconCode
+	// Codes for VFR aircraft receiving flight following.
+	VFRBeaconCode
+)
+
+func (c BeaconCodeCategory) String() string {
+	return []string{"internal", "external", "VFR"}[c]
+}
+
+// BeaconCodeBanks gives the ranges of beacon codes that the facility
+// allocates for each category, e.g., "0101-0177". Default banks are used
+// for categories that aren't specified.
+type BeaconCodeBanks struct {
+	Internal []string `json:"internal"`
+	External []string `json:"external"`
+	VFR      []string `json:"vfr"`
+}
+
+var defaultBeaconCodeBanks = BeaconCodeBanks{
+	Internal: []string{"0101-0177", "0201-0277"},
+	External: []string{"2001-2777", "3001-3777", "4001-4777", "5001-5777", "6001-6777"},
+	VFR:      []string{"0401-0477"},
+}
+
+// parseBeaconCodeBank parses a range of codes given as "0101-0177" or a
+// single code.
+func parseBeaconCodeBank(s string) (Squawk, Squawk, error) {
+	first, last, ok := strings.Cut(s, "-")
+	if !ok {
+		last = first
+	}
+	f, err := ParseSquawk(strings.TrimSpace(first))
+	if err != nil {
+		return 0, 0, err
+	}
+	l, err := ParseSquawk(strings.TrimSpace(last))
+	if err != nil {
+		return 0, 0, err
+	}
+	if l < f {
+		return 0, 0, fmt.Errorf("%s: last code is before the first", s)
+	}
+	return f, l, nil
+}
+
+func (b *BeaconCodeBanks) PostDeserialize(e *ErrorLogger) {
+	e.Push("beacon_codes")
+	defer e.Pop()
+
+	for _, banks := range [][]string{b.Internal, b.External, b.VFR} {
+		for _, bank := range banks {
+			if _, _, err := parseBeaconCodeBank(bank); err != nil {
+				e.Error(err)
+			}
+		}
+	}
+}
+
+// Codes returns all of the discrete codes in the given category's banks.
+func (b BeaconCodeBanks) Codes(cat BeaconCodeCategory) []Squawk {
+	banks := [][]string{b.Internal, b.External, b.VFR}[cat]
+	if len(banks) == 0 {
+		banks = [][]string{defaultBeaconCodeBanks.Internal, defaultBeaconCodeBanks.External,
+			defaultBeaconCodeBanks.VFR}[cat]
+	}
+
+	var codes []Squawk
+	for _, bank := range banks {
+		first, last, err := parseBeaconCodeBank(bank)
+		if err != nil {
+			continue // reported in PostDeserialize
+		}
+		for sq := first; sq <= last; sq++ {
+			// Codes ending in 00 aren't discrete and 1200 and the special
+			// purpose codes are never assigned.
+			if spc, _ := SquawkIsSPC(sq); sq%0o100 != 0 && sq != Squawk(0o1200) && !spc {
+				codes = append(codes, sq)
+			}
+		}
+	}
+	return codes
+}
+
+// beaconCodeCategory returns the category of code to allocate for the
+// aircraft.
+func beaconCodeCategory(ac *Aircraft) BeaconCodeCategory {
+	if ac.FlightPlan != nil && ac.FlightPlan.Rules == VFR {
+		return VFRBeaconCode
+	}
+	return ExternalBeaconCode
+}
+
+// squawkInUse returns the aircraft other than the given one that has been
+// assigned the code, if any.
+func (w *World) squawkInUse(sq Squawk, ac *Aircraft) *Aircraft {
+	for _, other := range w.Aircraft {
+		if other != ac && other.AssignedSquawk == sq {
+			return other
+		}
+	}
+	return nil
+}
+
+// AllocateSquawk returns a code from the given category's banks that
+// isn't assigned to any aircraft. Codes are released when aircraft are
+// deleted or are assigned a different code.
+func (w *World) AllocateSquawk(cat BeaconCodeCategory) (Squawk, error) {
+	inUse := make(map[Squawk]interface{})
+	for _, ac := range w.Aircraft {
+		inUse[ac.AssignedSquawk] = nil
+	}
+
+	codes := FilterSlice(w.STARSFacilityAdaptation.BeaconCodes.Codes(cat), func(sq Squawk) bool {
+		_, ok := inUse[sq]
+		return !ok
+	})
+	if len(codes) == 0 {
+		return 0, fmt.Errorf("%w: %s", ErrNoBeaconCodesAvailable, cat)
+	}
+	return SampleSlice(codes), nil
+}
+
+// misSetSquawk returns the code that a pilot who's misheard or mistyped
+// the given code might set: two digits transposed or one digit off.
+func misSetSquawk(sq Squawk) Squawk {
+	digits := []int{int(sq>>9) & 7, int(sq>>6) & 7, int(sq>>3) & 7, int(sq) & 7}
+	if i := rand.Intn(3); digits[i] != digits[i+1] && rand.Intn(2) == 0 {
+		digits[i], digits[i+1] = digits[i+1], digits[i]
+	} else {
+		i := 1 + rand.Intn(3)
+		digits[i] = (digits[i] + Sample(1, 7)) % 8
+	}
+	var wrong Squawk
+	for _, d := range digits {
+		wrong = wrong<<3 | Squawk(d)
+	}
+	if spc, _ := SquawkIsSPC(wrong); spc || slices.Contains([]Squawk{sq, Squawk(0o1200)}, wrong) {
+		return sq
+	}
+	return wrong
+}
+
+// assignSquawk assigns the code to the aircraft. If the pilot is talking
+// to the controller, they read it back and set it, occasionally
+// incorrectly.
+func (s *Sim) assignSquawk(ctrl *Controller, ac *Aircraft, sq Squawk) []RadioTransmission {
+	ac.AssignedSquawk = sq
+	if ac.ControllingController != ctrl.Callsign || ac.NORDO {
+		return nil
+	}
+
+	if ac.Squawk == Squawk(0o7600) || ac.Squawk == Squawk(0o7700) {
+		// Keep squawking the emergency code.
+	} else if rand.Float32() < 0.05 {
+		ac.Squawk = misSetSquawk(sq)
+	} else {
+		ac.Squawk = sq
+	}
+	return ac.readback("squawk %s", sq)
+}
+
+// SetSquawk assigns the given code to the aircraft; it must not be
+// assigned to another aircraft.
+func (s *Sim) SetSquawk(token, callsign string, squawk Squawk) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	return s.dispatchCommand(token, callsign,
+		func(ctrl *Controller, ac *Aircraft) error {
+			if ac.TrackingController != ctrl.Callsign && ac.ControllingController != ctrl.Callsign {
+				return ErrOtherControllerHasTrack
+			}
+			if other := s.World.squawkInUse(squawk, ac); other != nil {
+				return fmt.Errorf("%w: %s is assigned to %s", ErrDuplicateBeaconCode, squawk, other.Callsign)
+			}
+			return nil
+		},
+		func(ctrl *Controller, ac *Aircraft) []RadioTransmission {
+			return s.assignSquawk(ctrl, ac, squawk)
+		})
+}
+
+// SetSquawkAutomatic assigns the aircraft a new code from the facility's
+// banks.
+func (s *Sim) SetSquawkAutomatic(token, callsign string) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	var squawk Squawk
+	return s.dispatchCommand(token, callsign,
+		func(ctrl *Controller, ac *Aircraft) error {
+			if ac.TrackingController != ctrl.Callsign && ac.ControllingController != ctrl.Callsign {
+				return ErrOtherControllerHasTrack
+			}
+			var err error
+			squawk, err = s.World.AllocateSquawk(beaconCodeCategory(ac))
+			return err
+		},
+		func(ctrl *Controller, ac *Aircraft) []RadioTransmission {
+			return s.assignSquawk(ctrl, ac, squawk)
+		})
+}
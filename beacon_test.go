@@ -0,0 +1,84 @@
+// beacon_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBeaconCodeAllocation(t *testing.T) {
+	banks := BeaconCodeBanks{Internal: []string{"0176-0202"}, VFR: []string{"1177-1201"}}
+
+	if codes := banks.Codes(InternalBeaconCode); len(codes) != 4 || codes[0] != Squawk(0o176) ||
+		codes[3] != Squawk(0o202) {
+		t.Errorf("internal codes: got %v, expected 0176, 0177, 0201, 0202", codes)
+	}
+	// 1200 is never allocated.
+	if codes := banks.Codes(VFRBeaconCode); len(codes) != 2 || codes[0] != Squawk(0o1177) ||
+		codes[1] != Squawk(0o1201) {
+		t.Errorf("VFR codes: got %v, expected 1177, 1201", codes)
+	}
+	// Unspecified categories get the default banks.
+	if len(banks.Codes(ExternalBeaconCode)) == 0 {
+		t.Errorf("no default external codes")
+	}
+
+	w := &World{Aircraft: make(map[string]*Aircraft)}
+	w.STARSFacilityAdaptation.BeaconCodes = banks
+	for i := 0; i < 2; i++ {
+		sq, err := w.AllocateSquawk(VFRBeaconCode)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if w.squawkInUse(sq, nil) != nil {
+			t.Errorf("%s allocated twice", sq)
+		}
+		w.Aircraft[sq.String()] = &Aircraft{Callsign: sq.String(), AssignedSquawk: sq}
+	}
+	if _, err := w.AllocateSquawk(VFRBeaconCode); !errors.Is(err, ErrNoBeaconCodesAvailable) {
+		t.Errorf("expected ErrNoBeaconCodesAvailable, got %v", err)
+	}
+
+	for _, sq := range []Squawk{0o4521, 0o1234, 0o7000} {
+		if wrong := misSetSquawk(sq); wrong == Squawk(0o1200) {
+			t.Errorf("%s mis-set as 1200", sq)
+		} else if ok, _ := SquawkIsSPC(wrong); ok {
+			t.Errorf("%s mis-set as special purpose code %s", sq, wrong)
+		}
+	}
+}
+
+func TestLaunchReallocatesSquawk(t *testing.T) {
+	s := &Sim{World: &World{Aircraft: make(map[string]*Aircraft)}}
+	s.World.STARSFacilityAdaptation.BeaconCodes = BeaconCodeBanks{Internal: []string{"0176-0177"}}
+
+	// Both were created before either was launched and so were given
+	// the same code.
+	launch := func(callsign string) error {
+		return s.launchAircraftNoLock(Aircraft{
+			Callsign:       callsign,
+			Squawk:         Squawk(0o176),
+			AssignedSquawk: Squawk(0o176),
+			FlightPlan:     &FlightPlan{Rules: IFR},
+		})
+	}
+	if err := launch("AAL1"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := launch("AAL2"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if ac := s.World.Aircraft["AAL2"]; ac.AssignedSquawk != Squawk(0o177) || ac.Squawk != ac.AssignedSquawk {
+		t.Errorf("AAL2: got assigned %s squawking %s, expected 0177", ac.AssignedSquawk, ac.Squawk)
+	}
+
+	if err := launch("AAL3"); !errors.Is(err, ErrNoBeaconCodesAvailable) {
+		t.Errorf("expected ErrNoBeaconCodesAvailable, got %v", err)
+	}
+	if _, ok := s.World.Aircraft["AAL3"]; ok {
+		t.Errorf("AAL3 launched without a code")
+	}
+}
@@ -0,0 +1,850 @@
+// commands.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AircraftCommand describes one of the control commands that may be
+// issued to aircraft (e.g., "L270" to turn left heading 270). Commands are
+// matched against the entries of aircraftCommands in order, so more
+// specific syntaxes must come before more general ones.
+type AircraftCommand struct {
+	// Syntax gives the command's literal characters and its arguments,
+	// named in braces, e.g. "D{fix}/H{hdg}". The argument names are keys
+	// into commandArgTypes.
+	Syntax      string
+	Description string
+	Handler     func(sim *Sim, token, callsign string, args []any) error
+	// Trigger is set for commands that condition the commands that
+	// follow them on some event (e.g., "@MERIT L250"); Handler is nil
+	// for them.
+	Trigger func(s *Sim, ac *Aircraft, args []any) ClearanceTrigger
+
+	pieces []commandPiece
+}
+
+// commandPiece is either a run of literal characters or an argument.
+type commandPiece struct {
+	literal string
+	arg     *commandArgType
+}
+
+type commandArgChars int
+
+const (
+	commandArgDigits     commandArgChars = iota // [0-9]+
+	commandArgIdentifier                        // [A-Z0-9_]+; scenarios' own fixes start with _
+	commandArgRemainder                         // everything that's left
+)
+
+type commandArgType struct {
+	Chars commandArgChars
+	// Parse converts the argument's text to the value passed to the
+	// command's handler. The aircraft may be nil, in which case checks
+	// that depend on it are skipped.
+	Parse func(w *World, ac *Aircraft, s string) (any, error)
+	// Complete returns the possible values of the argument, if they can
+	// be enumerated.
+	Complete func(w *World, ac *Aircraft) []string
+}
+
+// crossingRestriction is the parsed argument to the "cross fix" command.
+type crossingRestriction struct {
+	ar    *AltitudeRestriction
+	speed int
+}
+
+func parseIntArg(w *World, ac *Aircraft, s string) (any, error) {
+	return strconv.Atoi(s)
+}
+
+var commandArgTypes = map[string]*commandArgType{
+	"alt": &commandArgType{
+		Chars: commandArgDigits,
+		Parse: parseIntArg,
+	},
+	"hdg": &commandArgType{
+		Chars: commandArgDigits,
+		Parse: func(w *World, ac *Aircraft, s string) (any, error) {
+			if hdg, err := strconv.Atoi(s); err != nil {
+				return nil, err
+			} else if hdg <= 0 || hdg > 360 {
+				return nil, fmt.Errorf("%w %d", ErrInvalidHeading, hdg)
+			} else {
+				return hdg, nil
+			}
+		},
+	},
+	"deg": &commandArgType{
+		Chars: commandArgDigits,
+		Parse: parseIntArg,
+	},
+	"spd": &commandArgType{
+		Chars: commandArgDigits,
+		Parse: parseIntArg,
+	},
+	"dist": &commandArgType{
+		Chars: commandArgDigits,
+		Parse: parseIntArg,
+	},
+	"clock": &commandArgType{
+		Chars: commandArgDigits,
+		Parse: func(w *World, ac *Aircraft, s string) (any, error) {
+			if clock, err := strconv.Atoi(s); err != nil {
+				return nil, err
+			} else if clock < 1 || clock > 12 {
+				return nil, fmt.Errorf("%w: clock position \"%s\"", ErrInvalidCommandSyntax, s)
+			} else {
+				return clock, nil
+			}
+		},
+	},
+	"dir": &commandArgType{
+		Chars: commandArgIdentifier,
+		Parse: func(w *World, ac *Aircraft, s string) (any, error) {
+			if !slices.Contains(trafficDirections, s) {
+				return nil, fmt.Errorf("%w: traffic direction \"%s\"", ErrInvalidCommandSyntax, s)
+			}
+			return s, nil
+		},
+		Complete: func(w *World, ac *Aircraft) []string { return trafficDirections },
+	},
+	"min": &commandArgType{
+		Chars: commandArgDigits,
+		Parse: parseIntArg,
+	},
+	"bcn": &commandArgType{
+		Chars: commandArgDigits,
+		Parse: func(w *World, ac *Aircraft, s string) (any, error) {
+			if sq, err := ParseSquawk(s); err != nil || len(s) != 4 {
+				return nil, fmt.Errorf("%w: beacon code \"%s\"", ErrInvalidCommandSyntax, s)
+			} else {
+				return sq, nil
+			}
+		},
+	},
+	"altim": &commandArgType{
+		Chars: commandArgIdentifier,
+		Parse: func(w *World, ac *Aircraft, s string) (any, error) {
+			if alt, err := ParseAltimeter(s); err != nil {
+				return nil, fmt.Errorf("%w %s", ErrInvalidAltimeter, s)
+			} else {
+				return alt, nil
+			}
+		},
+	},
+	"fix": &commandArgType{
+		Chars: commandArgIdentifier,
+		Parse: func(w *World, ac *Aircraft, s string) (any, error) {
+			if _, ok := w.Locate(s); !ok {
+				return nil, fmt.Errorf("%w %s", ErrUnknownFix, s)
+			}
+			return s, nil
+		},
+		Complete: func(w *World, ac *Aircraft) []string {
+			return SortedMapKeys(w.Fixes)
+		},
+	},
+	"appr": &commandArgType{
+		Chars: commandArgIdentifier,
+		Parse: func(w *World, ac *Aircraft, s string) (any, error) {
+			if ap, icao := arrivalAirport(w, ac); ap != nil {
+				if _, ok := ap.Approaches[s]; !ok {
+					return nil, fmt.Errorf("%w %s at %s", ErrUnknownApproach, s, icao)
+				}
+			}
+			return s, nil
+		},
+		Complete: func(w *World, ac *Aircraft) []string {
+			if ap, _ := arrivalAirport(w, ac); ap != nil {
+				return SortedMapKeys(ap.Approaches)
+			}
+			return nil
+		},
+	},
+	"restr": &commandArgType{
+		Chars: commandArgRemainder,
+		Parse: func(w *World, ac *Aircraft, s string) (any, error) {
+			var cr crossingRestriction
+			for _, r := range strings.Split(s, "/") {
+				if len(r) < 2 {
+					return nil, fmt.Errorf("%w: crossing restriction \"%s\"", ErrInvalidCommandSyntax, r)
+				}
+
+				var err error
+				switch r[0] {
+				case 'A':
+					if cr.ar, err = ParseAltitudeRestriction(r[1:]); err != nil {
+						return nil, fmt.Errorf("%w: altitude restriction \"%s\"", ErrInvalidCommandSyntax, r[1:])
+					}
+					// User input here is 100s of feet, while AltitudeRestriction is feet...
+					cr.ar.Range[0] *= 100
+					cr.ar.Range[1] *= 100
+				case 'S':
+					if cr.speed, err = strconv.Atoi(r[1:]); err != nil {
+						return nil, fmt.Errorf("%w: speed restriction \"%s\"", ErrInvalidCommandSyntax, r[1:])
+					}
+				default:
+					return nil, fmt.Errorf("%w: crossing restriction \"%s\"", ErrInvalidCommandSyntax, r)
+				}
+			}
+			return cr, nil
+		},
+	},
+}
+
+// arrivalAirport returns the airport the aircraft is landing at, if it's
+// one of the scenario's airports.
+func arrivalAirport(w *World, ac *Aircraft) (*Airport, string) {
+	if ac == nil || ac.FlightPlan == nil {
+		return nil, ""
+	}
+	icao := ac.FlightPlan.ArrivalAirport
+	return w.GetAirport(icao), icao
+}
+
+var aircraftCommands = []*AircraftCommand{
+	// Altitude
+	&AircraftCommand{
+		Syntax:      "CVS",
+		Description: "Climb via the SID",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.ClimbViaSID(token, callsign)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "DVS",
+		Description: "Descend via the STAR",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.DescendViaSTAR(token, callsign)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "C{alt}",
+		Description: "Climb and maintain altitude",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignAltitude(token, callsign, 100*args[0].(int), false)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "D{alt}",
+		Description: "Descend and maintain altitude",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignAltitude(token, callsign, 100*args[0].(int), false)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "EC",
+		Description: "Expedite climb",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.ExpediteClimb(token, callsign)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "ED",
+		Description: "Expedite descent",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.ExpediteDescent(token, callsign)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "TC{alt}",
+		Description: "After reaching speed, climb and maintain altitude",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignAltitude(token, callsign, 100*args[0].(int), true)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "TD{alt}",
+		Description: "After reaching speed, descend and maintain altitude",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignAltitude(token, callsign, 100*args[0].(int), true)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "TA{alt}",
+		Description: "After reaching speed, maintain altitude",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignAltitude(token, callsign, 100*args[0].(int), true)
+		},
+	},
+
+	// Heading
+	&AircraftCommand{
+		Syntax:      "H",
+		Description: "Fly present heading",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignHeading(&HeadingArgs{ControllerToken: token, Callsign: callsign, Present: true})
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "H{hdg}",
+		Description: "Fly heading, turning the shorter way",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignHeading(&HeadingArgs{ControllerToken: token, Callsign: callsign,
+				Heading: args[0].(int), Turn: TurnClosest})
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "L{deg}D",
+		Description: "Turn left the given number of degrees",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignHeading(&HeadingArgs{ControllerToken: token, Callsign: callsign, LeftDegrees: args[0].(int)})
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "L{hdg}",
+		Description: "Turn left heading",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignHeading(&HeadingArgs{ControllerToken: token, Callsign: callsign,
+				Heading: args[0].(int), Turn: TurnLeft})
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "R{deg}D",
+		Description: "Turn right the given number of degrees",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignHeading(&HeadingArgs{ControllerToken: token, Callsign: callsign, RightDegrees: args[0].(int)})
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "R{hdg}",
+		Description: "Turn right heading",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignHeading(&HeadingArgs{ControllerToken: token, Callsign: callsign,
+				Heading: args[0].(int), Turn: TurnRight})
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "T{deg}L",
+		Description: "Turn left the given number of degrees",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignHeading(&HeadingArgs{ControllerToken: token, Callsign: callsign, LeftDegrees: args[0].(int)})
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "T{deg}R",
+		Description: "Turn right the given number of degrees",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignHeading(&HeadingArgs{ControllerToken: token, Callsign: callsign, RightDegrees: args[0].(int)})
+		},
+	},
+
+	// Speed
+	&AircraftCommand{
+		Syntax:      "S",
+		Description: "Cancel speed restrictions",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignSpeed(token, callsign, 0, false)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "SMIN",
+		Description: "Maintain slowest practical speed",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.MaintainSlowestPractical(token, callsign)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "SMAX",
+		Description: "Maintain maximum forward speed",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.MaintainMaximumForward(token, callsign)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "S{spd}",
+		Description: "Maintain speed",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignSpeed(token, callsign, args[0].(int), false)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "TS{spd}",
+		Description: "After reaching altitude, maintain speed",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignSpeed(token, callsign, args[0].(int), true)
+		},
+	},
+
+	// Navigation
+	&AircraftCommand{
+		Syntax:      "D{fix}/D{fix}",
+		Description: "Depart the first fix direct to the second",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.DepartFixDirect(token, callsign, args[0].(string), args[1].(string))
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "D{fix}/H{hdg}",
+		Description: "Depart the fix at heading",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.DepartFixHeading(token, callsign, args[0].(string), args[1].(int))
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "D{fix}",
+		Description: "Proceed direct to the fix",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.DirectFix(token, callsign, args[0].(string))
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "CAF",
+		Description: "Cleared as filed",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.ClearedAsFiled(token, callsign)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "C{fix}/{restr}",
+		Description: "Cross the fix at altitude (A) and/or speed (S), e.g. CCAMRN/A110+/S250",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			cr := args[1].(crossingRestriction)
+			return sim.CrossFixAt(token, callsign, args[0].(string), cr.ar, cr.speed)
+		},
+	},
+
+	// Approaches
+	&AircraftCommand{
+		Syntax:      "CAC",
+		Description: "Cancel approach clearance",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.CancelApproachClearance(token, callsign)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "E{appr}",
+		Description: "Expect the approach",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.ExpectApproach(token, callsign, args[0].(string))
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "CSI{appr}",
+		Description: "Cleared straight-in approach",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.ClearedApproach(token, callsign, args[0].(string), true)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "C{appr}",
+		Description: "Cleared approach",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.ClearedApproach(token, callsign, args[0].(string), false)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "A{fix}/C{appr}",
+		Description: "At the fix, cleared approach",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AtFixCleared(token, callsign, args[0].(string), args[1].(string))
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "I",
+		Description: "Intercept the localizer",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.InterceptLocalizer(token, callsign)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "TO",
+		Description: "Contact tower",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.ContactTower(token, callsign)
+		},
+	},
+
+	// Miscellaneous
+	&AircraftCommand{
+		Syntax:      "ID",
+		Description: "Ident",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.Ident(token, callsign)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "SQ{bcn}",
+		Description: "Squawk beacon code",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.SetSquawk(token, callsign, args[0].(Squawk))
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "ALT{altim}",
+		Description: "Altimeter setting, e.g. ALT2992 or ALTQ1013",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.AssignAltimeter(token, callsign, args[0].(float32))
+		},
+	},
+
+	// Reports, queries, and traffic
+	&AircraftCommand{
+		Syntax:      "RR{alt}",
+		Description: "Report reaching altitude",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.RequestReport(token, callsign, func(ac *Aircraft) ClearanceTrigger {
+				return altitudeTrigger(ac, float32(100*args[0].(int)), false)
+			})
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "RL{alt}",
+		Description: "Report leaving altitude",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.RequestReport(token, callsign, func(ac *Aircraft) ClearanceTrigger {
+				return altitudeTrigger(ac, float32(100*args[0].(int)), true)
+			})
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "RE",
+		Description: "Report established on the localizer",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.RequestReport(token, callsign, func(ac *Aircraft) ClearanceTrigger {
+				return ClearanceTrigger{Established: true}
+			})
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "RP{fix}",
+		Description: "Report passing fix",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.RequestReport(token, callsign, func(ac *Aircraft) ClearanceTrigger {
+				return fixTrigger(sim.World, args[0].(string))
+			})
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "TR{clock}/{dist}/{dir}/{alt}",
+		Description: "Traffic advisory with direction and altitude, e.g. TR2/5/OPP/30",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.TrafficAdvisory(token, callsign, args[0].(int), args[1].(int), args[3].(int), args[2].(string))
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "TR{clock}/{dist}/{alt}",
+		Description: "Traffic advisory with altitude, e.g. TR2/5/30",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.TrafficAdvisory(token, callsign, args[0].(int), args[1].(int), args[2].(int), "")
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "TR{clock}/{dist}/{dir}",
+		Description: "Traffic advisory with direction, e.g. TR10/3/N",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.TrafficAdvisory(token, callsign, args[0].(int), args[1].(int), 0, args[2].(string))
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "TR{clock}/{dist}",
+		Description: "Traffic advisory, e.g. TR2/5",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.TrafficAdvisory(token, callsign, args[0].(int), args[1].(int), 0, "")
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "VS",
+		Description: "Maintain visual separation from the traffic in sight",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.MaintainVisualSeparation(token, callsign)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "SA",
+		Description: "Say altitude",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.SayAltitude(token, callsign)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "SH",
+		Description: "Say heading",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.SayHeading(token, callsign)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "SS",
+		Description: "Say speed",
+		Handler: func(sim *Sim, token, callsign string, args []any) error {
+			return sim.SaySpeed(token, callsign)
+		},
+	},
+
+	// Conditions: the commands that follow are issued once the condition
+	// is met.
+	&AircraftCommand{
+		Syntax:      "@A{alt}",
+		Description: "Upon reaching altitude, e.g. @A50 S210",
+		Trigger: func(s *Sim, ac *Aircraft, args []any) ClearanceTrigger {
+			return altitudeTrigger(ac, float32(100*args[0].(int)), false)
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "@T{min}",
+		Description: "After the given number of minutes, e.g. @T2 D40",
+		Trigger: func(s *Sim, ac *Aircraft, args []any) ClearanceTrigger {
+			return ClearanceTrigger{Time: s.SimTime.Add(time.Duration(args[0].(int)) * time.Minute)}
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "@{fix}/{dist}",
+		Description: "At distance from fix, e.g. @CAMRN/4 S170",
+		Trigger: func(s *Sim, ac *Aircraft, args []any) ClearanceTrigger {
+			t := fixTrigger(s.World, args[0].(string))
+			t.DME = float32(args[1].(int))
+			t.Above = nmdistance2ll(ac.Position(), t.Location) > t.DME
+			return t
+		},
+	},
+	&AircraftCommand{
+		Syntax:      "@{fix}",
+		Description: "After passing fix, e.g. @MERIT L250",
+		Trigger: func(s *Sim, ac *Aircraft, args []any) ClearanceTrigger {
+			return fixTrigger(s.World, args[0].(string))
+		},
+	},
+}
+
+func init() {
+	for _, cmd := range aircraftCommands {
+		cmd.pieces = parseCommandSyntax(cmd.Syntax)
+	}
+}
+
+func parseCommandSyntax(syntax string) []commandPiece {
+	var pieces []commandPiece
+	for syntax != "" {
+		if syntax[0] == '{' {
+			end := strings.IndexByte(syntax, '}')
+			if end == -1 {
+				panic(syntax + ": unterminated argument")
+			}
+			arg, ok := commandArgTypes[syntax[1:end]]
+			if !ok {
+				panic(syntax + ": unknown argument type")
+			}
+			pieces = append(pieces, commandPiece{arg: arg})
+			syntax = syntax[end+1:]
+		} else {
+			end := strings.IndexByte(syntax, '{')
+			if end == -1 {
+				end = len(syntax)
+			}
+			pieces = append(pieces, commandPiece{literal: syntax[:end]})
+			syntax = syntax[end:]
+		}
+	}
+	return pieces
+}
+
+// consumeArg returns the number of characters at the start of s that may
+// be part of an argument with the given characters.
+func consumeArg(chars commandArgChars, s string) int {
+	if chars == commandArgRemainder {
+		return len(s)
+	}
+	n := 0
+	isIdentifier := func(ch byte) bool { return (ch >= 'A' && ch <= 'Z') || ch == '_' }
+	for n < len(s) && ((s[n] >= '0' && s[n] <= '9') || (chars == commandArgIdentifier && isIdentifier(s[n]))) {
+		n++
+	}
+	return n
+}
+
+// match returns the text of the command's arguments if s matches its
+// syntax.
+func (c *AircraftCommand) match(s string) ([]string, bool) {
+	var args []string
+	for _, p := range c.pieces {
+		if p.arg == nil {
+			if !strings.HasPrefix(s, p.literal) {
+				return nil, false
+			}
+			s = s[len(p.literal):]
+		} else {
+			n := consumeArg(p.arg.Chars, s)
+			if n == 0 {
+				return nil, false
+			}
+			args, s = append(args, s[:n]), s[n:]
+		}
+	}
+	return args, s == ""
+}
+
+// HelpSyntax returns the command's syntax with arguments shown as, e.g.,
+// "<HDG>".
+func (c *AircraftCommand) HelpSyntax() string {
+	return strings.NewReplacer("{", "<", "}", ">").Replace(strings.ToUpper(c.Syntax))
+}
+
+// ParseAircraftCommand finds the command that matches the given text and
+// parses its arguments. If the aircraft is given, arguments are also
+// checked against it; for example, approaches must be at its arrival
+// airport.
+func ParseAircraftCommand(w *World, ac *Aircraft, command string) (*AircraftCommand, []any, error) {
+	// If the syntax matches but an argument is invalid, report that
+	// rather than a syntax error, unless a later command matches.
+	var argErr error
+	for _, cmd := range aircraftCommands {
+		strs, ok := cmd.match(command)
+		if !ok {
+			continue
+		}
+
+		var args []any
+		for i, s := range strs {
+			if a, err := cmd.argTypes()[i].Parse(w, ac, s); err != nil {
+				if argErr == nil {
+					argErr = err
+				}
+				args = nil
+				break
+			} else {
+				args = append(args, a)
+			}
+		}
+		if len(args) == len(strs) {
+			return cmd, args, nil
+		}
+	}
+
+	if argErr != nil {
+		return nil, nil, argErr
+	}
+	return nil, nil, fmt.Errorf("%w: \"%s\"", ErrInvalidCommandSyntax, command)
+}
+
+func (c *AircraftCommand) argTypes() []*commandArgType {
+	var types []*commandArgType
+	for _, p := range c.pieces {
+		if p.arg != nil {
+			types = append(types, p.arg)
+		}
+	}
+	return types
+}
+
+// ValidateAircraftCommands checks that all of the given commands are
+// valid for the aircraft without issuing them.
+func ValidateAircraftCommands(w *World, ac *Aircraft, cmds string) error {
+	commands := strings.Fields(cmds)
+	for i, command := range commands {
+		if cmd, _, err := ParseAircraftCommand(w, ac, command); err != nil {
+			return err
+		} else if cmd.Trigger != nil && i == len(commands)-1 {
+			return fmt.Errorf("%w: no commands after \"%s\"", ErrInvalidCommandSyntax, command)
+		}
+	}
+	return nil
+}
+
+// AircraftCommandHelp returns the syntax and description of the commands
+// that start with the given prefix.
+func AircraftCommandHelp(prefix string) []string {
+	var help []string
+	for _, cmd := range aircraftCommands {
+		if strings.HasPrefix(cmd.Syntax, prefix) {
+			help = append(help, cmd.HelpSyntax()+" "+strings.ToUpper(cmd.Description))
+		}
+	}
+	return help
+}
+
+// CompleteAircraftCommand returns the possible completions of a partially
+// typed command's final argument, for arguments like fixes and approaches
+// whose values can be enumerated.
+func CompleteAircraftCommand(w *World, ac *Aircraft, partial string) []string {
+	var completions []string
+	for _, cmd := range aircraftCommands {
+		s, typed := partial, ""
+		for _, p := range cmd.pieces {
+			if p.arg == nil {
+				if !strings.HasPrefix(s, p.literal) {
+					break
+				}
+				s, typed = s[len(p.literal):], typed+p.literal
+				continue
+			}
+
+			n := consumeArg(p.arg.Chars, s)
+			if n < len(s) {
+				s, typed = s[n:], typed+s[:n]
+				continue
+			}
+			// The argument runs to the end of what's been typed, so
+			// this is the one to complete.
+			if p.arg.Complete != nil {
+				for _, v := range p.arg.Complete(w, ac) {
+					if strings.HasPrefix(v, s) {
+						completions = append(completions, typed+v)
+					}
+				}
+			}
+			break
+		}
+	}
+
+	slices.Sort(completions)
+	return slices.Compact(completions)
+}
+
+// runAircraftCommands parses the given control commands and issues them
+// to the aircraft on behalf of the controller with the given token.
+func runAircraftCommands(sim *Sim, token, callsign, cmds string) error {
+	commands := strings.Fields(cmds)
+
+	if i, err := issueAircraftCommands(sim, token, callsign, commands); err != nil {
+		sim.SetSTARSInput(strings.Join(commands[i:], " "))
+		return err
+	}
+	return nil
+}
+
+// issueAircraftCommands issues the given commands in order. If there is
+// an error, it also returns the index of the command that caused it. When
+// a trigger is encountered, the remaining commands are stored as a
+// conditional clearance rather than being issued immediately.
+func issueAircraftCommands(sim *Sim, token, callsign string, commands []string) (int, error) {
+	// Some arguments (e.g., approaches) can only be resolved given the
+	// aircraft's flight plan.
+	for i, command := range commands {
+		cmd, args, err := ParseAircraftCommand(sim.World, sim.lookupAircraft(callsign), command)
+		if err != nil {
+			return i, err
+		}
+
+		if cmd.Trigger == nil {
+			if err := cmd.Handler(sim, token, callsign, args); err != nil {
+				return i, err
+			}
+			continue
+		}
+
+		rest := commands[i+1:]
+		if len(rest) == 0 {
+			return i, fmt.Errorf("%w: no commands after \"%s\"", ErrInvalidCommandSyntax, command)
+		}
+		// Make sure the conditional commands are valid now rather than
+		// finding out when the trigger fires.
+		for j, c := range rest {
+			if _, _, err := ParseAircraftCommand(sim.World, sim.lookupAircraft(callsign), c); err != nil {
+				return i + 1 + j, err
+			}
+		}
+		return i, sim.AddConditionalClearance(token, callsign, cmd, args, strings.Join(rest, " "))
+	}
+
+	return len(commands), nil
+}
@@ -0,0 +1,143 @@
+// commands_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseAircraftCommand(t *testing.T) {
+	saved := database
+	defer func() { database = saved }()
+	database = &StaticDatabase{}
+
+	w := &World{
+		Fixes: map[string]Point2LL{"CAMRN": Point2LL{}, "CCC": Point2LL{}, "ROBER": Point2LL{}, "_RWY_31L": Point2LL{}},
+		Airports: map[string]*Airport{
+			"KJFK": &Airport{Approaches: map[string]*Approach{"I2L": &Approach{}, "I2R": &Approach{}, "R4L": &Approach{}}},
+		},
+	}
+	ac := &Aircraft{Callsign: "AAL1234", FlightPlan: &FlightPlan{ArrivalAirport: "KJFK"}}
+
+	for _, test := range []struct {
+		cmd, syntax string
+		args        []any
+	}{
+		{"C40", "C{alt}", []any{40}},
+		{"CAC", "CAC", nil},
+		{"CAF", "CAF", nil},
+		{"CI2L", "C{appr}", []any{"I2L"}},
+		{"CSII2L", "CSI{appr}", []any{"I2L"}},
+		{"DCAMRN", "D{fix}", []any{"CAMRN"}},
+		{"D90", "D{alt}", []any{90}},
+		{"DCAMRN/H180", "D{fix}/H{hdg}", []any{"CAMRN", 180}},
+		{"D_RWY_31L", "D{fix}", []any{"_RWY_31L"}},
+		{"L20D", "L{deg}D", []any{20}},
+		{"L270", "L{hdg}", []any{270}},
+		{"T20R", "T{deg}R", []any{20}},
+		{"SMIN", "SMIN", nil},
+		{"S210", "S{spd}", []any{210}},
+		{"ACAMRN/CI2L", "A{fix}/C{appr}", []any{"CAMRN", "I2L"}},
+		{"ID", "ID", nil},
+		{"@A50", "@A{alt}", []any{50}},
+		{"@T2", "@T{min}", []any{2}},
+		{"@CAMRN/4", "@{fix}/{dist}", []any{"CAMRN", 4}},
+		{"@ROBER", "@{fix}", []any{"ROBER"}},
+		{"RR50", "RR{alt}", []any{50}},
+		{"RPCAMRN", "RP{fix}", []any{"CAMRN"}},
+		{"RE", "RE", nil},
+		{"SS", "SS", nil},
+		{"TR2/5/30", "TR{clock}/{dist}/{alt}", []any{2, 5, 30}},
+		{"TR11/3", "TR{clock}/{dist}", []any{11, 3}},
+		{"TR2/5/OPP/30", "TR{clock}/{dist}/{dir}/{alt}", []any{2, 5, "OPP", 30}},
+		{"TR10/3/N", "TR{clock}/{dist}/{dir}", []any{10, 3, "N"}},
+		{"SQ4521", "SQ{bcn}", []any{Squawk(0o4521)}},
+	} {
+		cmd, args, err := ParseAircraftCommand(w, ac, test.cmd)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.cmd, err)
+		} else if cmd.Syntax != test.syntax {
+			t.Errorf("%s: matched %q; expected %q", test.cmd, cmd.Syntax, test.syntax)
+		} else if !slices.Equal(args, test.args) {
+			t.Errorf("%s: got args %v; expected %v", test.cmd, args, test.args)
+		}
+	}
+
+	if _, _, err := ParseAircraftCommand(w, ac, "CCAMRN/A110+/S250"); err != nil {
+		t.Errorf("CCAMRN/A110+/S250: unexpected error %v", err)
+	}
+
+	for _, test := range []struct {
+		cmd string
+		err error
+		msg string
+	}{
+		{"CI4R", ErrUnknownApproach, "Unknown approach I4R at KJFK"},
+		{"DFOO", ErrUnknownFix, "Unknown fix FOO"},
+		{"H400", ErrInvalidHeading, "Invalid heading 400"},
+		{"Q123", ErrInvalidCommandSyntax, "Invalid command syntax: \"Q123\""},
+		{"TR2/5/NE", ErrInvalidCommandSyntax, "Invalid command syntax: traffic direction \"NE\""},
+	} {
+		if _, _, err := ParseAircraftCommand(w, ac, test.cmd); !errors.Is(err, test.err) || err.Error() != test.msg {
+			t.Errorf("%s: got error %v; expected %q", test.cmd, err, test.msg)
+		}
+	}
+}
+
+func TestCompleteAircraftCommand(t *testing.T) {
+	w := &World{
+		Fixes: map[string]Point2LL{"CAMRN": Point2LL{}, "CCC": Point2LL{}, "ROBER": Point2LL{}},
+		Airports: map[string]*Airport{
+			"KJFK": &Airport{Approaches: map[string]*Approach{"I2L": &Approach{}, "I2R": &Approach{}, "R4L": &Approach{}}},
+		},
+	}
+	ac := &Aircraft{Callsign: "AAL1234", FlightPlan: &FlightPlan{ArrivalAirport: "KJFK"}}
+
+	for _, test := range []struct {
+		partial     string
+		completions []string
+	}{
+		{"DCA", []string{"DCAMRN"}},
+		{"EI", []string{"EI2L", "EI2R"}},
+		{"AROBER/CR", []string{"AROBER/CR4L"}},
+		{"S2", nil},
+	} {
+		if c := CompleteAircraftCommand(w, ac, test.partial); !slices.Equal(c, test.completions) {
+			t.Errorf("%s: got completions %v; expected %v", test.partial, c, test.completions)
+		}
+	}
+}
+
+func TestIssueAircraftCommands(t *testing.T) {
+	saved := database
+	defer func() { database = saved }()
+	database = &StaticDatabase{}
+
+	s := &Sim{World: &World{
+		Fixes: map[string]Point2LL{"CAMRN": Point2LL{}},
+		Airports: map[string]*Airport{
+			"KJFK": &Airport{Approaches: map[string]*Approach{"I4R": &Approach{}}},
+		},
+		Aircraft: map[string]*Aircraft{
+			"AAL1": &Aircraft{Callsign: "AAL1", FlightPlan: &FlightPlan{ArrivalAirport: "KJFK"}},
+		},
+	}}
+
+	// Approaches are checked against the aircraft's arrival airport
+	// before any of the commands are issued.
+	for _, test := range []struct {
+		cmds  []string
+		index int
+	}{
+		{[]string{"CI2L"}, 0},
+		{[]string{"@CAMRN", "CI2L"}, 1},
+	} {
+		if i, err := issueAircraftCommands(s, "token", "AAL1", test.cmds); !errors.Is(err, ErrUnknownApproach) || i != test.index {
+			t.Errorf("%v: got error %v at %d; expected %v at %d", test.cmds, err, i, ErrUnknownApproach, test.index)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+// conditional.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Conditional clearances are instructions that the pilot is to carry out
+// once some condition is met: "after MERIT, turn left heading 250", "at
+// 4 DME, reduce speed to 170", and so forth. They're entered by preceding
+// commands with a trigger (e.g., "@MERIT L250"); the commands after the
+// trigger are stored with the aircraft and then issued on behalf of the
+// controller who gave the clearance when the trigger fires. Requests
+// that the pilot report reaching an altitude, passing a fix, and so forth
+// are handled the same way.
+
+// ClearanceTrigger is the condition for a conditional clearance or a
+// pilot report. Exactly one of Fix, Altitude, Established, or Time is
+// set; DME is optionally set along with Fix and Leaving along with
+// Altitude.
+type ClearanceTrigger struct {
+	Fix         string
+	Location    Point2LL
+	DME         float32
+	Altitude    float32
+	Leaving     bool
+	Established bool      // on the localizer or the final approach course
+	Time        time.Time // sim time
+
+	// For DME and altitude triggers, whether the aircraft was beyond the
+	// distance or above the altitude when the clearance was issued; the
+	// trigger fires when that changes.
+	Above bool
+	// For leaving triggers, whether the aircraft was already at the
+	// altitude, in which case the trigger fires when it moves off of it
+	// in either direction.
+	Level bool
+	// For fix triggers, whether the fix was ahead of the aircraft when
+	// the trigger was last checked.
+	Ahead bool
+}
+
+func fixTrigger(w *World, fix string) ClearanceTrigger {
+	p, _ := w.Locate(fix)
+	return ClearanceTrigger{Fix: fix, Location: p}
+}
+
+func altitudeTrigger(ac *Aircraft, alt float32, leaving bool) ClearanceTrigger {
+	return ClearanceTrigger{
+		Altitude: alt,
+		Leaving:  leaving,
+		Above:    ac.IndicatedAltitude() > alt,
+		Level:    leaving && abs(ac.IndicatedAltitude()-alt) < 100,
+	}
+}
+
+func (t ClearanceTrigger) String() string {
+	switch {
+	case t.Fix != "" && t.DME != 0:
+		return fmt.Sprintf("at %.0f DME from %s", t.DME, t.Fix)
+	case t.Fix != "":
+		return "after " + t.Fix
+	case t.Altitude != 0:
+		return Select(t.Leaving, "leaving ", "reaching ") + FormatAltitude(t.Altitude)
+	case t.Established:
+		return "established"
+	default:
+		return "at " + t.Time.UTC().Format("1504") + "Z"
+	}
+}
+
+// report returns what the pilot says when reporting that the trigger
+// has fired.
+func (t ClearanceTrigger) report() string {
+	switch {
+	case t.Fix != "" && t.DME == 0:
+		return "passing " + t.Fix
+	case t.Established:
+		return "established on the localizer"
+	default:
+		return t.String()
+	}
+}
+
+func (t ClearanceTrigger) fired(ac *Aircraft, passed *Waypoint, now time.Time) bool {
+	switch {
+	case t.Fix != "" && t.DME != 0:
+		d := nmdistance2ll(ac.Position(), t.Location)
+		return (d > t.DME) != t.Above
+	case t.Fix != "":
+		if passed != nil && passed.Fix == t.Fix {
+			return true
+		}
+		// The fix may not be in the aircraft's route if it's being
+		// vectored, in which case the fix moving from ahead to behind it
+		// while close abeam is passing it.
+		along, cross := t.fixOffset(ac)
+		return t.Ahead && along <= 0 && cross < fixPassageDistance
+	case t.Altitude != 0 && t.Leaving:
+		alt := ac.IndicatedAltitude()
+		return abs(alt-t.Altitude) >= 100 && (t.Level || (alt > t.Altitude) != t.Above)
+	case t.Altitude != 0:
+		alt := ac.IndicatedAltitude()
+		return abs(alt-t.Altitude) < 50 || (alt > t.Altitude) != t.Above
+	case t.Established:
+		nav := &ac.Nav
+		return nav.Approach.InterceptState == HoldingLocalizer ||
+			(nav.Approach.Cleared && nav.Approach.PassedApproachFix)
+	default:
+		return !now.Before(t.Time)
+	}
+}
+
+// fixPassageDistance is how close abeam a fix an aircraft that isn't
+// flying a route to it must be to be considered to have passed it, in nm.
+const fixPassageDistance = 2
+
+// fixMissedDistance is how close abeam a fix an aircraft must go by it
+// for the fix to be considered missed; the aircraft may still be turned
+// back toward fixes that it passes farther away, in nm.
+const fixMissedDistance = 10
+
+// fixOffset returns the distance along the aircraft's heading to the
+// point abeam the trigger's fix, which is negative if the fix is behind
+// the aircraft, and the distance from there to the fix, both in nm.
+func (t ClearanceTrigger) fixOffset(ac *Aircraft) (along, cross float32) {
+	nmPerLongitude := ac.NmPerLongitude()
+	v := sub2f(ll2nm(t.Location, nmPerLongitude), ll2nm(ac.Position(), nmPerLongitude))
+	hdg := radians(ac.Heading() - ac.MagneticVariation())
+	dir := [2]float32{sin(hdg), cos(hdg)}
+	return dot(v, dir), abs(v[0]*dir[1] - v[1]*dir[0])
+}
+
+// update records the aircraft's current position relative to the
+// trigger's fix; it should be called after the trigger is created and
+// each time that it is checked.
+func (t *ClearanceTrigger) update(ac *Aircraft) {
+	if t.Fix != "" && t.DME == 0 {
+		along, _ := t.fixOffset(ac)
+		t.Ahead = along > 0
+	}
+}
+
+// inRoute returns true if the trigger's fix is still to come in the
+// aircraft's route, in which case it will be passed when the aircraft
+// sequences it.
+func (t ClearanceTrigger) inRoute(ac *Aircraft) bool {
+	return slices.ContainsFunc(ac.Nav.Waypoints, func(wp Waypoint) bool { return wp.Fix == t.Fix })
+}
+
+// alreadyPassed returns true if the aircraft has just flown by the trigger's
+// fix, so that a new clearance for it is unable.
+func (t ClearanceTrigger) alreadyPassed(ac *Aircraft) bool {
+	if t.Fix == "" || t.DME != 0 || t.inRoute(ac) {
+		return false
+	}
+	along, cross := t.fixOffset(ac)
+	return along <= 0 && cross < fixPassageDistance
+}
+
+// missed returns true if the aircraft has gone by the trigger's fix
+// without passing it, in which case the trigger can no longer fire. The
+// fix isn't missed if it's still in the aircraft's route or if it's
+// behind an aircraft that hasn't gone by it (e.g., on a downwind or
+// entering a hold). It should only be called if fired returned false.
+func (t ClearanceTrigger) missed(ac *Aircraft) bool {
+	if t.Fix == "" || t.DME != 0 || t.inRoute(ac) {
+		return false
+	}
+	along, cross := t.fixOffset(ac)
+	return t.Ahead && along <= 0 && cross < fixMissedDistance
+}
+
+// ConditionalClearance is either a set of commands to be issued or, if
+// Report is set, a request that the pilot report when the trigger fires.
+type ConditionalClearance struct {
+	Trigger    ClearanceTrigger
+	Commands   string
+	Report     bool
+	Controller string
+}
+
+func (cc ConditionalClearance) String() string {
+	if cc.Report {
+		return "Report " + cc.Trigger.report()
+	}
+	s := cc.Trigger.String()
+	return strings.ToUpper(s[:1]) + s[1:] + ": " + cc.Commands
+}
+
+// firedClearance records a conditional clearance whose trigger has fired
+// so that its commands can be issued once the Sim's mutex is released.
+type firedClearance struct {
+	Callsign string
+	ConditionalClearance
+}
+
+// AddConditionalClearance stores the given commands to be issued to the
+// aircraft once the trigger fires.
+func (s *Sim) AddConditionalClearance(token, callsign string, trigger *AircraftCommand, args []any,
+	commands string) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	return s.dispatchControllingCommand(token, callsign,
+		func(ctrl *Controller, ac *Aircraft) []RadioTransmission {
+			cc := ConditionalClearance{
+				Trigger:    trigger.Trigger(s, ac, args),
+				Commands:   commands,
+				Controller: ctrl.Callsign,
+			}
+			if cc.Trigger.alreadyPassed(ac) {
+				return ac.readback("unable, we're already past %s", cc.Trigger.Fix)
+			}
+			cc.Trigger.update(ac)
+			ac.ConditionalClearances = append(ac.ConditionalClearances, cc)
+			s.lg.Info("conditional clearance", slog.String("callsign", callsign), slog.Any("clearance", cc))
+
+			return ac.readback("%s, wilco", cc.Trigger)
+		})
+}
+
+// RequestReport asks the pilot to report when the given trigger fires.
+func (s *Sim) RequestReport(token, callsign string, trigger func(ac *Aircraft) ClearanceTrigger) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	return s.dispatchControllingCommand(token, callsign,
+		func(ctrl *Controller, ac *Aircraft) []RadioTransmission {
+			cc := ConditionalClearance{
+				Trigger:    trigger(ac),
+				Report:     true,
+				Controller: ctrl.Callsign,
+			}
+			if cc.Trigger.alreadyPassed(ac) {
+				return ac.readback("unable, we're already past %s", cc.Trigger.Fix)
+			}
+			cc.Trigger.update(ac)
+			ac.ConditionalClearances = append(ac.ConditionalClearances, cc)
+
+			return ac.readback(Sample("will report %s", "wilco, report %s"), cc.Trigger.report())
+		})
+}
+
+// checkConditionalClearances is called after the aircraft's state has
+// been updated; it makes the reports that are due and finds the
+// aircraft's conditional clearances that are ready to be issued.
+// Clearances whose fix the aircraft has gone by without passing it are
+// dropped.
+func (s *Sim) checkConditionalClearances(ac *Aircraft, passed *Waypoint) {
+	ac.ConditionalClearances = FilterSlice(ac.ConditionalClearances, func(cc ConditionalClearance) bool {
+		if !cc.Trigger.fired(ac, passed, s.SimTime) {
+			if !cc.Trigger.missed(ac) {
+				return true
+			}
+
+			s.lg.Info("conditional clearance missed", slog.String("callsign", ac.Callsign),
+				slog.Any("clearance", cc))
+			if ac.ControllingController == cc.Controller && !ac.NORDO {
+				PostRadioEvents(ac.Callsign, []RadioTransmission{RadioTransmission{
+					Controller: cc.Controller,
+					Message: fmt.Sprintf("%s is behind us now, %s", cc.Trigger.Fix,
+						Select(cc.Report, "unable to report passing it", "disregarding the clearance after it")),
+					Type: RadioTransmissionUnexpected,
+				}}, s)
+			} else {
+				s.eventStream.Post(Event{
+					Type: StatusMessageEvent,
+					Message: fmt.Sprintf("%s: %s: \"%s\" was not carried out: %s is behind the aircraft",
+						cc.Controller, ac.Callsign, cc, cc.Trigger.Fix),
+				})
+			}
+			return false
+		}
+		if cc.Report {
+			// Only report if still talking to the controller who asked.
+			if ac.ControllingController == cc.Controller && !ac.NORDO {
+				PostRadioEvents(ac.Callsign, []RadioTransmission{RadioTransmission{
+					Controller: cc.Controller,
+					Message:    cc.Trigger.report(),
+					Type:       RadioTransmissionContact,
+				}}, s)
+			}
+			return false
+		}
+		s.firedClearances = append(s.firedClearances, firedClearance{
+			Callsign:             ac.Callsign,
+			ConditionalClearance: cc,
+		})
+		return false
+	})
+
+	for i := range ac.ConditionalClearances {
+		ac.ConditionalClearances[i].Trigger.update(ac)
+	}
+}
+
+// issueFiredClearances issues the commands from the conditional
+// clearances whose triggers have fired. It must be called with s.mu held;
+// the mutex is released while the commands are issued since doing so
+// acquires it.
+func (s *Sim) issueFiredClearances() {
+	fired := s.firedClearances
+	s.firedClearances = nil
+
+	for _, fc := range fired {
+		token, ok := s.controllerToken(fc.Controller)
+		var err error
+		if !ok {
+			err = ErrNoController
+		} else {
+			s.mu.Unlock(s.lg)
+			_, err = issueAircraftCommands(s, token, fc.Callsign, strings.Fields(fc.Commands))
+			s.mu.Lock(s.lg)
+		}
+
+		if err != nil {
+			s.lg.Info("conditional clearance failed", slog.String("callsign", fc.Callsign),
+				slog.Any("clearance", fc.ConditionalClearance), slog.Any("error", err))
+			s.eventStream.Post(Event{
+				Type: StatusMessageEvent,
+				Message: fmt.Sprintf("%s: %s: \"%s\" %s was not carried out: %v", fc.Controller, fc.Callsign,
+					fc.Commands, fc.Trigger, err),
+			})
+		}
+	}
+}
+
+// controllerToken returns the token of the signed-in controller with the
+// given callsign.
+func (s *Sim) controllerToken(callsign string) (string, bool) {
+	for token, sc := range s.controllers {
+		if sc.Callsign == callsign {
+			return token, true
+		}
+	}
+	return "", false
+}
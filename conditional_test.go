@@ -0,0 +1,146 @@
+// conditional_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFixTrigger(t *testing.T) {
+	const nmPerLongitude = 45
+	ll := func(x, y float32) Point2LL {
+		return nm2ll([2]float32{x, y}, nmPerLongitude)
+	}
+
+	ac := &Aircraft{Callsign: "AAL1", ControllingController: "1A"}
+	ac.Nav.FlightState.NmPerLongitude = nmPerLongitude
+	ac.Nav.FlightState.MagneticVariation = 10
+	ac.Nav.FlightState.Heading = 100 // due east
+
+	trigger := ClearanceTrigger{Fix: "MERIT", Location: ll(5, 0.5)}
+	for _, test := range []struct {
+		x             float32 // nm east of the origin
+		fired, missed bool
+	}{
+		{x: 0},
+		{x: 4.9},
+		{x: 5.1, fired: true},
+	} {
+		ac.Nav.FlightState.Position = ll(test.x, 0)
+		fired := trigger.fired(ac, nil, time.Time{})
+		if fired != test.fired || (!fired && trigger.missed(ac) != test.missed) {
+			t.Errorf("%.1fnm: got fired %v missed %v, expected %v %v", test.x, fired, trigger.missed(ac),
+				test.fired, test.missed)
+		}
+		trigger.update(ac)
+	}
+
+	// Flying its route, it passes the fix when it sequences it.
+	ac.Nav.FlightState.Position = ll(4.5, 0)
+	if !trigger.fired(ac, &Waypoint{Fix: "MERIT"}, time.Time{}) {
+		t.Errorf("didn't fire after passing the fix in the route")
+	}
+
+	// Vectored away from it: it never passes the fix and the trigger is
+	// missed once it has gone by it.
+	trigger.Location = ll(5, 6)
+	ac.Nav.FlightState.Position = ll(4.9, 0)
+	trigger.update(ac)
+	ac.Nav.FlightState.Position = ll(5.1, 0)
+	if trigger.fired(ac, nil, time.Time{}) || !trigger.missed(ac) {
+		t.Errorf("fix 6nm abeam: expected the trigger to be missed")
+	}
+
+	// The clearance is dropped and the pilot says so.
+	s := &Sim{
+		World:       &World{Aircraft: map[string]*Aircraft{ac.Callsign: ac}},
+		eventStream: NewEventStream(),
+	}
+	sub := s.eventStream.Subscribe()
+	ac.ConditionalClearances = []ConditionalClearance{
+		ConditionalClearance{Trigger: trigger, Commands: "L250", Controller: "1A"},
+		ConditionalClearance{Trigger: ClearanceTrigger{Altitude: 3000}, Commands: "S210", Controller: "1A"},
+	}
+	s.checkConditionalClearances(ac, nil)
+	if len(ac.ConditionalClearances) != 1 || ac.ConditionalClearances[0].Commands != "S210" {
+		t.Errorf("expected only the altitude clearance to remain: %+v", ac.ConditionalClearances)
+	}
+	if len(s.firedClearances) != 0 {
+		t.Errorf("missed clearance was issued: %+v", s.firedClearances)
+	}
+	if !slices.ContainsFunc(sub.Get(), func(e Event) bool {
+		return e.Type == RadioTransmissionEvent && e.Callsign == "AAL1" && e.ToController == "1A"
+	}) {
+		t.Errorf("pilot didn't say that the clearance was dropped")
+	}
+
+	// On a downwind with the fix behind it, it hasn't gone by the fix
+	// yet; it will pass it after turning back toward it.
+	ac.Nav.FlightState.Heading = 280 // due west
+	ac.Nav.FlightState.Position = ll(5, 0)
+	downwind := ClearanceTrigger{Fix: "MERIT", Location: ll(8, 3)}
+	if downwind.alreadyPassed(ac) {
+		t.Errorf("downwind: fix reported as already passed")
+	}
+	downwind.update(ac)
+	ac.Nav.FlightState.Position = ll(4, 0)
+	if downwind.fired(ac, nil, time.Time{}) || downwind.missed(ac) {
+		t.Errorf("downwind: expected the trigger to still be pending")
+	}
+
+	// Going by it far away or with it still in the route doesn't miss it.
+	far := ClearanceTrigger{Fix: "MERIT", Location: ll(3.5, 15)}
+	far.update(ac)
+	ac.Nav.FlightState.Position = ll(3, 0)
+	if far.fired(ac, nil, time.Time{}) || far.missed(ac) {
+		t.Errorf("fix 15nm abeam: expected the trigger to still be pending")
+	}
+	inRoute := ClearanceTrigger{Fix: "MERIT", Location: ll(2.5, 6)}
+	inRoute.update(ac)
+	ac.Nav.FlightState.Position = ll(2, 0)
+	ac.Nav.Waypoints = []Waypoint{Waypoint{Fix: "MERIT"}}
+	if inRoute.fired(ac, nil, time.Time{}) || inRoute.missed(ac) {
+		t.Errorf("fix in route: expected the trigger to still be pending")
+	}
+
+	// It's unable to take a clearance for a fix it has just flown by.
+	ac.Nav.Waypoints = nil
+	if !(ClearanceTrigger{Fix: "MERIT", Location: ll(2.5, 0.5)}).alreadyPassed(ac) {
+		t.Errorf("expected fix just behind the aircraft to be already passed")
+	}
+}
+
+func TestAltitudeTrigger(t *testing.T) {
+	for _, test := range []struct {
+		local, altimeter float32
+		from, to, alt    float32 // indicated altitudes
+		leaving          bool
+	}{
+		// Climbing to FL240 with a low local altimeter; its true altitude
+		// at FL240 is below 24,000'.
+		{local: 29.70, altimeter: 29.92, from: 20000, to: 24000, alt: 24000},
+		// Below the transition altitude with its altimeter set 0.1"
+		// high.
+		{local: 30.10, altimeter: 30.20, from: 4000, to: 5000, alt: 5000},
+		{local: 30.10, altimeter: 30.00, from: 6000, to: 5000, alt: 5000},
+		{local: 29.70, altimeter: 29.92, from: 24000, to: 23800, alt: 24000, leaving: true},
+	} {
+		ac := &Aircraft{Callsign: "AAL1"}
+		ac.Nav.FlightState.LocalAltimeter = test.local
+		ac.Nav.FlightState.Altimeter = test.altimeter
+		ac.Nav.FlightState.Altitude = test.from
+
+		trigger := altitudeTrigger(ac, test.alt, test.leaving)
+		if trigger.fired(ac, nil, time.Time{}) {
+			t.Errorf("%+v: fired at %.0f", test, test.from)
+		}
+		ac.Nav.FlightState.Altitude = test.to
+		if !trigger.fired(ac, nil, time.Time{}) {
+			t.Errorf("%+v: didn't fire at %.0f", test, test.to)
+		}
+	}
+}
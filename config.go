@@ -53,7 +53,9 @@ type GlobalConfigNoSim struct {
 	LastTRACON            string
 	UIFontSize            int
 
-	Audio AudioEngine
+	Audio  AudioEngine
+	Speech SpeechEngine
+	Macros CommandMacros
 
 	DisplayRoot *DisplayNode
 
@@ -207,6 +209,7 @@ func LoadOrMakeDefaultConfig() {
 	if err := globalConfig.Audio.Activate(); err != nil {
 		lg.Errorf("Audio: %v", err)
 	}
+	globalConfig.Speech.Activate(&globalConfig.Audio)
 
 	imgui.LoadIniSettingsFromMemory(globalConfig.ImGuiSettings)
 }
@@ -14,13 +14,18 @@ import (
 
 // Aviation-related
 var (
+	ErrAircraftAssociated           = errors.New("Aircraft is already associated with a flight plan")
 	ErrClearedForUnexpectedApproach = errors.New("Cleared for unexpected approach")
+	ErrDuplicateBeaconCode          = errors.New("Beacon code is already assigned")
+	ErrDuplicateCallsign            = errors.New("An aircraft with that callsign already exists")
 	ErrFixNotInRoute                = errors.New("Fix not in aircraft's route")
 	ErrInvalidAltitude              = errors.New("Altitude above aircraft's ceiling")
+	ErrInvalidAltimeter             = errors.New("Invalid altimeter setting")
 	ErrInvalidApproach              = errors.New("Invalid approach")
 	ErrInvalidCommandSyntax         = errors.New("Invalid command syntax")
 	ErrInvalidHeading               = errors.New("Invalid heading")
 	ErrNoAircraftForCallsign        = errors.New("No aircraft exists with specified callsign")
+	ErrNoBeaconCodesAvailable       = errors.New("No beacon codes available")
 	ErrNoController                 = errors.New("No controller with that callsign")
 	ErrNotLaunchController          = errors.New("Not signed in as the launch controller")
 	ErrNoFlightPlan                 = errors.New("No flight plan has been filed for aircraft")
@@ -35,6 +40,8 @@ var (
 	ErrUnknownAircraftType          = errors.New("Unknown aircraft type")
 	ErrUnknownAirport               = errors.New("Unknown airport")
 	ErrUnknownApproach              = errors.New("Unknown approach")
+	ErrUnknownFix                   = errors.New("Unknown fix")
+	ErrUnknownPhraseology           = errors.New("Unable to understand")
 	ErrUnknownRunway                = errors.New("Unknown runway")
 )
 
@@ -45,20 +52,41 @@ var (
 	ErrInvalidControllerToken    = errors.New("Invalid controller token")
 	ErrNoNamedSim                = errors.New("No Sim with that name")
 	ErrNoSimForControllerToken   = errors.New("No Sim running for controller token")
+	ErrNotInstructor             = errors.New("Only the instructor can do that")
+	ErrNotPseudoPilot            = errors.New("Only a pseudo-pilot can do that")
+	ErrNoPilotInstruction        = errors.New("No pending pilot instruction with that id")
 	ErrRPCTimeout                = errors.New("RPC call timed out")
 	ErrRPCVersionMismatch        = errors.New("Client and server RPC versions don't match")
 	ErrRestoringSavedState       = errors.New("Errors during state restoration")
 	ErrInvalidPassword           = errors.New("Invalid password")
 )
 
+// Audio-related
+var (
+	ErrInvalidWAV      = errors.New("Invalid or unsupported WAV data")
+	ErrNoSpeechCommand = errors.New("No speech synthesis command specified")
+)
+
+// Command macros
+var (
+	ErrInvalidMacro   = errors.New("Invalid macro")
+	ErrMacroArguments = errors.New("Too few arguments for macro")
+	ErrMacroRecursion = errors.New("Macros are nested too deeply")
+)
+
 var errorStringToError = map[string]error{
+	ErrAircraftAssociated.Error():           ErrAircraftAssociated,
 	ErrClearedForUnexpectedApproach.Error(): ErrClearedForUnexpectedApproach,
+	ErrDuplicateBeaconCode.Error():          ErrDuplicateBeaconCode,
+	ErrDuplicateCallsign.Error():            ErrDuplicateCallsign,
 	ErrFixNotInRoute.Error():                ErrFixNotInRoute,
 	ErrInvalidAltitude.Error():              ErrInvalidAltitude,
+	ErrInvalidAltimeter.Error():             ErrInvalidAltimeter,
 	ErrInvalidApproach.Error():              ErrInvalidApproach,
 	ErrInvalidCommandSyntax.Error():         ErrInvalidCommandSyntax,
 	ErrInvalidHeading.Error():               ErrInvalidHeading,
 	ErrNoAircraftForCallsign.Error():        ErrNoAircraftForCallsign,
+	ErrNoBeaconCodesAvailable.Error():       ErrNoBeaconCodesAvailable,
 	ErrNoController.Error():                 ErrNoController,
 	ErrNoFlightPlan.Error():                 ErrNoFlightPlan,
 	ErrNoValidDepartureFound.Error():        ErrNoValidDepartureFound,
@@ -71,22 +99,43 @@ var errorStringToError = map[string]error{
 	ErrUnknownAircraftType.Error():          ErrUnknownAircraftType,
 	ErrUnknownAirport.Error():               ErrUnknownAirport,
 	ErrUnknownApproach.Error():              ErrUnknownApproach,
+	ErrUnknownFix.Error():                   ErrUnknownFix,
+	ErrUnknownPhraseology.Error():           ErrUnknownPhraseology,
 	ErrUnknownRunway.Error():                ErrUnknownRunway,
 	ErrControllerAlreadySignedIn.Error():    ErrControllerAlreadySignedIn,
 	ErrDuplicateSimName.Error():             ErrDuplicateSimName,
 	ErrInvalidControllerToken.Error():       ErrInvalidControllerToken,
 	ErrNoNamedSim.Error():                   ErrNoNamedSim,
 	ErrNoSimForControllerToken.Error():      ErrNoSimForControllerToken,
+	ErrNotInstructor.Error():                ErrNotInstructor,
+	ErrNotPseudoPilot.Error():               ErrNotPseudoPilot,
+	ErrNoPilotInstruction.Error():           ErrNoPilotInstruction,
 	ErrRPCTimeout.Error():                   ErrRPCTimeout,
 	ErrRPCVersionMismatch.Error():           ErrRPCVersionMismatch,
 	ErrRestoringSavedState.Error():          ErrRestoringSavedState,
 	ErrInvalidPassword.Error():              ErrInvalidPassword,
+	ErrInvalidMacro.Error():                 ErrInvalidMacro,
+	ErrMacroArguments.Error():               ErrMacroArguments,
+	ErrMacroRecursion.Error():               ErrMacroRecursion,
 }
 
 func TryDecodeError(e error) error {
 	if err, ok := errorStringToError[e.Error()]; ok {
 		return err
 	}
+
+	// The error may have been wrapped with more details (e.g., "Unknown
+	// approach I2R at KJFK"); if so, rewrap it with the longest matching
+	// error.
+	var base error
+	for s, err := range errorStringToError {
+		if strings.HasPrefix(e.Error(), s) && (base == nil || len(s) > len(base.Error())) {
+			base = err
+		}
+	}
+	if base != nil {
+		return fmt.Errorf("%w%s", base, strings.TrimPrefix(e.Error(), base.Error()))
+	}
 	return e
 }
 
@@ -126,13 +175,16 @@ var (
 )
 
 var starsErrorRemap = map[error]*STARSError{
+	ErrAircraftAssociated:           ErrSTARSIllegalFlight,
 	ErrClearedForUnexpectedApproach: ErrSTARSIllegalValue,
+	ErrDuplicateBeaconCode:          ErrSTARSDuplicateBeacon,
 	ErrFixNotInRoute:                ErrSTARSIllegalFix,
 	ErrInvalidAltitude:              ErrSTARSIllegalValue,
 	ErrInvalidApproach:              ErrSTARSIllegalValue,
 	ErrInvalidCommandSyntax:         ErrSTARSCommandFormat,
 	ErrInvalidHeading:               ErrSTARSIllegalValue,
 	ErrNoAircraftForCallsign:        ErrSTARSNoFlight,
+	ErrNoBeaconCodesAvailable:       ErrSTARSIllegalCode,
 	ErrNoController:                 ErrSTARSIllegalSector,
 	ErrNoFlightPlan:                 ErrSTARSIllegalFlight,
 	ErrNotBeingHandedOffToMe:        ErrSTARSIllegalTrack,
@@ -144,6 +196,8 @@ var starsErrorRemap = map[error]*STARSError{
 	ErrUnknownAircraftType:          ErrSTARSIllegalParam,
 	ErrUnknownAirport:               ErrSTARSIllegalAirport,
 	ErrUnknownApproach:              ErrSTARSIllegalValue,
+	ErrUnknownFix:                   ErrSTARSIllegalFix,
+	ErrUnknownPhraseology:           ErrSTARSCommandFormat,
 	ErrUnknownRunway:                ErrSTARSIllegalValue,
 }
 
@@ -153,13 +207,13 @@ func GetSTARSError(e error) *STARSError {
 	}
 
 	if _, ok := e.(rpc.ServerError); ok {
-		if err, ok := errorStringToError[e.Error()]; ok {
-			e = err
-		}
+		e = TryDecodeError(e)
 	}
 
-	if se, ok := starsErrorRemap[e]; ok {
-		return se
+	for err := e; err != nil; err = errors.Unwrap(err) {
+		if se, ok := starsErrorRemap[err]; ok {
+			return se
+		}
 	}
 
 	lg.Errorf("%v: unexpected error passed to GetSTARSError", e)
@@ -192,6 +192,7 @@ const (
 	IdentEvent
 	HandoffControllEvent
 	SetGlobalLeaderLineEvent
+	PrivateMessageEvent
 	NumEventTypes
 )
 
@@ -200,7 +201,7 @@ func (t EventType) String() string {
 		"OfferedHandoff", "AcceptedHandoff", "CanceledHandoff", "RejectedHandoff",
 		"RadioTransmission", "StatusMessage", "ServerBroadcastMessage",
 		"AcknowledgedPointOut", "RejectedPointOut", "Ident", "HandoffControll",
-		"SetGlobalLeaderLine"}[t]
+		"SetGlobalLeaderLine", "PrivateMessage"}[t]
 }
 
 type Event struct {
@@ -0,0 +1,327 @@
+// export.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Export of an airport's procedures, fixes, and runways as GeoJSON and
+// KML so that they can be checked visually in GIS tools and Google Earth
+// when writing scenarios.
+
+// ExportFeature is a point or a line with associated properties; it
+// corresponds to a GeoJSON feature or a KML placemark.
+type ExportFeature struct {
+	Name         string
+	Points       []Point2LL // a single point or a line string
+	Altitudes    []float32  // feet, for each point; may be nil
+	Properties   map[string]any
+	IsLineString bool
+}
+
+// ProcedureExporter collects the features to be exported.
+type ProcedureExporter struct {
+	Features []ExportFeature
+	locate   func(string) (Point2LL, bool)
+	fixes    map[string]interface{}
+}
+
+func NewProcedureExporter(locate func(string) (Point2LL, bool)) *ProcedureExporter {
+	return &ProcedureExporter{locate: locate, fixes: make(map[string]interface{})}
+}
+
+// AddAirport adds the airport's runways, SIDs, STARs, and approaches, as
+// well as all of the fixes along the procedures.
+func (pe *ProcedureExporter) AddAirport(icao string) error {
+	ap, ok := database.Airports[icao]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownAirport, icao)
+	}
+
+	for _, rwy := range ap.Runways {
+		f := ExportFeature{
+			Name:      icao + " " + rwy.Id,
+			Points:    []Point2LL{rwy.Threshold},
+			Altitudes: []float32{float32(rwy.Elevation)},
+			Properties: map[string]any{
+				"airport":   icao,
+				"type":      "runway",
+				"runway":    rwy.Id,
+				"heading":   rwy.Heading,
+				"elevation": rwy.Elevation,
+				"length":    rwy.Length,
+			},
+		}
+		if opp, ok := oppositeRunway(ap.Runways, rwy.Id); ok {
+			f.Points = append(f.Points, opp.Threshold)
+			f.Altitudes = append(f.Altitudes, float32(opp.Elevation))
+			f.IsLineString = true
+		}
+		pe.Features = append(pe.Features, f)
+	}
+
+	for _, name := range SortedMapKeys(ap.SIDs) {
+		sid := ap.SIDs[name]
+		for _, tr := range SortedMapKeys(sid.Transitions) {
+			pe.addProcedure(icao, "SID", name, tr, sid.Transitions[tr])
+		}
+	}
+	for _, name := range SortedMapKeys(ap.STARs) {
+		star := ap.STARs[name]
+		for _, tr := range SortedMapKeys(star.Transitions) {
+			pe.addProcedure(icao, "STAR", name, tr, star.Transitions[tr])
+		}
+		for _, rwy := range SortedMapKeys(star.RunwayWaypoints) {
+			pe.addProcedure(icao, "STAR", name, "RW"+rwy, star.RunwayWaypoints[rwy])
+		}
+	}
+	for _, name := range SortedMapKeys(ap.Approaches) {
+		for _, wps := range ap.Approaches[name] {
+			// Approaches don't have named transitions; use the first fix.
+			tr := ""
+			if len(ap.Approaches[name]) > 1 && len(wps) > 0 {
+				tr = wps[0].Fix
+			}
+			pe.addProcedure(icao, "approach", name, tr, wps)
+		}
+	}
+
+	return nil
+}
+
+func (pe *ProcedureExporter) addProcedure(icao, typ, name, transition string, wps WaypointArray) {
+	fullName := icao + " " + name + Select(transition != "", "."+transition, "")
+	line := ExportFeature{
+		Name: fullName,
+		Properties: map[string]any{
+			"airport":    icao,
+			"type":       typ,
+			"procedure":  name,
+			"transition": transition,
+			"route":      wps.Encode(),
+		},
+		IsLineString: true,
+	}
+
+	for _, wp := range wps {
+		p := wp.Location
+		if p.IsZero() {
+			var ok bool
+			if p, ok = pe.locate(wp.Fix); !ok {
+				continue
+			}
+		}
+		line.Points = append(line.Points, p)
+
+		// Each fix is exported once, with its restrictions on this
+		// procedure in the procedure's waypoint features below.
+		if _, ok := pe.fixes[wp.Fix]; !ok {
+			pe.fixes[wp.Fix] = nil
+			pe.Features = append(pe.Features, ExportFeature{
+				Name:       wp.Fix,
+				Points:     []Point2LL{p},
+				Properties: map[string]any{"type": "fix", "fix": wp.Fix},
+			})
+		}
+
+		if wp.AltitudeRestriction != nil || wp.Speed != 0 {
+			props := map[string]any{
+				"airport":    icao,
+				"type":       typ + " restriction",
+				"procedure":  name,
+				"transition": transition,
+				"fix":        wp.Fix,
+			}
+			if ar := wp.AltitudeRestriction; ar != nil {
+				props["altitude_restriction"] = ar.Encoded()
+				if ar.Range[0] != 0 {
+					props["min_altitude"] = ar.Range[0]
+				}
+				if ar.Range[1] != 0 {
+					props["max_altitude"] = ar.Range[1]
+				}
+			}
+			if wp.Speed != 0 {
+				props["speed"] = wp.Speed
+			}
+			pe.Features = append(pe.Features, ExportFeature{
+				Name:       fullName + " " + wp.Fix,
+				Points:     []Point2LL{p},
+				Properties: props,
+			})
+		}
+	}
+
+	if len(line.Points) >= 2 {
+		pe.Features = append(pe.Features, line)
+	}
+}
+
+// WriteGeoJSON writes the features as a GeoJSON FeatureCollection.
+func (pe *ProcedureExporter) WriteGeoJSON(w io.Writer) error {
+	type geometry struct {
+		Type        string `json:"type"`
+		Coordinates any    `json:"coordinates"`
+	}
+	type feature struct {
+		Type       string         `json:"type"`
+		Geometry   geometry       `json:"geometry"`
+		Properties map[string]any `json:"properties"`
+	}
+
+	coords := func(f ExportFeature, i int) []float32 {
+		c := []float32{f.Points[i][0], f.Points[i][1]}
+		if f.Altitudes != nil {
+			c = append(c, f.Altitudes[i]*0.3048) // meters
+		}
+		return c
+	}
+
+	var features []feature
+	for _, f := range pe.Features {
+		props := DuplicateMap(f.Properties)
+		props["name"] = f.Name
+
+		g := geometry{Type: "Point", Coordinates: coords(f, 0)}
+		if f.IsLineString {
+			var c [][]float32
+			for i := range f.Points {
+				c = append(c, coords(f, i))
+			}
+			g = geometry{Type: "LineString", Coordinates: c}
+		}
+		features = append(features, feature{Type: "Feature", Geometry: g, Properties: props})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Type     string    `json:"type"`
+		Features []feature `json:"features"`
+	}{Type: "FeatureCollection", Features: features})
+}
+
+// WriteKML writes the features as KML placemarks, with their properties
+// in each placemark's extended data.
+func (pe *ProcedureExporter) WriteKML(w io.Writer, name string) error {
+	type data struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value"`
+	}
+	type placemark struct {
+		Name         string  `xml:"name"`
+		ExtendedData []data  `xml:"ExtendedData>Data"`
+		Point        *string `xml:"Point>coordinates,omitempty"`
+		LineString   *string `xml:"LineString>coordinates,omitempty"`
+	}
+
+	coords := func(f ExportFeature) string {
+		var c []string
+		for i, p := range f.Points {
+			alt := float32(0)
+			if f.Altitudes != nil {
+				alt = f.Altitudes[i] * 0.3048 // meters
+			}
+			c = append(c, fmt.Sprintf("%f,%f,%.0f", p[0], p[1], alt))
+		}
+		return strings.Join(c, " ")
+	}
+
+	var placemarks []placemark
+	for _, f := range pe.Features {
+		pm := placemark{Name: f.Name}
+		for _, k := range SortedMapKeys(f.Properties) {
+			pm.ExtendedData = append(pm.ExtendedData, data{Name: k, Value: fmt.Sprintf("%v", f.Properties[k])})
+		}
+		c := coords(f)
+		if f.IsLineString {
+			pm.LineString = &c
+		} else {
+			pm.Point = &c
+		}
+		placemarks = append(placemarks, pm)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(struct {
+		XMLName    xml.Name    `xml:"kml"`
+		Xmlns      string      `xml:"xmlns,attr"`
+		Name       string      `xml:"Document>name"`
+		Placemarks []placemark `xml:"Document>Placemark"`
+	}{Xmlns: "http://www.opengis.net/kml/2.2", Name: name, Placemarks: placemarks})
+}
+
+// ExportProcedures writes GeoJSON and KML files with the given path
+// prefix for either an airport or all of the airports in the scenario
+// group with the given name.
+func ExportProcedures(name, prefix string) error {
+	locate := func(s string) (Point2LL, bool) {
+		if n, ok := database.Navaids[s]; ok {
+			return n.Location, true
+		} else if f, ok := database.Fixes[s]; ok {
+			return f.Location, true
+		} else if ap, ok := database.Airports[s]; ok {
+			return ap.Location, true
+		}
+		return Point2LL{}, false
+	}
+	airports := []string{name}
+
+	if _, ok := database.Airports[name]; !ok {
+		var e ErrorLogger
+		scenarioGroups, _ := LoadScenarioGroups(&e)
+		var sg *ScenarioGroup
+		for _, groups := range scenarioGroups {
+			if g, ok := groups[name]; ok {
+				sg = g
+			}
+		}
+		if sg == nil {
+			return fmt.Errorf("%s: not an airport or scenario group", name)
+		}
+		locate = sg.locate
+		airports = SortedMapKeys(sg.Airports)
+	}
+
+	pe := NewProcedureExporter(locate)
+	for _, ap := range airports {
+		if err := pe.AddAirport(ap); err != nil {
+			return err
+		}
+	}
+	// Put the fixes last so that they're drawn on top of the routes.
+	slices.SortStableFunc(pe.Features, func(a, b ExportFeature) int {
+		fa, fb := a.Properties["type"] == "fix", b.Properties["type"] == "fix"
+		return Select(fa == fb, 0, Select(fa, 1, -1))
+	})
+
+	write := func(filename string, write func(io.Writer) error) error {
+		f, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		if err := write(f); err != nil {
+			f.Close()
+			return err
+		}
+		fmt.Printf("Wrote %s\n", filename)
+		return f.Close()
+	}
+	if err := write(prefix+".geojson", pe.WriteGeoJSON); err != nil {
+		return err
+	}
+	return write(prefix+".kml", func(w io.Writer) error { return pe.WriteKML(w, name) })
+}
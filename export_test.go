@@ -0,0 +1,101 @@
+// export_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+)
+
+func TestExportProcedures(t *testing.T) {
+	saved := database
+	defer func() { database = saved }()
+
+	database = &StaticDatabase{
+		Airports: map[string]FAAAirport{
+			"KJFK": FAAAirport{
+				Runways: []Runway{
+					Runway{Id: "4R", Threshold: Point2LL{-73.79, 40.62}, Elevation: 12},
+					Runway{Id: "22L", Threshold: Point2LL{-73.77, 40.65}, Elevation: 13},
+				},
+				STARs: map[string]STAR{"CAMRN5": STAR{Transitions: map[string]WaypointArray{
+					"": WaypointArray{
+						Waypoint{Fix: "CAMRN", AltitudeRestriction: &AltitudeRestriction{Range: [2]float32{11000, 11000}}, Speed: 250},
+						Waypoint{Fix: "KARRS"},
+					},
+				}}},
+			},
+		},
+		Fixes: map[string]Fix{
+			"CAMRN": Fix{Id: "CAMRN", Location: Point2LL{-73.86, 40.02}},
+			"KARRS": Fix{Id: "KARRS", Location: Point2LL{-73.88, 40.35}},
+		},
+	}
+
+	pe := NewProcedureExporter(func(s string) (Point2LL, bool) {
+		f, ok := database.Fixes[s]
+		return f.Location, ok
+	})
+	if err := pe.AddAirport("KJFK"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pe.AddAirport("KBOS"); err == nil {
+		t.Errorf("expected error for unknown airport")
+	}
+
+	var gj bytes.Buffer
+	if err := pe.WriteGeoJSON(&gj); err != nil {
+		t.Fatal(err)
+	}
+	var fc struct {
+		Features []struct {
+			Geometry struct {
+				Type string `json:"type"`
+			} `json:"geometry"`
+			Properties map[string]any `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(gj.Bytes(), &fc); err != nil {
+		t.Fatalf("invalid GeoJSON: %v", err)
+	}
+
+	count := make(map[string]int)
+	for _, f := range fc.Features {
+		typ := f.Properties["type"].(string)
+		count[typ]++
+		switch typ {
+		case "STAR":
+			if f.Geometry.Type != "LineString" {
+				t.Errorf("STAR geometry %q, expected LineString", f.Geometry.Type)
+			}
+		case "STAR restriction":
+			if f.Properties["altitude_restriction"] != "11000" || f.Properties["speed"] != float64(250) {
+				t.Errorf("unexpected restriction properties %v", f.Properties)
+			}
+		}
+	}
+	for typ, n := range map[string]int{"runway": 2, "STAR": 1, "STAR restriction": 1, "fix": 2} {
+		if count[typ] != n {
+			t.Errorf("got %d %q features, expected %d", count[typ], typ, n)
+		}
+	}
+
+	var kml bytes.Buffer
+	if err := pe.WriteKML(&kml, "KJFK"); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Placemarks []struct {
+			Name string `xml:"name"`
+		} `xml:"Document>Placemark"`
+	}
+	if err := xml.Unmarshal(kml.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid KML: %v", err)
+	} else if len(doc.Placemarks) != len(fc.Features) {
+		t.Errorf("got %d placemarks, expected %d", len(doc.Placemarks), len(fc.Features))
+	}
+}
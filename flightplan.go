@@ -0,0 +1,330 @@
+// flightplan.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// Flight plan entry and amendment: controllers may create flight plans
+// for VFR pop-ups and unassociated targets and may amend the route,
+// requested altitude, aircraft type, equipment suffix, and destination of
+// the flight plans of the aircraft they're tracking.
+
+// EquipmentSuffix returns the equipment suffix in the flight plan's
+// aircraft type (e.g., "L" for "B738/L"), if there is one.
+func (fp FlightPlan) EquipmentSuffix() string {
+	if s := strings.TrimPrefix(fp.AircraftType, fp.TypeWithoutSuffix()); len(s) > 1 && s[0] == '/' {
+		return s[1:]
+	}
+	return ""
+}
+
+// SetAircraftType updates the flight plan's aircraft type and equipment
+// suffix; either may be empty, in which case the current one is kept.
+func (fp *FlightPlan) SetAircraftType(actype, suffix string) {
+	if actype == "" {
+		actype = fp.TypeWithoutSuffix()
+	}
+	if suffix == "" {
+		suffix = fp.EquipmentSuffix()
+	}
+	fp.AircraftType = actype + Select(suffix != "", "/"+suffix, "")
+}
+
+// checkFlightPlan returns an error if the flight plan's aircraft type or
+// airports are unknown.
+func checkFlightPlan(fp FlightPlan) error {
+	if _, ok := database.AircraftPerformance[fp.BaseType()]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownAircraftType, fp.BaseType())
+	}
+	for _, ap := range []string{fp.DepartureAirport, fp.ArrivalAirport} {
+		if _, ok := database.Airports[ap]; ap != "" && !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownAirport, ap)
+		}
+	}
+	return nil
+}
+
+// ExpandRoute returns the waypoints for a flight plan route like "DEEZZ5
+// CANDR J60 PSB HNK ROBUC3": airways are expanded to the fixes along them
+// between the fixes before and after them, a SID at the departure airport
+// is expanded to its common route and the transition given by the
+// following fix, and a STAR at the arrival airport is expanded from the
+// preceding fix. "DCT", the airports, and the "/." that indicates that the
+// route starts at the aircraft's present position are skipped. If some of
+// the route can't be expanded, the waypoints for the rest of it are
+// returned along with an error.
+func ExpandRoute(route, departure, arrival string, locate func(string) (Point2LL, bool)) ([]Waypoint, error) {
+	var wps []Waypoint
+	var err error
+	add := func(wp Waypoint) {
+		if wp.Location.IsZero() {
+			var ok bool
+			if wp.Location, ok = locate(wp.Fix); !ok {
+				if err == nil {
+					err = fmt.Errorf("%w: %s", ErrUnknownFix, wp.Fix)
+				}
+				return
+			}
+		}
+		if n := len(wps); n == 0 || wps[n-1].Fix != wp.Fix {
+			wps = append(wps, wp)
+		}
+	}
+	prevFix := func() string {
+		if len(wps) == 0 {
+			return ""
+		}
+		return wps[len(wps)-1].Fix
+	}
+
+	var fields []string
+	for _, f := range strings.Fields(strings.ToUpper(route)) {
+		if _, ok := locate(f); !ok && f != "/." && strings.Contains(f, ".") {
+			// SID.TRANSITION or TRANSITION.STAR
+			fields = append(fields, strings.FieldsFunc(f, func(r rune) bool { return r == '.' })...)
+		} else {
+			fields = append(fields, f)
+		}
+	}
+
+	dep, arr := database.Airports[departure], database.Airports[arrival]
+	for i, f := range fields {
+		next := ""
+		if i+1 < len(fields) {
+			next = fields[i+1]
+		}
+
+		if f == "DCT" || f == "/." || f == departure || f == arrival {
+			continue
+		} else if sid, ok := dep.SIDs[f]; ok {
+			tr, ok := sid.Transitions[next]
+			if !ok {
+				tr = sid.Transitions[""]
+			}
+			for _, wp := range tr {
+				add(wp)
+			}
+		} else if star, ok := arr.STARs[f]; ok {
+			if swps := star.GetWaypointsFrom(prevFix()); swps != nil {
+				for _, wp := range swps {
+					add(wp)
+				}
+			} else if err == nil {
+				err = fmt.Errorf("%w: %s is not on %s", ErrUnknownFix, prevFix(), f)
+			}
+		} else if airways, ok := database.Airways[f]; ok {
+			found := false
+			for _, awy := range airways {
+				if fixes, ok := awy.FixesBetween(prevFix(), next); ok {
+					for _, fix := range fixes {
+						add(Waypoint{Fix: fix})
+					}
+					found = true
+					break
+				}
+			}
+			if !found && err == nil {
+				err = fmt.Errorf("%w: %s and %s are not both on %s", ErrUnknownFix, prevFix(), next, f)
+			}
+		} else {
+			add(Waypoint{Fix: f})
+		}
+	}
+	return wps, err
+}
+
+// replanRoute updates the aircraft's route to fly the given waypoints.
+// If the last of them is in the current route, the rest of the current
+// route after it (e.g., a STAR and approach) is kept.
+func (nav *Nav) replanRoute(wps []Waypoint) {
+	if len(wps) == 0 {
+		return
+	}
+	last := wps[len(wps)-1].Fix
+	if idx := slices.IndexFunc(nav.Waypoints, func(wp Waypoint) bool { return wp.Fix == last }); idx != -1 {
+		wps = append(wps[:len(wps)-1], nav.Waypoints[idx:]...)
+	}
+	nav.Waypoints = wps
+}
+
+// updateArrivalAirport updates the aircraft's navigation state for a new
+// destination.
+func (nav *Nav) updateArrivalAirport(icao string) {
+	if ap, ok := database.Airports[icao]; ok {
+		nav.FlightState.ArrivalAirportLocation = ap.Location
+		nav.FlightState.ArrivalAirportElevation = float32(ap.Elevation)
+	}
+}
+
+// CreateFlightPlan creates a flight plan for an untracked aircraft: a VFR
+// pop-up or an unassociated target. The controller starts tracking it
+// and the aircraft is assigned a beacon code for the plan. Aircraft that
+// are squawking the code of the flight plan they already have must be
+// tracked instead.
+func (s *Sim) CreateFlightPlan(token, callsign string, fp FlightPlan) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	var squawk Squawk
+	return s.dispatchCommand(token, callsign,
+		func(ctrl *Controller, ac *Aircraft) error {
+			if ac.TrackingController != "" {
+				return ErrOtherControllerHasTrack
+			}
+			if ac.IsAssociated() {
+				return ErrAircraftAssociated
+			}
+			if ac.FlightPlan != nil && fp.DepartureAirport == "" {
+				fp.DepartureAirport = ac.FlightPlan.DepartureAirport
+			}
+			if err := checkFlightPlan(fp); err != nil {
+				return err
+			}
+			var err error
+			squawk, err = s.World.AllocateSquawk(Select(fp.Rules == VFR, VFRBeaconCode, InternalBeaconCode))
+			return err
+		},
+		func(ctrl *Controller, ac *Aircraft) []RadioTransmission {
+			ac.FlightPlan = &fp
+			ac.Nav.updateArrivalAirport(fp.ArrivalAirport)
+			if fp.Altitude != 0 {
+				ac.Nav.FinalAltitude = float32(fp.Altitude)
+			}
+
+			// The pilot has called up, so they're on frequency.
+			ac.TrackingController = ctrl.Callsign
+			ac.ControllingController = ctrl.Callsign
+			s.eventStream.Post(Event{
+				Type:         InitiatedTrackEvent,
+				Callsign:     ac.Callsign,
+				ToController: ctrl.Callsign,
+			})
+			s.lg.Info("created flight plan", slog.String("callsign", ac.Callsign), slog.Any("flight_plan", fp))
+
+			return s.assignSquawk(ctrl, ac, squawk)
+		})
+}
+
+// AmendFlightPlan replaces the aircraft's flight plan with the amended
+// one. If the controller is talking to the aircraft, the pilot
+// acknowledges the amendment; if the route has changed, the pilot is
+// cleared via the new route and flies it. If the pilot can't accept it,
+// the amendment is rejected.
+func (s *Sim) AmendFlightPlan(token, callsign string, fp FlightPlan) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	var wps []Waypoint
+	var replan bool
+	return s.dispatchCommand(token, callsign,
+		func(ctrl *Controller, ac *Aircraft) error {
+			if ac.TrackingController != ctrl.Callsign && ac.TrackingController != "" {
+				return ErrOtherControllerHasTrack
+			}
+			if ac.FlightPlan == nil {
+				return ErrNoFlightPlan
+			}
+			if err := checkFlightPlan(fp); err != nil {
+				return err
+			}
+
+			if fp.Route != ac.FlightPlan.Route {
+				var err error
+				if wps, err = ExpandRoute(fp.Route, fp.DepartureAirport, fp.ArrivalAirport, s.World.Locate); err != nil {
+					return err
+				}
+				replan = len(wps) > 0 && ac.ControllingController == ctrl.Callsign && !ac.NORDO
+				if replan && ac.Nav.Approach.Cleared {
+					return fmt.Errorf("%w: cleared for the approach", ErrUnableCommand)
+				}
+			}
+			return nil
+		},
+		func(ctrl *Controller, ac *Aircraft) []RadioTransmission {
+			prev := *ac.FlightPlan
+			*ac.FlightPlan = fp
+			if fp.ArrivalAirport != prev.ArrivalAirport {
+				ac.Nav.updateArrivalAirport(fp.ArrivalAirport)
+			}
+			if fp.Altitude != prev.Altitude {
+				ac.Nav.FinalAltitude = float32(fp.Altitude)
+			}
+			s.lg.Info("amended flight plan", slog.String("callsign", ac.Callsign),
+				slog.Any("prev_flight_plan", prev), slog.Any("flight_plan", fp))
+
+			if !replan {
+				if ac.ControllingController != ctrl.Callsign || ac.NORDO {
+					return nil
+				} else if fp.ArrivalAirport != prev.ArrivalAirport {
+					return ac.readback("roger, new destination %s", fp.ArrivalAirport)
+				}
+				return ac.readback("roger, flight plan amended")
+			}
+			ac.Nav.replanRoute(wps)
+			return ac.readback("cleared to %s via %s", fp.ArrivalAirport,
+				strings.Join(MapSlice(wps, func(wp Waypoint) string { return FixReadback(wp.Fix) }), ", "))
+		})
+}
+
+// closestFixAhead returns the index of the waypoint closest to the
+// aircraft of the ones that are in front of it, or -1 if none are.
+func closestFixAhead(ac *Aircraft, wps []Waypoint) int {
+	closest, dist := -1, float32(0)
+	for i, wp := range wps {
+		hdg := headingp2ll(ac.Position(), wp.Location, ac.NmPerLongitude(), ac.MagneticVariation())
+		if headingDifference(ac.Heading(), hdg) >= 70 {
+			continue
+		}
+		if d := nmdistance2ll(ac.Position(), wp.Location); closest == -1 || d < dist {
+			closest, dist = i, d
+		}
+	}
+	return closest
+}
+
+// ClearedAsFiled clears the aircraft to fly the route in its flight plan,
+// starting at the fix on it that's closest to the aircraft of the ones in
+// front of it. Aircraft that have been cleared for an approach keep
+// flying it.
+func (s *Sim) ClearedAsFiled(token, callsign string) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	return s.dispatchCommand(token, callsign,
+		func(ctrl *Controller, ac *Aircraft) error {
+			if ac.ControllingController != ctrl.Callsign {
+				return ErrOtherControllerHasTrack
+			}
+			if ac.Nav.Approach.Cleared {
+				return fmt.Errorf("%w: cleared for the approach", ErrUnableCommand)
+			}
+			return nil
+		},
+		func(ctrl *Controller, ac *Aircraft) []RadioTransmission {
+			if ac.NORDO {
+				return nil
+			} else if ac.FlightPlan == nil {
+				return ac.readbackUnexpected("unable, we don't have a flight plan")
+			}
+			fp := ac.FlightPlan
+			wps, err := ExpandRoute(fp.Route, fp.DepartureAirport, fp.ArrivalAirport, s.World.Locate)
+			if err != nil || len(wps) == 0 {
+				return ac.readbackUnexpected("unable, we can't find our filed route")
+			}
+
+			closest := closestFixAhead(ac, wps)
+			if closest == -1 {
+				return ac.readbackUnexpected("unable, our filed route is behind us")
+			}
+			ac.Nav.replanRoute(wps[closest:])
+			ac.Nav.EnqueueHeading(NavHeading{})
+			return ac.readback("cleared as filed")
+		})
+}
@@ -0,0 +1,261 @@
+// flightplan_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFlightPlanAircraftType(t *testing.T) {
+	for _, test := range []struct {
+		actype, newType, suffix string
+		expectedSuffix          string
+		expected                string
+	}{
+		{actype: "B738/L", expectedSuffix: "L", newType: "A320", expected: "A320/L"},
+		{actype: "B738/L", expectedSuffix: "L", suffix: "G", expected: "B738/G"},
+		{actype: "B738", newType: "C172", suffix: "G", expected: "C172/G"},
+		{actype: "H/B744/L", expectedSuffix: "L", suffix: "W", expected: "H/B744/W"},
+		{actype: "H/B744", newType: "H/B748", expected: "H/B748"},
+	} {
+		fp := FlightPlan{AircraftType: test.actype}
+		if s := fp.EquipmentSuffix(); s != test.expectedSuffix {
+			t.Errorf("%s: got suffix %q, expected %q", test.actype, s, test.expectedSuffix)
+		}
+		fp.SetAircraftType(test.newType, test.suffix)
+		if fp.AircraftType != test.expected {
+			t.Errorf("%s: got %q, expected %q", test.actype, fp.AircraftType, test.expected)
+		}
+	}
+}
+
+func TestReplanRoute(t *testing.T) {
+	fixes := func(wps []Waypoint) []string {
+		return MapSlice(wps, func(wp Waypoint) string { return wp.Fix })
+	}
+	makeWaypoints := func(fixes ...string) []Waypoint {
+		return MapSlice(fixes, func(fix string) Waypoint { return Waypoint{Fix: fix} })
+	}
+
+	for _, test := range []struct {
+		route, amended, expected []string
+	}{
+		// Rejoins the route at the last fix
+		{route: []string{"MERIT", "HFD", "PUT", "BOS"}, amended: []string{"CCC", "PUT"},
+			expected: []string{"CCC", "PUT", "BOS"}},
+		// Entirely new route
+		{route: []string{"MERIT", "HFD", "PUT", "BOS"}, amended: []string{"CCC", "SEY"},
+			expected: []string{"CCC", "SEY"}},
+	} {
+		nav := Nav{Waypoints: makeWaypoints(test.route...)}
+		nav.replanRoute(makeWaypoints(test.amended...))
+		if f := fixes(nav.Waypoints); !slices.Equal(f, test.expected) {
+			t.Errorf("%v amended with %v: got %v, expected %v", test.route, test.amended, f, test.expected)
+		}
+	}
+}
+
+func TestClosestFixAhead(t *testing.T) {
+	const nmPerLongitude = 45
+	ll := func(x float32) Point2LL {
+		return nm2ll([2]float32{x, 0}, nmPerLongitude)
+	}
+	wps := []Waypoint{
+		Waypoint{Fix: "A", Location: ll(-10)},
+		Waypoint{Fix: "B", Location: ll(0)},
+		Waypoint{Fix: "C", Location: ll(10)},
+		Waypoint{Fix: "D", Location: ll(20)},
+	}
+
+	for _, test := range []struct {
+		x, heading float32
+		expected   int
+	}{
+		// B is closer but it's been passed.
+		{x: 1, heading: 90, expected: 2},
+		{x: 1, heading: 270, expected: 1},
+		{x: -15, heading: 80, expected: 0},
+		{x: 25, heading: 90, expected: -1},
+	} {
+		ac := &Aircraft{}
+		ac.Nav.FlightState.NmPerLongitude = nmPerLongitude
+		ac.Nav.FlightState.Position = ll(test.x)
+		ac.Nav.FlightState.Heading = test.heading
+		if i := closestFixAhead(ac, wps); i != test.expected {
+			t.Errorf("at %.0fnm heading %.0f: got %d, expected %d", test.x, test.heading, i, test.expected)
+		}
+	}
+}
+
+func TestAmendFlightPlan(t *testing.T) {
+	saved := database
+	defer func() { database = saved }()
+	database = &StaticDatabase{
+		Airports:            map[string]FAAAirport{"KJFK": FAAAirport{}, "KBOS": FAAAirport{}},
+		AircraftPerformance: map[string]AircraftPerformance{"B738": AircraftPerformance{}},
+	}
+
+	fp := FlightPlan{Rules: IFR, AircraftType: "B738", ArrivalAirport: "KJFK", Altitude: 11000}
+	ac := &Aircraft{Callsign: "AAL1", TrackingController: "1A", ControllingController: "1A", FlightPlan: &fp}
+	s := &Sim{
+		World: &World{
+			Aircraft:    map[string]*Aircraft{"AAL1": ac},
+			Controllers: map[string]*Controller{"1A": &Controller{Callsign: "1A"}},
+		},
+		controllers: map[string]*ServerController{"token": &ServerController{Callsign: "1A"}},
+		eventStream: NewEventStream(),
+	}
+	sub := s.eventStream.Subscribe()
+
+	for _, test := range []struct {
+		amend    func(fp *FlightPlan)
+		readback string
+	}{
+		{amend: func(fp *FlightPlan) { fp.Altitude = 13000 }, readback: "roger, flight plan amended"},
+		{amend: func(fp *FlightPlan) { fp.ArrivalAirport = "KBOS" }, readback: "roger, new destination KBOS"},
+	} {
+		amended := *ac.FlightPlan
+		test.amend(&amended)
+		if err := s.AmendFlightPlan("token", "AAL1", amended); err != nil {
+			t.Errorf("unexpected error %v", err)
+		} else if *ac.FlightPlan != amended {
+			t.Errorf("flight plan not amended: got %+v, expected %+v", *ac.FlightPlan, amended)
+		}
+		if !slices.ContainsFunc(sub.Get(), func(e Event) bool {
+			return e.Type == RadioTransmissionEvent && e.Callsign == "AAL1" && e.Message == test.readback
+		}) {
+			t.Errorf("pilot didn't respond %q", test.readback)
+		}
+	}
+}
+
+func TestClearedAsFiledOnApproach(t *testing.T) {
+	ac := &Aircraft{
+		Callsign:              "AAL1",
+		ControllingController: "1A",
+		FlightPlan:            &FlightPlan{Rules: IFR, Route: "DIXIE V229 PANZE", ArrivalAirport: "KJFK"},
+	}
+	ac.Nav.Approach.Cleared = true
+	ac.Nav.Waypoints = []Waypoint{Waypoint{Fix: "ROSLY"}}
+	s := &Sim{
+		World: &World{
+			Aircraft:    map[string]*Aircraft{"AAL1": ac},
+			Controllers: map[string]*Controller{"1A": &Controller{Callsign: "1A"}},
+		},
+		controllers: map[string]*ServerController{"token": &ServerController{Callsign: "1A"}},
+		eventStream: NewEventStream(),
+	}
+
+	if err := s.ClearedAsFiled("token", "AAL1"); !errors.Is(err, ErrUnableCommand) {
+		t.Errorf("expected ErrUnableCommand, got %v", err)
+	}
+	if len(ac.Nav.Waypoints) != 1 || ac.Nav.Waypoints[0].Fix != "ROSLY" {
+		t.Errorf("route of aircraft cleared for the approach was changed: %+v", ac.Nav.Waypoints)
+	}
+}
+
+func TestExpandRoute(t *testing.T) {
+	saved := database
+	defer func() { database = saved }()
+
+	makeWaypoints := func(fixes ...string) WaypointArray {
+		return MapSlice(fixes, func(fix string) Waypoint { return Waypoint{Fix: fix} })
+	}
+	database = &StaticDatabase{
+		Airports: map[string]FAAAirport{
+			"KJFK": FAAAirport{SIDs: map[string]SID{"DEEZZ5": SID{Transitions: map[string]WaypointArray{
+				"":      makeWaypoints("DEEZZ", "HEERO"),
+				"CANDR": makeWaypoints("DEEZZ", "HEERO", "CANDR"),
+			}}}},
+			"KBOS": FAAAirport{STARs: map[string]STAR{"ROBUC3": STAR{Transitions: map[string]WaypointArray{
+				"HNK": makeWaypoints("HNK", "ROBUC", "LAIRS"),
+			}}}},
+		},
+		Airways: map[string][]Airway{
+			"J60": []Airway{Airway{Name: "J60", Fixes: []string{"PSB", "LVZ", "CANDR", "ETX"}}},
+			"J75": []Airway{Airway{Name: "J75", Fixes: []string{"MERIT", "CMK", "HNK"}}},
+		},
+	}
+	locate := func(fix string) (Point2LL, bool) {
+		if fix == "UNKNW" || strings.Contains(fix, ".") {
+			return Point2LL{}, false
+		}
+		return Point2LL{1, 1}, true
+	}
+
+	for _, test := range []struct {
+		route    string
+		expected []string
+		err      bool
+	}{
+		{route: "DEEZZ5 CANDR J60 PSB DCT HNK ROBUC3",
+			expected: []string{"DEEZZ", "HEERO", "CANDR", "LVZ", "PSB", "HNK", "ROBUC", "LAIRS"}},
+		{route: "KJFK DEEZZ5.CANDR ETX KBOS", expected: []string{"DEEZZ", "HEERO", "CANDR", "ETX"}},
+		{route: "/. MERIT J75 HNK ROBUC3", expected: []string{"MERIT", "CMK", "HNK", "ROBUC", "LAIRS"}},
+		{route: "MERIT J60 HNK", expected: []string{"MERIT", "HNK"}, err: true},
+		{route: "MERIT UNKNW HNK", expected: []string{"MERIT", "HNK"}, err: true},
+	} {
+		wps, err := ExpandRoute(test.route, "KJFK", "KBOS", locate)
+		if f := MapSlice(wps, func(wp Waypoint) string { return wp.Fix }); !slices.Equal(f, test.expected) {
+			t.Errorf("%s: got %v, expected %v", test.route, f, test.expected)
+		}
+		if (err != nil) != test.err {
+			t.Errorf("%s: got error %v", test.route, err)
+		}
+	}
+}
+
+func TestCreateFlightPlan(t *testing.T) {
+	saved := database
+	defer func() { database = saved }()
+	database = &StaticDatabase{
+		AircraftPerformance: map[string]AircraftPerformance{"C172": AircraftPerformance{}},
+	}
+
+	aircraft := func(callsign string, sq Squawk, tracking string) *Aircraft {
+		return &Aircraft{
+			Callsign:           callsign,
+			Mode:               Charlie,
+			Squawk:             sq,
+			AssignedSquawk:     Squawk(0o4521),
+			TrackingController: tracking,
+			FlightPlan:         &FlightPlan{Rules: IFR, AircraftType: "B738"},
+		}
+	}
+	s := &Sim{
+		World: &World{
+			Aircraft: map[string]*Aircraft{
+				"AAL1": aircraft("AAL1", Squawk(0o4521), ""),
+				"AAL2": aircraft("AAL2", Squawk(0o4521), "2B"),
+				"N1AB": aircraft("N1AB", Squawk(0o1200), ""),
+			},
+			Controllers: map[string]*Controller{"1A": &Controller{Callsign: "1A"}},
+		},
+		controllers: map[string]*ServerController{"token": &ServerController{Callsign: "1A"}},
+		eventStream: NewEventStream(),
+	}
+
+	fp := FlightPlan{Rules: VFR, AircraftType: "C172"}
+	for callsign, expected := range map[string]error{
+		"AAL1": ErrAircraftAssociated,
+		"AAL2": ErrOtherControllerHasTrack,
+		"N1AB": nil,
+	} {
+		if err := s.CreateFlightPlan("token", callsign, fp); err != expected {
+			t.Errorf("%s: got error %v, expected %v", callsign, err, expected)
+		}
+	}
+
+	if ac := s.World.Aircraft["N1AB"]; ac.FlightPlan.AircraftType != "C172" || ac.TrackingController != "1A" ||
+		ac.AssignedSquawk == Squawk(0o4521) {
+		t.Errorf("flight plan not created for unassociated target: %+v", ac)
+	}
+	if ac := s.World.Aircraft["AAL1"]; ac.FlightPlan.AircraftType != "B738" || ac.TrackingController != "" {
+		t.Errorf("associated aircraft's flight plan was replaced: %+v", ac)
+	}
+}
@@ -39,6 +39,7 @@ var (
 	FontAwesomeIconCog                 = faUsedIcons["Cog"]
 	FontAwesomeIconCopyright           = faUsedIcons["Copyright"]
 	FontAwesomeIconDiscord             = faBrandsUsedIcons["Discord"]
+	FontAwesomeIconDrawPolygon         = faUsedIcons["DrawPolygon"]
 	FontAwesomeIconExclamationTriangle = faUsedIcons["ExclamationTriangle"]
 	FontAwesomeIconFile                = faUsedIcons["File"]
 	FontAwesomeIconFolder              = faUsedIcons["Folder"]
@@ -78,6 +79,7 @@ var (
 		"CheckSquare":         FontAwesomeString("CheckSquare"),
 		"Cog":                 FontAwesomeString("Cog"),
 		"Copyright":           FontAwesomeString("Copyright"),
+		"DrawPolygon":         FontAwesomeString("DrawPolygon"),
 		"ExclamationTriangle": FontAwesomeString("ExclamationTriangle"),
 		"File":                FontAwesomeString("File"),
 		"Folder":              FontAwesomeString("Folder"),
@@ -0,0 +1,449 @@
+// instructor.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/mmp/imgui-go/v4"
+)
+
+// The instructor signs on to a running multi-controller sim without
+// working a position. They see all of the traffic and the controllers'
+// actions; once an instructor is signed in, only they can pause the sim
+// or change its rate. The instructor can also inject emergencies, radio
+// failures, wind shifts, and specific aircraft, and can exchange private
+// messages with the trainees.
+
+const InstructorCallsign = "Instructor"
+
+func (s *Sim) instructorIsSignedIn() bool {
+	for _, ctrl := range s.controllers {
+		if ctrl.Instructor {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Sim) SignOnInstructor() (*World, string, error) {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	if s.instructorIsSignedIn() {
+		return nil, "", ErrControllerAlreadySignedIn
+	}
+
+	s.eventStream.Post(Event{
+		Type:    StatusMessageEvent,
+		Message: InstructorCallsign + " has signed on.",
+	})
+	s.lg.Info("instructor signed on")
+
+	return s.addServerController(ServerController{
+		Callsign:   InstructorCallsign,
+		Instructor: true,
+	})
+}
+
+// checkInstructor returns an error if the token doesn't belong to the
+// instructor.
+func (s *Sim) checkInstructor(token string) error {
+	if sc, ok := s.controllers[token]; !ok {
+		return ErrInvalidControllerToken
+	} else if !sc.Instructor {
+		return ErrNotInstructor
+	}
+	return nil
+}
+
+// checkSimControl returns an error if the token's holder isn't allowed
+// to pause the sim or change its rate: anyone can if there's no
+// instructor, but otherwise it's up to the instructor.
+func (s *Sim) checkSimControl(token string) error {
+	if sc, ok := s.controllers[token]; !ok {
+		return ErrInvalidControllerToken
+	} else if !sc.Instructor && s.instructorIsSignedIn() {
+		return ErrNotInstructor
+	}
+	return nil
+}
+
+func (s *Sim) DeclareEmergency(token, callsign string) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	if err := s.checkInstructor(token); err != nil {
+		return err
+	}
+	ac, ok := s.World.Aircraft[callsign]
+	if !ok {
+		return ErrNoAircraftForCallsign
+	}
+
+	ac.Squawk = Squawk(0o7700)
+	s.lg.Info("instructor declared emergency", slog.String("callsign", callsign))
+
+	problem := Sample("we've lost an engine", "we have smoke in the cockpit",
+		"we have a medical emergency on board", "we have a hydraulic failure")
+	PostRadioEvents(callsign, []RadioTransmission{RadioTransmission{
+		Controller: ac.ControllingController,
+		Message:    "mayday, mayday, mayday, " + problem,
+		Type:       RadioTransmissionUnexpected,
+	}}, s)
+
+	return nil
+}
+
+func (s *Sim) ToggleNORDO(token, callsign string) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	if err := s.checkInstructor(token); err != nil {
+		return err
+	}
+	ac, ok := s.World.Aircraft[callsign]
+	if !ok {
+		return ErrNoAircraftForCallsign
+	}
+
+	ac.NORDO = !ac.NORDO
+	s.lg.Info("instructor toggled NORDO", slog.String("callsign", callsign), slog.Bool("nordo", ac.NORDO))
+
+	if ac.NORDO {
+		ac.Squawk = Squawk(0o7600)
+	} else {
+		ac.Squawk = ac.AssignedSquawk
+		PostRadioEvents(callsign, []RadioTransmission{RadioTransmission{
+			Controller: ac.ControllingController,
+			Message:    "we had a radio problem but we're back with you now",
+			Type:       RadioTransmissionUnexpected,
+		}}, s)
+	}
+
+	return nil
+}
+
+// metarWind returns the given wind in the format used in METARs.
+func metarWind(wind Wind) string {
+	if wind.Speed <= 0 {
+		return "00000KT"
+	}
+	str := fmt.Sprintf("%03d%02d", wind.Direction, wind.Speed)
+	if wind.Gust > wind.Speed {
+		str += fmt.Sprintf("G%02d", wind.Gust)
+	}
+	return str + "KT"
+}
+
+func (s *Sim) SetWind(token string, wind Wind) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	if err := s.checkInstructor(token); err != nil {
+		return err
+	}
+	if wind.Direction < 1 || wind.Direction > 360 {
+		return ErrInvalidHeading
+	}
+
+	s.World.Wind = wind
+	for icao, m := range s.World.METAR {
+		// Make a copy so that Worlds that share the old METAR don't
+		// see it change underneath them.
+		metar := *m
+		metar.Wind = metarWind(wind)
+		s.World.METAR[icao] = &metar
+	}
+	s.lg.Info("instructor changed wind", slog.Any("wind", wind))
+
+	msg := fmt.Sprintf("Wind is now %03d at %d", wind.Direction, wind.Speed)
+	if wind.Gust > wind.Speed {
+		msg += fmt.Sprintf(" gusting %d", wind.Gust)
+	}
+	s.eventStream.Post(Event{
+		Type:    StatusMessageEvent,
+		Message: msg + ".",
+	})
+
+	return nil
+}
+
+// SendPrivateMessage sends a message that is only shown to the given
+// controller. The instructor may send messages to any controller, but
+// trainees may only send them to the instructor.
+func (s *Sim) SendPrivateMessage(token, controller, message string) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	sc, ok := s.controllers[token]
+	if !ok {
+		return ErrInvalidControllerToken
+	}
+	if !sc.Instructor && controller != InstructorCallsign {
+		return ErrNotInstructor
+	}
+	if !s.controllerIsSignedIn(controller) {
+		return ErrNoController
+	}
+
+	s.eventStream.Post(Event{
+		Type:           PrivateMessageEvent,
+		FromController: sc.Callsign,
+		ToController:   controller,
+		Message:        message,
+	})
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////
+// InstructorWindow
+
+type InstructorWindow struct {
+	callsign string
+
+	wind Wind
+
+	spawnDeparture bool
+	spawnGroup     string // arrival group or departure airport
+	spawnAirport   string // arrival airport or departure runway
+	spawnCallsign  string
+	spawnType      string
+
+	messageTo string
+	message   string
+
+	err error
+}
+
+func MakeInstructorWindow(w *World) *InstructorWindow {
+	return &InstructorWindow{wind: w.Wind}
+}
+
+func (iw *InstructorWindow) onErr(err error) {
+	iw.err = err
+}
+
+func (iw *InstructorWindow) Draw(w *World) {
+	imgui.SetNextWindowSizeConstraints(imgui.Vec2{300, 100}, imgui.Vec2{-1, float32(platform.WindowSize()[1]) * 19 / 20})
+	imgui.BeginV("Instructor", nil, imgui.WindowFlagsAlwaysAutoResize)
+
+	if imgui.SliderFloatV("Simulation speed", &w.SimRate, 1, 20, "%.1f", 0) {
+		w.SetSimRate(w.SimRate)
+	}
+
+	if imgui.CollapsingHeader("Aircraft") {
+		iw.drawAircraftUI(w)
+	}
+	if imgui.CollapsingHeader("Wind") {
+		iw.drawWindUI(w)
+	}
+	if imgui.CollapsingHeader("Spawn") {
+		iw.drawSpawnUI(w)
+	}
+	if imgui.CollapsingHeader("Message") {
+		iw.drawMessageUI(w)
+	}
+
+	if iw.err != nil {
+		imgui.PushStyleColor(imgui.StyleColorText, imgui.Vec4{1, .5, .5, 1})
+		imgui.Text(iw.err.Error())
+		imgui.PopStyleColor()
+	}
+
+	imgui.End()
+}
+
+func (iw *InstructorWindow) drawAircraftUI(w *World) {
+	if imgui.BeginComboV("Aircraft", iw.callsign, imgui.ComboFlagsHeightLarge) {
+		for _, callsign := range SortedMapKeys(w.Aircraft) {
+			if imgui.SelectableV(callsign, callsign == iw.callsign, 0, imgui.Vec2{}) {
+				iw.callsign = callsign
+			}
+		}
+		imgui.EndCombo()
+	}
+
+	ac, ok := w.Aircraft[iw.callsign]
+	uiStartDisable(!ok)
+	if imgui.Button("Declare emergency") {
+		iw.err = nil
+		w.DeclareEmergency(iw.callsign, iw.onErr)
+	}
+	imgui.SameLine()
+	if imgui.Button(Select(ok && ac.NORDO, "Restore radio", "Lose radio")) {
+		iw.err = nil
+		w.ToggleNORDO(iw.callsign, iw.onErr)
+	}
+	uiEndDisable(!ok)
+}
+
+func (iw *InstructorWindow) drawWindUI(w *World) {
+	imgui.Text(fmt.Sprintf("Current: %s", metarWind(w.Wind)))
+
+	imgui.InputIntV("Direction", &iw.wind.Direction, 10, 30, 0)
+	iw.wind.Direction = clamp(iw.wind.Direction, 1, 360)
+	imgui.InputIntV("Speed", &iw.wind.Speed, 1, 5, 0)
+	iw.wind.Speed = max(iw.wind.Speed, 0)
+	imgui.InputIntV("Gust", &iw.wind.Gust, 1, 5, 0)
+	iw.wind.Gust = max(iw.wind.Gust, 0)
+
+	if imgui.Button("Set wind") {
+		iw.err = nil
+		w.SetWind(iw.wind, iw.onErr)
+	}
+}
+
+func (iw *InstructorWindow) drawSpawnUI(w *World) {
+	if imgui.RadioButton("Arrival", !iw.spawnDeparture) && iw.spawnDeparture {
+		iw.spawnDeparture = false
+		iw.spawnGroup, iw.spawnAirport = "", ""
+	}
+	imgui.SameLine()
+	if imgui.RadioButton("Departure", iw.spawnDeparture) && !iw.spawnDeparture {
+		iw.spawnDeparture = true
+		iw.spawnGroup, iw.spawnAirport = "", ""
+	}
+
+	combo := func(label string, selected *string, options []string) {
+		if imgui.BeginComboV(label, *selected, imgui.ComboFlagsHeightLarge) {
+			for _, opt := range options {
+				if imgui.SelectableV(opt, opt == *selected, 0, imgui.Vec2{}) {
+					*selected = opt
+				}
+			}
+			imgui.EndCombo()
+		}
+	}
+
+	if iw.spawnDeparture {
+		airports := make(map[string]interface{})
+		for _, rwy := range w.DepartureRunways {
+			airports[rwy.Airport] = nil
+		}
+		combo("Airport", &iw.spawnGroup, SortedMapKeys(airports))
+
+		var runways []string
+		for _, rwy := range w.DepartureRunways {
+			if rwy.Airport == iw.spawnGroup {
+				runways = append(runways, rwy.Runway+Select(rwy.Category != "", "/"+rwy.Category, ""))
+			}
+		}
+		combo("Runway", &iw.spawnAirport, runways)
+	} else {
+		combo("Arrival group", &iw.spawnGroup, SortedMapKeys(w.ArrivalGroups))
+
+		airports := make(map[string]interface{})
+		for _, arr := range w.ArrivalGroups[iw.spawnGroup] {
+			for ap := range arr.Airlines {
+				airports[ap] = nil
+			}
+		}
+		combo("Airport", &iw.spawnAirport, SortedMapKeys(airports))
+	}
+
+	imgui.InputTextV("Callsign", &iw.spawnCallsign, imgui.InputTextFlagsCharsUppercase, nil)
+	imgui.InputTextV("Aircraft type", &iw.spawnType, imgui.InputTextFlagsCharsUppercase, nil)
+
+	uiStartDisable(iw.spawnGroup == "" || iw.spawnAirport == "")
+	if imgui.Button("Spawn") {
+		if ac, err := iw.spawn(w); err != nil {
+			iw.err = err
+		} else {
+			iw.err = nil
+			w.LaunchAircraft(*ac, func(err error) { iw.err = err })
+		}
+	}
+	uiEndDisable(iw.spawnGroup == "" || iw.spawnAirport == "")
+}
+
+// spawn creates the aircraft specified in the spawn UI.
+func (iw *InstructorWindow) spawn(w *World) (*Aircraft, error) {
+	callsign := strings.TrimSpace(iw.spawnCallsign)
+	if _, ok := w.Aircraft[callsign]; ok {
+		return nil, ErrDuplicateCallsign
+	}
+
+	acType := strings.TrimSpace(iw.spawnType)
+	if acType != "" {
+		perf, ok := database.AircraftPerformance[acType]
+		if !ok {
+			return nil, ErrUnknownAircraftType
+		}
+		acType = flightPlanAircraftType(acType, perf)
+	}
+
+	var ac *Aircraft
+	if iw.spawnDeparture {
+		rwy, category, _ := strings.Cut(iw.spawnAirport, "/")
+		idx := slices.IndexFunc(w.DepartureRunways, func(r ScenarioGroupDepartureRunway) bool {
+			return r.Airport == iw.spawnGroup && r.Runway == rwy && r.Category == category
+		})
+		if idx == -1 {
+			return nil, ErrUnknownRunway
+		}
+
+		var dep *Departure
+		var err error
+		if ac, dep, err = w.CreateDeparture(iw.spawnGroup, rwy, category, 0, nil); err != nil {
+			return nil, err
+		}
+		if acType != "" {
+			ac.FlightPlan.AircraftType = acType
+			exitRoute := w.DepartureRunways[idx].ExitRoutes[dep.Exit]
+			if err := ac.InitializeDeparture(w, w.Airports[iw.spawnGroup], iw.spawnGroup, dep, rwy, exitRoute); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		var err error
+		if ac, err = w.CreateArrival(iw.spawnGroup, iw.spawnAirport); err != nil {
+			return nil, err
+		}
+		if acType != "" {
+			ac.FlightPlan.AircraftType = acType
+			if err := ac.InitializeArrival(w, ac.ArrivalGroup, ac.ArrivalGroupIndex, ac.WaypointHandoffController); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if callsign != "" {
+		ac.Callsign = callsign
+	}
+	return ac, nil
+}
+
+func (iw *InstructorWindow) drawMessageUI(w *World) {
+	var trainees []string
+	for _, callsign := range SortedMapKeys(w.Controllers) {
+		if w.Controllers[callsign].IsHuman {
+			trainees = append(trainees, callsign)
+		}
+	}
+
+	if imgui.BeginComboV("To", iw.messageTo, 0) {
+		for _, callsign := range trainees {
+			if imgui.SelectableV(callsign, callsign == iw.messageTo, 0, imgui.Vec2{}) {
+				iw.messageTo = callsign
+			}
+		}
+		imgui.EndCombo()
+	}
+	imgui.InputTextV("Message", &iw.message, 0, nil)
+
+	disable := !slices.Contains(trainees, iw.messageTo) || iw.message == ""
+	uiStartDisable(disable)
+	if imgui.Button("Send") {
+		iw.err = nil
+		w.SendPrivateMessage(iw.messageTo, iw.message, iw.onErr)
+		iw.message = ""
+	}
+	uiEndDisable(disable)
+}
@@ -0,0 +1,599 @@
+// lint.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// The checks in PostDeserialize ensure that scenarios are well-formed;
+// the ones here look for problems that only show up once a sim is
+// running: aircraft sent below the MVA, altitude restrictions that
+// aircraft can't make, arrivals with no way onto an approach, handoffs
+// to controllers that aren't in a split, and holes in the airspace.
+
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// LintFinding is a single problem found by -lint. Findings are written
+// one per line as JSON so that they can be processed by other tools.
+type LintFinding struct {
+	Severity      string `json:"severity"`
+	Check         string `json:"check"`
+	TRACON        string `json:"tracon,omitempty"`
+	ScenarioGroup string `json:"scenario_group,omitempty"`
+	Scenario      string `json:"scenario,omitempty"`
+	// Element is the path to the element of the scenario group's JSON
+	// that the finding is about, e.g. "arrival_groups/CAMRN/0".
+	Element string `json:"element,omitempty"`
+	Message string `json:"message"`
+}
+
+const (
+	lintSampleSpacing     = 0.5  // nm, for MVA and airspace sampling
+	lintMaxAirspaceGap    = 2    // nm; wider gaps are assumed to be intended
+	lintArrivalSpeed      = 250  // knots, if the arrival doesn't specify one
+	lintDepartureSpeed    = 250  // knots
+	lintAltitudeTolerance = 1000 // feet, as in InAirspace()
+)
+
+// LintLoadErrors returns findings for errors reported while the scenarios
+// were loaded; the semantic checks aren't run if there are any.
+func LintLoadErrors(e *ErrorLogger) []LintFinding {
+	return MapSlice(e.errors, func(err string) LintFinding {
+		return LintFinding{Severity: LintError, Check: "load", Message: err}
+	})
+}
+
+func WriteLintFindings(w io.Writer, findings []LintFinding) error {
+	enc := json.NewEncoder(w)
+	for _, f := range findings {
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LintScenarioGroups runs the semantic checks on scenario groups that
+// have been loaded and validated.
+func LintScenarioGroups(scenarioGroups map[string]map[string]*ScenarioGroup) []LintFinding {
+	var findings []LintFinding
+	for _, tracon := range SortedMapKeys(scenarioGroups) {
+		for _, name := range SortedMapKeys(scenarioGroups[tracon]) {
+			findings = append(findings, lintScenarioGroup(scenarioGroups[tracon][name])...)
+		}
+	}
+	return findings
+}
+
+type scenarioLinter struct {
+	sg       *ScenarioGroup
+	mvas     []lintMVA
+	findings []LintFinding
+}
+
+type lintMVA struct {
+	*MVA
+	bounds Extent2D
+}
+
+func lintScenarioGroup(sg *ScenarioGroup) []LintFinding {
+	l := &scenarioLinter{sg: sg}
+	for i := range database.MVAs[sg.TRACON] {
+		mva := &database.MVAs[sg.TRACON][i]
+		l.mvas = append(l.mvas, lintMVA{MVA: mva, bounds: Extent2DFromPoints(mva.ExteriorRing)})
+	}
+
+	for _, group := range SortedMapKeys(sg.ArrivalGroups) {
+		for i := range sg.ArrivalGroups[group] {
+			l.checkArrival(group, i)
+		}
+	}
+	for _, icao := range SortedMapKeys(sg.Airports) {
+		ap := sg.Airports[icao]
+		for _, rwy := range SortedMapKeys(ap.DepartureRoutes) {
+			for _, exit := range SortedMapKeys(ap.DepartureRoutes[rwy]) {
+				l.checkDeparture(icao, rwy, exit)
+			}
+		}
+	}
+
+	for _, name := range SortedMapKeys(sg.Scenarios) {
+		s := sg.Scenarios[name]
+		l.checkArrivalApproaches(name, s)
+		l.checkHandoffControllers(name, s)
+		l.checkAirspace(name, s)
+	}
+
+	return l.findings
+}
+
+func (l *scenarioLinter) report(severity, check, scenario, element string, msg string, args ...interface{}) {
+	l.findings = append(l.findings, LintFinding{
+		Severity:      severity,
+		Check:         check,
+		TRACON:        l.sg.TRACON,
+		ScenarioGroup: l.sg.Name,
+		Scenario:      scenario,
+		Element:       element,
+		Message:       fmt.Sprintf(msg, args...),
+	})
+}
+
+// maxMVA returns the highest MVA along the segment from p0 to p1 and
+// where it is; zero is returned if the segment is outside the MVA map.
+func (l *scenarioLinter) maxMVA(p0, p1 Point2LL) (int, Point2LL) {
+	mva, pos := 0, Point2LL{}
+	n := max(1, int(nmdistance2ll(p0, p1)/lintSampleSpacing))
+	for i := 0; i <= n; i++ {
+		p := lerp2f(float32(i)/float32(n), p0, p1)
+		for _, m := range l.mvas {
+			if m.MinimumLimit > mva && m.bounds.Inside(p) && m.Inside(p) {
+				mva, pos = m.MinimumLimit, p
+			}
+		}
+	}
+	return mva, pos
+}
+
+// fleetTypes returns the aircraft types that the given airlines may fly.
+func fleetTypes(airlines []DepartureAirline) []string {
+	types := make(map[string]interface{})
+	for _, al := range airlines {
+		fleet := Select(al.Fleet != "", al.Fleet, "default")
+		for _, ac := range database.Airlines[al.ICAO].Fleets[fleet] {
+			types[ac.ICAO] = nil
+		}
+	}
+	return SortedMapKeys(types)
+}
+
+// The altitude gradients that aircraft can fly, in feet per nm. These
+// mirror the rates that Nav uses when planning to meet restrictions
+// but without its fudge factors.
+func descentGradient(perf AircraftPerformance, alt, speed float32) float32 {
+	rate := perf.Rate.Descent
+	if alt < 10000 {
+		rate = min(rate, 2000) * min(speed/250, 1)
+	}
+	return rate * 60 / speed
+}
+
+func climbGradient(perf AircraftPerformance, speed float32) float32 {
+	rate := Select(perf.Rate.Climb > 2500, perf.Rate.Climb-500, perf.Rate.Climb)
+	return rate * 60 / speed
+}
+
+// slowestType returns the aircraft type with the lowest gradient along
+// with that gradient.
+func slowestType(types []string, gradient func(perf AircraftPerformance) float32) (string, float32) {
+	slowest, sg := "", float32(0)
+	for _, t := range types {
+		perf, ok := database.AircraftPerformance[t]
+		if !ok || perf.Rate.Climb == 0 || perf.Rate.Descent == 0 {
+			continue
+		}
+		if g := gradient(perf); slowest == "" || g < sg {
+			slowest, sg = t, g
+		}
+	}
+	return slowest, sg
+}
+
+///////////////////////////////////////////////////////////////////////////
+// Arrivals
+
+// arrivalProfile follows an arrival along its route, tracking the lowest
+// altitude that it may be at.
+type arrivalProfile struct {
+	alt  float32
+	fix  string   // last fix with an altitude restriction
+	dist float32  // distance flown since fix
+	last Waypoint // previous waypoint
+}
+
+func (l *scenarioLinter) checkArrival(group string, idx int) {
+	ar := l.sg.ArrivalGroups[group][idx]
+	element := fmt.Sprintf("arrival_groups/%s/%d", group, idx)
+	if len(ar.Waypoints) == 0 {
+		return
+	}
+
+	// The MVA check stops at the first fix on an approach to one of the
+	// arrival's airports, since the approach's altitudes take over from
+	// there.
+	approachFixes := make(map[string]interface{})
+	var airlines []DepartureAirline
+	for _, icao := range SortedMapKeys(ar.Airlines) {
+		if ap, ok := l.sg.Airports[icao]; ok {
+			for _, appr := range ap.Approaches {
+				for _, wps := range appr.Waypoints {
+					for _, wp := range wps {
+						approachFixes[wp.Fix] = nil
+					}
+				}
+			}
+		}
+		for _, al := range ar.Airlines[icao] {
+			airlines = append(airlines, DepartureAirline{ICAO: al.ICAO, Fleet: al.Fleet})
+		}
+	}
+	types := fleetTypes(airlines)
+	speed := Select(ar.InitialSpeed != 0, ar.InitialSpeed, float32(lintArrivalSpeed))
+
+	alt := ar.InitialAltitude
+	if ar.AssignedAltitude != 0 {
+		alt = min(alt, ar.AssignedAltitude)
+	}
+	prof := arrivalProfile{alt: alt, fix: ar.Waypoints[0].Fix, last: ar.Waypoints[0]}
+	done := l.followArrival(element, &prof, ar.Waypoints, approachFixes, types, speed)
+
+	for _, icao := range SortedMapKeys(ar.RunwayWaypoints) {
+		for _, rwy := range SortedMapKeys(ar.RunwayWaypoints[icao]) {
+			wps := ar.RunwayWaypoints[icao][rwy]
+			if len(wps) > 0 && wps[0].Fix == prof.last.Fix {
+				wps = wps[1:]
+			}
+			if !done {
+				p := prof
+				l.followArrival(element+"/runway_waypoints/"+icao+"/"+rwy, &p, wps, approachFixes, types, speed)
+			}
+		}
+	}
+}
+
+// followArrival updates the profile along the given waypoints, reporting
+// any problems; it returns true if the approach has been reached.
+func (l *scenarioLinter) followArrival(element string, prof *arrivalProfile, wps WaypointArray,
+	approachFixes map[string]interface{}, types []string, speed float32) bool {
+	for _, wp := range wps {
+		if wp.Location.IsZero() {
+			continue
+		}
+		if wp.Fix != prof.last.Fix {
+			prof.dist += nmdistance2ll(prof.last.Location, wp.Location)
+		}
+
+		if r := wp.AltitudeRestriction; r != nil {
+			target := r.TargetAltitude(prof.alt)
+			if target < prof.alt && prof.dist > 0 {
+				avg := (prof.alt + target) / 2
+				actype, grad := slowestType(types, func(perf AircraftPerformance) float32 {
+					return descentGradient(perf, avg, min(speed, Select(perf.Speed.CruiseTAS > 0, perf.Speed.CruiseTAS, speed)))
+				})
+				if need := (prof.alt - target) / prof.dist; actype != "" && need > grad {
+					l.report(LintError, "altitude-restriction", "", element,
+						"%s can't descend from %.0f at %s to %.0f at %s in %.1f nm: needs %.0f ft/nm but can only do %.0f ft/nm",
+						actype, prof.alt, prof.fix, target, wp.Fix, prof.dist, need, grad)
+				}
+			}
+			prof.alt, prof.fix, prof.dist = target, wp.Fix, 0
+		}
+
+		// The aircraft may descend to meet a restriction at any point
+		// before the fix, so the whole segment is checked at the new
+		// altitude.
+		if mva, p := l.maxMVA(prof.last.Location, wp.Location); mva > 0 && prof.alt < float32(mva) {
+			l.report(LintError, "mva", "", element,
+				"aircraft may be at %.0f between %s and %s but the MVA at %s is %d",
+				prof.alt, prof.last.Fix, wp.Fix, p.DMSString(), mva)
+		}
+
+		prof.last = wp
+		if _, ok := approachFixes[wp.Fix]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkArrivalApproaches makes sure that each arrival in the scenario can
+// get onto an approach to each of its airport's active runways.
+func (l *scenarioLinter) checkArrivalApproaches(name string, s *Scenario) {
+	for _, group := range SortedMapKeys(s.ArrivalGroupDefaultRates) {
+		for idx, ar := range l.sg.ArrivalGroups[group] {
+			element := fmt.Sprintf("arrival_groups/%s/%d", group, idx)
+			for _, icao := range SortedMapKeys(s.ArrivalGroupDefaultRates[group]) {
+				ap, ok := l.sg.Airports[icao]
+				if _, flies := ar.Airlines[icao]; !ok || !flies {
+					continue
+				}
+
+				var active []string
+				for _, rwy := range s.ArrivalRunways {
+					if rwy.Airport == icao {
+						active = append(active, rwy.Runway)
+					}
+				}
+
+				if ar.ExpectApproach != "" {
+					if appr, ok := ap.Approaches[ar.ExpectApproach]; ok && !slices.Contains(active, appr.Runway) {
+						l.report(LintError, "approach", name, element,
+							"\"expect_approach\" %s is to runway %s, which isn't an active arrival runway at %s",
+							ar.ExpectApproach, appr.Runway, icao)
+					}
+				}
+
+				for _, rwy := range active {
+					route := ar.Waypoints
+					if rwps, ok := ar.RunwayWaypoints[icao]; ok {
+						if wps, ok := rwps[rwy]; ok {
+							route = append(slices.Clone(route), wps...)
+						} else {
+							l.report(LintWarning, "approach", name, element,
+								"no \"runway_waypoints\" for active runway %s at %s; aircraft will need vectors", rwy, icao)
+							continue
+						}
+					}
+
+					reached := false
+					for _, id := range SortedMapKeys(ap.Approaches) {
+						if appr := ap.Approaches[id]; appr.Runway == rwy {
+							for _, wps := range appr.Waypoints {
+								for _, wp := range wps {
+									reached = reached || slices.ContainsFunc(route, func(w Waypoint) bool { return w.Fix == wp.Fix })
+								}
+							}
+						}
+					}
+					if !reached {
+						l.report(LintWarning, "approach", name, element,
+							"route doesn't reach any approach to active runway %s at %s; aircraft will need vectors",
+							rwy, icao)
+					}
+				}
+			}
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////
+// Departures
+
+func (l *scenarioLinter) checkDeparture(icao, rwy, exit string) {
+	ap := l.sg.Airports[icao]
+	route := ap.DepartureRoutes[rwy][exit]
+	element := "airports/" + icao + "/departure_routes/" + rwy + "/" + exit
+	if len(route.Waypoints) == 0 {
+		return
+	}
+
+	var airlines []DepartureAirline
+	for _, dep := range ap.Departures {
+		if dep.Exit == exit {
+			airlines = append(airlines, dep.Airlines...)
+		}
+	}
+	types := fleetTypes(airlines)
+
+	// If a virtual controller has the departures, they don't climb
+	// higher than the route's altitude until they're handed off.
+	climbTo := float32(max(route.AssignedAltitude, route.ClearedAltitude))
+	limited := ap.DepartureController != "" && climbTo != 0
+
+	alt := float32(database.Airports[icao].Elevation)
+	fix, dist, last := route.Waypoints[0].Fix, float32(0), route.Waypoints[0]
+	for _, wp := range route.Waypoints {
+		if wp.Location.IsZero() {
+			continue
+		}
+		if wp.Fix != last.Fix {
+			dist += nmdistance2ll(last.Location, wp.Location)
+		}
+
+		if limited {
+			if mva, p := l.maxMVA(last.Location, wp.Location); mva > 0 && climbTo < float32(mva) {
+				l.report(LintError, "mva", "", element,
+					"departures are held at %.0f between %s and %s but the MVA at %s is %d",
+					climbTo, last.Fix, wp.Fix, p.DMSString(), mva)
+			}
+		}
+
+		if r := wp.AltitudeRestriction; r != nil {
+			if top := r.Range[1]; top != 0 {
+				if mva, _ := l.maxMVA(wp.Location, wp.Location); mva > 0 && top < float32(mva) {
+					l.report(LintError, "mva", "", element,
+						"restriction at %s requires departures to be at or below %.0f but the MVA there is %d",
+						wp.Fix, top, mva)
+				}
+			}
+
+			target := r.TargetAltitude(alt)
+			if target > alt && dist > 0 {
+				actype, grad := slowestType(types, func(perf AircraftPerformance) float32 {
+					return climbGradient(perf, min(lintDepartureSpeed, Select(perf.Speed.CruiseTAS > 0, perf.Speed.CruiseTAS, lintDepartureSpeed)))
+				})
+				if need := (target - alt) / dist; actype != "" && need > grad {
+					l.report(LintError, "altitude-restriction", "", element,
+						"%s can't climb from %.0f at %s to %.0f at %s in %.1f nm: needs %.0f ft/nm but can only do %.0f ft/nm",
+						actype, alt, fix, target, wp.Fix, dist, need, grad)
+				}
+			}
+			alt, fix, dist = target, wp.Fix, 0
+		}
+
+		if wp.Handoff {
+			limited = false
+		}
+		last = wp
+	}
+}
+
+// checkHandoffControllers makes sure that the controllers that virtual
+// departure controllers hand off to are in every split; otherwise the
+// handoff goes to whoever is primary.
+func (l *scenarioLinter) checkHandoffControllers(name string, s *Scenario) {
+	for _, rwy := range s.DepartureRunways {
+		ap, ok := l.sg.Airports[rwy.Airport]
+		if !ok || ap.DepartureController == "" {
+			continue
+		}
+		for _, exit := range SortedMapKeys(rwy.ExitRoutes) {
+			if rwy.Category != "" && ap.ExitCategories[exit] != rwy.Category {
+				continue
+			}
+			route := rwy.ExitRoutes[exit]
+			if route.HandoffController == "" {
+				continue
+			}
+
+			element := "airports/" + rwy.Airport + "/departure_routes/" + rwy.Runway + "/" + exit
+			for _, split := range SortedMapKeys(s.SplitConfigurations) {
+				if _, ok := s.SplitConfigurations[split][route.HandoffController]; !ok {
+					l.report(LintError, "handoff", name, element,
+						"\"handoff_controller\" %s isn't in split \"%s\"", route.HandoffController, split)
+				}
+			}
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////
+// Airspace
+
+type lintVolume struct {
+	vol    *ControllerAirspaceVolume
+	bounds Extent2D
+}
+
+func (v lintVolume) inside(p Point2LL) bool {
+	if !v.bounds.Inside(p) {
+		return false
+	}
+	inside := false
+	for _, pts := range v.vol.Boundaries {
+		if PointInPolygon2LL(p, pts) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// checkAirspace samples the scenario's approach and departure airspace
+// to find holes in them: altitude bands with airspace above and below or
+// small lateral gaps with airspace all around them. The volumes listed
+// for each are pieces of a single airspace rather than separate
+// controllers' airspace, so it's expected that they abut and overlap;
+// the two are checked separately since they're unrelated.
+func (l *scenarioLinter) checkAirspace(name string, s *Scenario) {
+	l.checkAirspaceHoles(name, "approach_airspace", s.ApproachAirspaceNames)
+	l.checkAirspaceHoles(name, "departure_airspace", s.DepartureAirspaceNames)
+}
+
+func (l *scenarioLinter) checkAirspaceHoles(name, airspace string, volumeNames []string) {
+	bounds := EmptyExtent2D()
+	var vols []lintVolume
+	for _, n := range volumeNames {
+		for i := range l.sg.Airspace.Volumes[n] {
+			v := lintVolume{vol: &l.sg.Airspace.Volumes[n][i], bounds: EmptyExtent2D()}
+			for _, pts := range v.vol.Boundaries {
+				for _, p := range pts {
+					v.bounds = Union(v.bounds, p)
+					bounds = Union(bounds, p)
+				}
+			}
+			vols = append(vols, v)
+		}
+	}
+	if len(vols) == 0 {
+		return
+	}
+
+	type example struct {
+		count int
+		p     Point2LL
+	}
+	verticalGaps := make(map[[2]int]*example)
+
+	// Sample the airspace on a grid.
+	dlat := float32(lintSampleSpacing) / l.sg.NmPerLatitude
+	dlong := float32(lintSampleSpacing) / l.sg.NmPerLongitude
+	nx := int(bounds.Width()/dlong) + 1
+	ny := int(bounds.Height()/dlat) + 1
+	covered := make([]bool, nx*ny)
+	point := func(x, y int) Point2LL {
+		return Point2LL{bounds.p0[0] + float32(x)*dlong, bounds.p0[1] + float32(y)*dlat}
+	}
+
+	// Look for altitude bands with no airspace, allowing the usual 1000'
+	// separation between volumes.
+	checkVerticalGaps := func(in []lintVolume, p Point2LL) {
+		slices.SortFunc(in, func(a, b lintVolume) int { return a.vol.LowerLimit - b.vol.LowerLimit })
+		top := 0
+		for i, v := range in {
+			if i > 0 && v.vol.LowerLimit-top > lintAltitudeTolerance {
+				key := [2]int{top, v.vol.LowerLimit}
+				if verticalGaps[key] == nil {
+					verticalGaps[key] = &example{p: p}
+				}
+				verticalGaps[key].count++
+			}
+			top = max(top, v.vol.UpperLimit)
+		}
+	}
+
+	var in []lintVolume
+	for y := 0; y < ny; y++ {
+		for x := 0; x < nx; x++ {
+			p := point(x, y)
+			in = in[:0]
+			for _, v := range vols {
+				if v.inside(p) {
+					in = append(in, v)
+				}
+			}
+			checkVerticalGaps(in, p)
+			covered[x+y*nx] = len(in) > 0
+		}
+	}
+
+	// Lateral gaps are uncovered points with airspace close by in all
+	// four directions.
+	steps := int(lintMaxAirspaceGap / lintSampleSpacing)
+	var lateralGaps example
+	for y := 0; y < ny; y++ {
+		for x := 0; x < nx; x++ {
+			if covered[x+y*nx] {
+				continue
+			}
+			surrounded := true
+			for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+				found := false
+				for i := 1; i <= steps && !found; i++ {
+					xx, yy := x+i*d[0], y+i*d[1]
+					found = xx >= 0 && xx < nx && yy >= 0 && yy < ny && covered[xx+yy*nx]
+				}
+				surrounded = surrounded && found
+			}
+			if surrounded {
+				if lateralGaps.count == 0 {
+					lateralGaps.p = point(x, y)
+				}
+				lateralGaps.count++
+			}
+		}
+	}
+
+	element := "scenarios/" + name + "/" + airspace
+	area := func(count int) float32 { return float32(count) * lintSampleSpacing * lintSampleSpacing }
+	for _, key := range SortedMapKeysPred(verticalGaps, func(a, b *[2]int) bool {
+		return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+	}) {
+		ex := verticalGaps[key]
+		l.report(LintWarning, "airspace", name, element,
+			"no airspace between %d and %d over about %.1f sq nm, e.g. at %s", key[0], key[1],
+			area(ex.count), ex.p.DMSString())
+	}
+	if lateralGaps.count > 0 {
+		l.report(LintWarning, "airspace", name, element,
+			"gap in airspace of about %.1f sq nm, e.g. at %s", area(lateralGaps.count), lateralGaps.p.DMSString())
+	}
+}
@@ -0,0 +1,120 @@
+// lint_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+)
+
+func TestLintScenarioGroup(t *testing.T) {
+	saved := database
+	defer func() { database = saved }()
+
+	square := func(x0, y0, x1, y1 float32) []Point2LL {
+		return []Point2LL{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}}
+	}
+	fleet := func(ac string) map[string][]FleetAircraft {
+		return map[string][]FleetAircraft{"default": []FleetAircraft{FleetAircraft{ICAO: ac, Count: 1}}}
+	}
+	perf := func(rate, speed float32) AircraftPerformance {
+		var p AircraftPerformance
+		p.Rate.Climb, p.Rate.Descent, p.Speed.CruiseTAS = rate, rate, speed
+		return p
+	}
+
+	database = &StaticDatabase{
+		Airports: map[string]FAAAirport{"KTST": FAAAirport{Elevation: 100}},
+		Airlines: map[string]Airline{"SLW": Airline{Fleets: fleet("C172")}},
+		AircraftPerformance: map[string]AircraftPerformance{
+			"C172": perf(700, 110),
+		},
+		MVAs: map[string][]MVA{
+			"TST": []MVA{MVA{MinimumLimit: 3000, ExteriorRing: [][2]float32{{-75, 40}, {-74, 40}, {-74, 41}, {-75, 41}}}},
+		},
+	}
+
+	// The arrival descends from 10,000' to 2,000' in 24nm, which a C172
+	// can't do and which puts it below the MVA.
+	arrival := Arrival{
+		Waypoints: WaypointArray{
+			Waypoint{Fix: "FIX1", Location: Point2LL{-74.5, 40.9}},
+			Waypoint{Fix: "FIX2", Location: Point2LL{-74.5, 40.5},
+				AltitudeRestriction: &AltitudeRestriction{Range: [2]float32{2000, 2000}}},
+		},
+		InitialAltitude: 10000,
+		Airlines:        map[string][]ArrivalAirline{"KTST": []ArrivalAirline{ArrivalAirline{ICAO: "SLW"}}},
+	}
+
+	sg := &ScenarioGroup{
+		TRACON: "TST",
+		Name:   "Test",
+		Airports: map[string]*Airport{
+			"KTST": &Airport{
+				Approaches: map[string]*Approach{
+					"I1": &Approach{Runway: "1", Waypoints: []WaypointArray{WaypointArray{Waypoint{Fix: "FIX3"}}}},
+				},
+				DepartureController: "TWR",
+			},
+		},
+		ArrivalGroups: map[string][]Arrival{"A": []Arrival{arrival}},
+		Airspace: Airspace{
+			Volumes: map[string][]ControllerAirspaceVolume{
+				"V1": []ControllerAirspaceVolume{ControllerAirspaceVolume{LowerLimit: 0, UpperLimit: 5000,
+					Boundaries: [][]Point2LL{square(-75, 40, -74.5, 40.5)}}},
+				"V2": []ControllerAirspaceVolume{ControllerAirspaceVolume{LowerLimit: 3000, UpperLimit: 10000,
+					Boundaries: [][]Point2LL{square(-74.6, 40, -74, 40.5)}}},
+				// Leaves a hole from 5,000' to 20,000' above the part of
+				// V1 that V2 doesn't cover.
+				"V3": []ControllerAirspaceVolume{ControllerAirspaceVolume{LowerLimit: 20000, UpperLimit: 25000,
+					Boundaries: [][]Point2LL{square(-75, 40, -74.8, 40.2)}}},
+				// Departure airspace above the approach airspace; there's
+				// no gap since the two aren't considered together.
+				"D1": []ControllerAirspaceVolume{ControllerAirspaceVolume{LowerLimit: 12000, UpperLimit: 15000,
+					Boundaries: [][]Point2LL{square(-75, 40, -74, 40.5)}}},
+			},
+		},
+		Scenarios: map[string]*Scenario{
+			"S": &Scenario{
+				SplitConfigurations: SplitConfigurationSet{
+					"default": SplitConfiguration{"APP": &MultiUserController{Primary: true}},
+				},
+				ArrivalGroupDefaultRates: map[string]map[string]int{"A": {"KTST": 10}},
+				ArrivalRunways:           []ScenarioGroupArrivalRunway{{Airport: "KTST", Runway: "1"}},
+				DepartureRunways: []ScenarioGroupDepartureRunway{{Airport: "KTST", Runway: "1",
+					ExitRoutes: map[string]ExitRoute{"EXIT": ExitRoute{HandoffController: "DEP"}}}},
+				ApproachAirspaceNames:  []string{"V1", "V2", "V3"},
+				DepartureAirspaceNames: []string{"D1"},
+			},
+		},
+		NmPerLatitude:  60,
+		NmPerLongitude: 45,
+	}
+
+	expected := map[[3]string]bool{
+		{LintError, "altitude-restriction", "arrival_groups/A/0"}:       false,
+		{LintError, "mva", "arrival_groups/A/0"}:                        false,
+		{LintWarning, "approach", "arrival_groups/A/0"}:                 false,
+		{LintError, "handoff", "airports/KTST/departure_routes/1/EXIT"}: false,
+		{LintWarning, "airspace", "scenarios/S/approach_airspace"}:      false,
+	}
+	for _, f := range lintScenarioGroup(sg) {
+		key := [3]string{f.Severity, f.Check, f.Element}
+		if found, ok := expected[key]; !ok {
+			t.Errorf("unexpected finding %+v", f)
+		} else if found {
+			t.Errorf("repeated finding %+v", f)
+		} else {
+			expected[key] = true
+		}
+		if f.TRACON != "TST" || f.ScenarioGroup != "Test" {
+			t.Errorf("finding %+v doesn't identify the scenario group", f)
+		}
+	}
+	for key, found := range expected {
+		if !found {
+			t.Errorf("didn't find expected %v", key)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+// macros.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mmp/imgui-go/v4"
+)
+
+// CommandMacro is a user-defined abbreviation for a sequence of aircraft
+// control commands. Macros are defined as "FINAL22 = D30 S180 CI22L";
+// they may also take parameters, as in "APP $1 = E$1 C$1", where "APP
+// I22L" then expands to "EI22L CI22L".
+type CommandMacro struct {
+	Name      string
+	NumParams int
+	Expansion string
+}
+
+// ParseCommandMacro parses a macro definition of the form "NAME [$1 $2
+// ...] = COMMANDS".
+func ParseCommandMacro(def string) (CommandMacro, error) {
+	lhs, rhs, ok := strings.Cut(strings.ToUpper(def), "=")
+	if !ok {
+		return CommandMacro{}, fmt.Errorf("%w: no \"=\" in \"%s\"", ErrInvalidMacro, def)
+	}
+
+	f := strings.Fields(lhs)
+	if len(f) == 0 {
+		return CommandMacro{}, fmt.Errorf("%w: no name given in \"%s\"", ErrInvalidMacro, def)
+	}
+	m := CommandMacro{
+		Name:      f[0],
+		NumParams: len(f) - 1,
+		Expansion: strings.Join(strings.Fields(rhs), " "),
+	}
+
+	if strings.IndexFunc(m.Name, func(r rune) bool { return (r < 'A' || r > 'Z') && (r < '0' || r > '9') }) != -1 {
+		return CommandMacro{}, fmt.Errorf("%w: name \"%s\" must only have letters and numbers", ErrInvalidMacro, m.Name)
+	}
+	if m.NumParams > 9 {
+		return CommandMacro{}, fmt.Errorf("%w: %s: at most 9 parameters may be given", ErrInvalidMacro, m.Name)
+	}
+	for i, p := range f[1:] {
+		if p != "$"+strconv.Itoa(i+1) {
+			return CommandMacro{}, fmt.Errorf("%w: %s: expected parameter $%d, found \"%s\"", ErrInvalidMacro, m.Name, i+1, p)
+		}
+	}
+	if m.Expansion == "" {
+		return CommandMacro{}, fmt.Errorf("%w: %s: no commands given", ErrInvalidMacro, m.Name)
+	}
+	for i := m.NumParams + 1; i <= 9; i++ {
+		if strings.Contains(m.Expansion, "$"+strconv.Itoa(i)) {
+			return CommandMacro{}, fmt.Errorf("%w: %s: $%d used but not declared", ErrInvalidMacro, m.Name, i)
+		}
+	}
+
+	return m, nil
+}
+
+func (m CommandMacro) String() string {
+	s := m.Name
+	for i := 1; i <= m.NumParams; i++ {
+		s += " $" + strconv.Itoa(i)
+	}
+	return s + " = " + m.Expansion
+}
+
+// expand returns the macro's expansion with the given arguments
+// substituted for its parameters.
+func (m CommandMacro) expand(args []string) string {
+	s := m.Expansion
+	for i, arg := range args {
+		s = strings.ReplaceAll(s, "$"+strconv.Itoa(i+1), arg)
+	}
+	return s
+}
+
+///////////////////////////////////////////////////////////////////////////
+// CommandMacros
+
+// maxMacroDepth bounds how deeply macros may expand to other macros,
+// which also catches macros that (indirectly) expand to themselves.
+const maxMacroDepth = 8
+
+// CommandMacros stores the user's macros. They are stored per-TRACON,
+// since fixes and approaches differ from one to the next; macros stored
+// under the empty string are available everywhere.
+type CommandMacros struct {
+	Macros map[string][]CommandMacro
+
+	newMacro     string
+	newForTRACON bool
+	err          error
+	importDialog *FileSelectDialogBox
+	exportDialog *FileSelectDialogBox
+}
+
+func (cm *CommandMacros) lookup(tracon, name string) (CommandMacro, bool) {
+	for _, t := range []string{tracon, ""} {
+		if idx := slices.IndexFunc(cm.Macros[t], func(m CommandMacro) bool { return m.Name == name }); idx != -1 {
+			return cm.Macros[t][idx], true
+		}
+	}
+	return CommandMacro{}, false
+}
+
+// Add adds the macro to the given TRACON's macros, replacing an existing
+// one with the same name.
+func (cm *CommandMacros) Add(tracon string, m CommandMacro) {
+	if cm.Macros == nil {
+		cm.Macros = make(map[string][]CommandMacro)
+	}
+	macros := cm.Macros[tracon]
+	if idx := slices.IndexFunc(macros, func(mm CommandMacro) bool { return mm.Name == m.Name }); idx != -1 {
+		macros[idx] = m
+	} else {
+		macros = append(macros, m)
+		slices.SortFunc(macros, func(a, b CommandMacro) int { return strings.Compare(a.Name, b.Name) })
+	}
+	cm.Macros[tracon] = macros
+}
+
+func (cm *CommandMacros) Delete(tracon, name string) {
+	cm.Macros[tracon] = FilterSlice(cm.Macros[tracon], func(m CommandMacro) bool { return m.Name != name })
+	if len(cm.Macros[tracon]) == 0 {
+		delete(cm.Macros, tracon)
+	}
+}
+
+// Expand returns the given commands with any macros expanded. Macros
+// defined for the TRACON take precedence over ones defined for all
+// TRACONs, and both take precedence over the built-in commands.
+func (cm *CommandMacros) Expand(tracon, cmds string) (string, error) {
+	f, err := cm.expand(tracon, strings.Fields(cmds), 0)
+	return strings.Join(f, " "), err
+}
+
+// ForTRACON returns the macros available in the given TRACON, with the
+// TRACON's own ones first so that they take precedence. These are sent
+// to the sim along with aircraft commands, which expands them there.
+func (cm *CommandMacros) ForTRACON(tracon string) []CommandMacro {
+	if tracon == "" {
+		return slices.Clone(cm.Macros[""])
+	}
+	return append(slices.Clone(cm.Macros[tracon]), cm.Macros[""]...)
+}
+
+// ExpandCommandMacros expands the given macros in cmds; if more than one
+// macro has the same name, the first one in macros is used.
+func ExpandCommandMacros(macros []CommandMacro, cmds string) (string, error) {
+	cm := CommandMacros{Macros: map[string][]CommandMacro{"": macros}}
+	return cm.Expand("", cmds)
+}
+
+func (cm *CommandMacros) expand(tracon string, cmds []string, depth int) ([]string, error) {
+	var expanded []string
+	for i := 0; i < len(cmds); i++ {
+		m, ok := cm.lookup(tracon, cmds[i])
+		if !ok {
+			expanded = append(expanded, cmds[i])
+			continue
+		}
+
+		if depth == maxMacroDepth {
+			return nil, fmt.Errorf("%w: %s", ErrMacroRecursion, m.Name)
+		}
+		if i+m.NumParams >= len(cmds) {
+			return nil, fmt.Errorf("%w: %s expects %d", ErrMacroArguments, m.Name, m.NumParams)
+		}
+
+		e, err := cm.expand(tracon, strings.Fields(m.expand(cmds[i+1:i+1+m.NumParams])), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, e...)
+		i += m.NumParams
+	}
+	return expanded, nil
+}
+
+// Import reads macro definitions from the given file, one per line, and
+// adds them to the given TRACON's macros. Blank lines and lines starting
+// with "#" are ignored.
+func (cm *CommandMacros) Import(tracon, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var macros []CommandMacro
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		def := strings.TrimSpace(scanner.Text())
+		if def == "" || strings.HasPrefix(def, "#") {
+			continue
+		}
+		m, err := ParseCommandMacro(def)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", filename, line, err)
+		}
+		macros = append(macros, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// Only add them once the whole file has been read successfully.
+	for _, m := range macros {
+		cm.Add(tracon, m)
+	}
+	return nil
+}
+
+// Export writes the given TRACON's macros to a file in the format that
+// Import reads, so that they may be shared with others.
+func (cm *CommandMacros) Export(tracon, filename string) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# vice command macros for %s\n", Select(tracon == "", "all TRACONs", tracon))
+	for _, m := range cm.Macros[tracon] {
+		fmt.Fprintln(&sb, m.String())
+	}
+	return os.WriteFile(filename, []byte(sb.String()), 0o644)
+}
+
+func (cm *CommandMacros) DrawUI(tracon string) {
+	flags := imgui.TableFlagsBordersV | imgui.TableFlagsBordersOuterH | imgui.TableFlagsRowBg | imgui.TableFlagsSizingStretchProp
+	if (len(cm.Macros[tracon]) > 0 || len(cm.Macros[""]) > 0) && imgui.BeginTableV("macros", 3, flags, imgui.Vec2{}, 0) {
+		imgui.TableSetupColumn("Macro")
+		imgui.TableSetupColumn("TRACON")
+		imgui.TableSetupColumn("")
+		imgui.TableHeadersRow()
+
+		for _, t := range []string{tracon, ""} {
+			for _, m := range cm.Macros[t] {
+				imgui.PushID(t + m.Name)
+				imgui.TableNextRow()
+				imgui.TableNextColumn()
+				imgui.Text(m.String())
+				imgui.TableNextColumn()
+				imgui.Text(Select(t == "", "All", t))
+				imgui.TableNextColumn()
+				if imgui.Button(FontAwesomeIconTrash) {
+					cm.Delete(t, m.Name)
+				}
+				imgui.PopID()
+			}
+		}
+		imgui.EndTable()
+	}
+
+	imgui.InputTextV("New macro", &cm.newMacro, imgui.InputTextFlagsCharsUppercase, nil)
+	if imgui.IsItemHovered() {
+		imgui.SetTooltip("e.g., \"FINAL22 = D30 S180 CI22L\" or \"APP $1 = E$1 C$1\"")
+	}
+	imgui.SameLine()
+	imgui.Checkbox("Only for "+tracon, &cm.newForTRACON)
+	imgui.SameLine()
+	if imgui.Button("Add") {
+		if m, err := ParseCommandMacro(cm.newMacro); err != nil {
+			cm.err = err
+		} else {
+			cm.Add(Select(cm.newForTRACON, tracon, ""), m)
+			cm.newMacro, cm.err = "", nil
+		}
+	}
+
+	if imgui.Button("Import...") {
+		cm.importDialog = NewFileSelectDialogBox("Import Macros", []string{".txt"}, "",
+			func(filename string) {
+				cm.err = cm.Import(tracon, filename)
+			})
+		cm.importDialog.Activate()
+	}
+	imgui.SameLine()
+	uiStartDisable(len(cm.Macros[tracon]) == 0)
+	if imgui.Button("Export " + tracon + " macros...") {
+		cm.exportDialog = NewDirectorySelectDialogBox("Export Macros", "",
+			func(dir string) {
+				cm.err = cm.Export(tracon, path.Join(dir, tracon+"-macros.txt"))
+			})
+		cm.exportDialog.Activate()
+	}
+	uiEndDisable(len(cm.Macros[tracon]) == 0)
+
+	if cm.importDialog != nil {
+		cm.importDialog.Draw()
+	}
+	if cm.exportDialog != nil {
+		cm.exportDialog.Draw()
+	}
+
+	if cm.err != nil {
+		imgui.PushStyleColor(imgui.StyleColorText, imgui.Vec4{1, .5, .5, 1})
+		imgui.Text(cm.err.Error())
+		imgui.PopStyleColor()
+	}
+}
@@ -0,0 +1,83 @@
+// macros_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommandMacros(t *testing.T) {
+	var cm CommandMacros
+	for _, def := range []struct{ tracon, def string }{
+		{"", "final22 = D30 S180 CI22L"},
+		{"", "APP $1 = E$1 C$1"},
+		{"N90", "FINAL22 = D40 S170 CI22R"},
+		{"", "BOTH $1 $2 = H$1 APP $2"},
+		{"", "LOOP = LOOP"},
+	} {
+		if m, err := ParseCommandMacro(def.def); err != nil {
+			t.Fatalf("%s: %v", def.def, err)
+		} else {
+			cm.Add(def.tracon, m)
+		}
+	}
+
+	for _, test := range [][3]string{
+		[3]string{"", "FINAL22", "D30 S180 CI22L"},
+		[3]string{"N90", "FINAL22", "D40 S170 CI22R"},
+		[3]string{"PHL", "L270 APP I27R S210", "L270 EI27R CI27R S210"},
+		[3]string{"", "BOTH 090 I4R", "H090 EI4R CI4R"},
+	} {
+		if s, err := cm.Expand(test[0], test[1]); err != nil {
+			t.Errorf("%s: %q: unexpected error %v", test[0], test[1], err)
+		} else if s != test[2] {
+			t.Errorf("%s: %q expanded to %q; expected %q", test[0], test[1], s, test[2])
+		}
+	}
+
+	if _, err := cm.Expand("", "L270 APP"); !errors.Is(err, ErrMacroArguments) {
+		t.Errorf("expected ErrMacroArguments, got %v", err)
+	}
+	if _, err := cm.Expand("", "LOOP"); !errors.Is(err, ErrMacroRecursion) {
+		t.Errorf("expected ErrMacroRecursion, got %v", err)
+	}
+
+	// What the sim expands with the macros it's sent.
+	for _, test := range [][3]string{
+		[3]string{"N90", "FINAL22", "D40 S170 CI22R"},
+		[3]string{"PHL", "FINAL22", "D30 S180 CI22L"},
+		[3]string{"N90", "BOTH 090 I4R", "H090 EI4R CI4R"},
+	} {
+		if s, err := ExpandCommandMacros(cm.ForTRACON(test[0]), test[1]); err != nil {
+			t.Errorf("%s: %q: unexpected error %v", test[0], test[1], err)
+		} else if s != test[2] {
+			t.Errorf("%s: %q expanded to %q; expected %q", test[0], test[1], s, test[2])
+		}
+	}
+	if s, err := ExpandCommandMacros(nil, "D30 S180"); err != nil || s != "D30 S180" {
+		t.Errorf("expansion without macros gave %q (%v)", s, err)
+	}
+
+	for _, def := range []string{"FOO D30", "= D30", "FOO $2 = D$2", "FOO = ", "FOO = D$1", "F/X = D30"} {
+		if _, err := ParseCommandMacro(def); !errors.Is(err, ErrInvalidMacro) {
+			t.Errorf("%q: expected ErrInvalidMacro, got %v", def, err)
+		}
+	}
+
+	// Round trip through a file.
+	fn := filepath.Join(t.TempDir(), "N90-macros.txt")
+	if err := cm.Export("", fn); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	var cm2 CommandMacros
+	if err := cm2.Import("N90", fn); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if s, err := cm2.Expand("N90", "APP I22L"); err != nil || s != "EI22L CI22L" {
+		t.Errorf("imported macro expanded to %q (%v); expected \"EI22L CI22L\"", s, err)
+	}
+}
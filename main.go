@@ -59,7 +59,7 @@ var (
 	cpuprofile        = flag.String("cpuprofile", "", "write CPU profile to file")
 	memprofile        = flag.String("memprofile", "", "write memory profile to this file")
 	logLevel          = flag.String("loglevel", "info", "logging level: debug, info, warn, error")
-	lintScenarios     = flag.Bool("lint", false, "check the validity of the built-in scenarios")
+	lintScenarios     = flag.Bool("lint", false, "check the built-in scenarios for problems, printing them as JSON")
 	server            = flag.Bool("runserver", false, "run vice scenario server")
 	serverPort        = flag.Int("port", ViceServerPort, "port to listen on when running server")
 	serverAddress     = flag.String("server", ViceServerAddress+fmt.Sprintf(":%d", ViceServerPort), "IP address of vice multi-controller server")
@@ -69,6 +69,9 @@ var (
 	broadcastPassword = flag.String("password", "", "password to authenticate with server for broadcast message")
 	resetSim          = flag.Bool("resetsim", false, "discard the saved simulation and do not try to resume it")
 	showRoutes        = flag.String("routes", "", "display the STARS, SIDs, and approaches known for the given airport")
+	exportRoutes      = flag.String("export", "", "write GeoJSON and KML files with the procedures, fixes, and runways for the given airport or scenario group")
+	importVideoMaps   = flag.String("importvideomaps", "", "write the video maps in the GeoJSON, shapefile, or XML files given as arguments to this file in vice's format")
+	generateVideoMaps = flag.String("genvideomaps", "", "write video maps generated from the CIFP for the given airport")
 )
 
 func init() {
@@ -108,6 +111,16 @@ func main() {
 	}
 	absPath(memprofile)
 	absPath(cpuprofile)
+	// The export files are written to the current directory.
+	exportPrefix := *exportRoutes
+	absPath(&exportPrefix)
+	absPath(importVideoMaps)
+	videoMapOutput := *generateVideoMaps + "-videomaps.json.zst"
+	absPath(&videoMapOutput)
+	videoMapInputs := flag.Args()
+	for i := range videoMapInputs {
+		absPath(&videoMapInputs[i])
+	}
 
 	writeMemProfile := func() {
 		f, err := os.Create(*memprofile)
@@ -161,15 +174,39 @@ func main() {
 
 	if *lintScenarios {
 		var e ErrorLogger
-		_, _ = LoadScenarioGroups(&e)
+		scenarioGroups, _ := LoadScenarioGroups(&e)
+		findings := LintLoadErrors(&e)
+		if !e.HaveErrors() {
+			findings = LintScenarioGroups(scenarioGroups)
+		}
+		if err := WriteLintFindings(os.Stdout, findings); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		// Only scenarios that fail to load fail the build for now; the
+		// built-in scenarios haven't all been cleaned up to pass the
+		// semantic checks.
 		if e.HaveErrors() {
-			e.PrintErrors(nil)
 			os.Exit(1)
 		}
 	} else if *broadcastMessage != "" {
 		BroadcastMessage(*serverAddress, *broadcastMessage, *broadcastPassword)
 	} else if *server {
 		RunSimServer()
+	} else if *exportRoutes != "" {
+		if err := ExportProcedures(*exportRoutes, exportPrefix); err != nil {
+			fmt.Printf("%s: %v\n", *exportRoutes, err)
+			os.Exit(1)
+		}
+	} else if *importVideoMaps != "" {
+		if err := ConvertVideoMaps(*importVideoMaps, videoMapInputs); err != nil {
+			fmt.Printf("%s: %v\n", *importVideoMaps, err)
+			os.Exit(1)
+		}
+	} else if *generateVideoMaps != "" {
+		if err := GenerateVideoMapFile(*generateVideoMaps, videoMapOutput); err != nil {
+			fmt.Printf("%s: %v\n", *generateVideoMaps, err)
+			os.Exit(1)
+		}
 	} else if *showRoutes != "" {
 		ap, ok := database.Airports[*showRoutes]
 		if !ok {
@@ -61,8 +61,21 @@ type FlightState struct {
 
 	Position Point2LL
 	Heading  float32
-	Altitude float32
+	Altitude float32 // indicated, given the Altimeter setting; see Nav.TrueAltitude
 	IAS, GS  float32 // speeds...
+
+	// Altimeter is the setting in the aircraft's altimeter and
+	// LocalAltimeter is the actual setting at the airport the aircraft
+	// is departing from or arriving at. AltimeterError is added to the
+	// local setting when the pilot sets it, e.g. after misunderstanding
+	// it on the radio.
+	Altimeter      float32
+	LocalAltimeter float32
+	AltimeterError float32
+
+	// Difference between the actual temperature and the ISA
+	// temperature, in degrees C.
+	ISADeviation float32
 }
 
 func (fs *FlightState) Summary() string {
@@ -76,6 +89,7 @@ func (fs FlightState) LogValue() slog.Value {
 		slog.Any("position", fs.Position),
 		slog.Float64("heading", float64(fs.Heading)),
 		slog.Float64("altitude", float64(fs.Altitude)),
+		slog.Float64("altimeter", float64(fs.Altimeter)),
 		slog.Float64("ias", float64(fs.IAS)),
 		slog.Float64("gs", float64(fs.GS)),
 	)
@@ -157,6 +171,7 @@ func MakeArrivalNav(w *World, arr *Arrival, fp FlightPlan, perf AircraftPerforma
 		}
 
 		nav.FlightState.Altitude = arr.InitialAltitude
+		nav.initializeWeather(w, fp.ArrivalAirport, nav.FlightState.ArrivalAirportElevation)
 		nav.FlightState.IAS = arr.InitialSpeed
 		// This won't be quite right but it's better than leaving GS to be
 		// 0 for the first nav update tick which leads to various Inf and
@@ -180,6 +195,7 @@ func MakeDepartureNav(w *World, fp FlightPlan, perf AircraftPerformance, assigne
 		}
 		nav.FlightState.IsDeparture = true
 		nav.FlightState.Altitude = nav.FlightState.DepartureAirportElevation
+		nav.initializeWeather(w, fp.DepartureAirport, nav.FlightState.DepartureAirportElevation)
 		return nav
 	}
 	return nil
@@ -233,6 +249,70 @@ func makeNav(w *World, fp FlightPlan, perf AircraftPerformance, wp []Waypoint) *
 	return nav
 }
 
+// initializeWeather sets the aircraft's local altimeter setting and the
+// temperature deviation from ISA using the METAR at the given airport;
+// with probability given by the launch configuration, the pilot has the
+// altimeter set incorrectly.
+func (nav *Nav) initializeWeather(w *World, airport string, elevation float32) {
+	nav.FlightState.LocalAltimeter = StandardAltimeter
+	if metar, ok := w.METAR[airport]; ok && metar != nil {
+		nav.FlightState.LocalAltimeter = metar.AltimeterSetting()
+		nav.FlightState.ISADeviation = metar.Temperature - ISATemperature(elevation)
+	}
+	if rand.Float32() < w.LaunchConfig.AltimeterErrorRate {
+		// Off by 0.05-0.30 inches.
+		e := float32(5+rand.Intn(26)) / 100
+		nav.FlightState.AltimeterError = Select(rand.Intn(2) == 0, e, -e)
+	}
+
+	if nav.FlightState.Altitude >= TransitionAltitude {
+		nav.FlightState.Altimeter = StandardAltimeter
+	} else {
+		nav.FlightState.Altimeter = nav.FlightState.LocalAltimeter + nav.FlightState.AltimeterError
+	}
+	// Altitude was specified as true altitude; convert it to what the
+	// aircraft's altimeter shows.
+	nav.FlightState.Altitude += nav.altimeterOffset()
+}
+
+// PressureAltitude returns the aircraft's pressure altitude, which is
+// what its transponder reports via Mode C.
+func (nav *Nav) PressureAltitude() float32 {
+	if nav.FlightState.Altimeter == 0 {
+		// Saved sims from before altimeters were tracked.
+		return nav.FlightState.Altitude
+	}
+	return PressureAltitude(nav.FlightState.Altitude, nav.FlightState.Altimeter)
+}
+
+// TrueAltitude returns the aircraft's actual altitude above sea level;
+// it differs from the indicated altitude when the altimeter isn't set to
+// the local setting. Anything physical--terrain, glideslopes, other
+// aircraft--should be compared against this rather than
+// FlightState.Altitude.
+func (nav *Nav) TrueAltitude() float32 {
+	return nav.FlightState.Altitude - nav.altimeterOffset()
+}
+
+// altimeterOffset returns how many feet the aircraft's indicated altitude
+// is above its true altitude.
+func (nav *Nav) altimeterOffset() float32 {
+	if nav.FlightState.Altimeter == 0 || nav.FlightState.LocalAltimeter == 0 {
+		// Saved sims from before altimeters were tracked.
+		return 0
+	}
+	return (nav.FlightState.Altimeter - nav.FlightState.LocalAltimeter) * 1000
+}
+
+// setAltimeter updates the aircraft's altimeter setting, which in turn
+// changes its indicated altitude.
+func (nav *Nav) setAltimeter(alt float32) {
+	if nav.FlightState.Altimeter != 0 {
+		nav.FlightState.Altitude += (alt - nav.FlightState.Altimeter) * 1000
+	}
+	nav.FlightState.Altimeter = alt
+}
+
 func (nav *Nav) TAS() float32 {
 	tas := IASToTAS(nav.FlightState.IAS, nav.FlightState.Altitude)
 	tas = min(tas, nav.Perf.Speed.CruiseTAS)
@@ -537,21 +617,171 @@ func (nav *Nav) ContactMessage(reportingPoints []ReportingPoint, star string) st
 ///////////////////////////////////////////////////////////////////////////
 // Simulation
 
-func (nav *Nav) updateAirspeed(lg *Logger) {
+// updateAirspeedAndAltitude works out the changes in speed and altitude
+// that the aircraft would like to make and then limits them based on how
+// much energy it can gain (with thrust) or lose (to drag) each second.
+// Among other things, this means that descending and slowing down at the
+// same time is difficult, as it is in reality.
+func (nav *Nav) updateAirspeedAndAltitude(lg *Logger) {
+	// Figure out what speed we're supposed to be going. The following is
+	// prioritized, so once targetSpeed has been set, nothing should
+	// override it.  cruising speed.
+	targetSpeed, speedRate := nav.TargetSpeed(lg)
+
+	// Stay within the aircraft's capabilities
+	targetSpeed = clamp(targetSpeed, nav.Perf.Speed.Min, min(MaxIAS, nav.machLimitedIAS()))
+
+	targetAltitude, altitudeRate := nav.TargetAltitude(lg)
+	if nav.FinalAltitude != 0 { // allow 0 for backwards compatability with saved
+		targetAltitude = min(targetAltitude, nav.FinalAltitude)
+	}
+
+	// Desired acceleration in knots per second.
+	var accel float32
 	if nav.Altitude.Expedite {
 		// Don't accelerate or decelerate if we're expediting
 		lg.Debug("expediting altitude, so speed unchanged")
-		return
+	} else if nav.FlightState.IAS < targetSpeed {
+		accel = nav.Perf.Rate.Accelerate / 2 // Accel is given in "per 2 seconds..."
+		accel = min(accel, speedRate/60)
+	} else if nav.FlightState.IAS > targetSpeed {
+		decel := nav.Perf.Rate.Decelerate / 2 // Decel is given in "per 2 seconds..."
+		accel = -min(decel, speedRate/60)
 	}
 
-	// Figure out what speed we're supposed to be going. The following is
-	// prioritized, so once targetSpeed has been set, nothing should
-	// override it.  cruising speed.
-	targetSpeed, targetRate := nav.TargetSpeed(lg)
+	// Desired vertical speed in feet per minute.
+	var vs float32
+	if nav.FlightState.Altitude < targetAltitude {
+		vs = nav.maxClimbRate()
+		if !nav.Altitude.Expedite {
+			vs = min(vs, altitudeRate)
+		}
+	} else if nav.FlightState.Altitude > targetAltitude {
+		descent := nav.Perf.Rate.Descent
+		if !nav.Altitude.Expedite {
+			if nav.FlightState.Altitude < 10000 {
+				// Have a slower baseline rate of descent on approach
+				descent = min(descent, 2000)
+				// And reduce it based on airspeed as well
+				descent *= min(nav.FlightState.IAS/250, 1)
+			}
+			descent = min(descent, altitudeRate)
+		}
+		vs = -descent
+	}
 
-	// Stay within the aircraft's capabilities
-	targetSpeed = clamp(targetSpeed, nav.Perf.Speed.Min, MaxIAS)
+	if nav.IsAirborne() {
+		accel, vs = nav.limitEnergyRate(accel, vs)
+	}
+
+	nav.updateAirspeed(targetSpeed, accel, lg)
+	nav.updateAltitude(targetAltitude, vs, lg)
+}
+
+const (
+	Gravity        = 32.174 // ft/s^2
+	KnotsToFeetSec = 1.68781
+)
+
+// limitEnergyRate takes the desired acceleration (knots/second) and
+// vertical speed (feet/minute) and scales them back as necessary so that
+// the aircraft's total energy--potential plus kinetic--doesn't increase
+// faster than its excess thrust allows or decrease faster than drag
+// allows.
+func (nav *Nav) limitEnergyRate(accel, vs float32) (float32, float32) {
+	// The altitude change in feet that is equivalent in energy to a 1
+	// knot change in IAS: from d(v^2/2g) = v dv / g, where v is the true
+	// airspeed in feet per second.
+	ias := max(nav.FlightState.IAS, 1)
+	tas := IASToTAS(ias, nav.FlightState.Altitude)
+	feetPerKnot := (tas * KnotsToFeetSec) * (tas / ias * KnotsToFeetSec) / Gravity
+
+	// Everything in feet per minute from here on out.
+	accelFPM := accel * feetPerKnot * 60
+	gain := max(vs, 0) + max(accelFPM, 0)
+	loss := max(-vs, 0) + max(-accelFPM, 0)
+
+	// Thrust available in excess of drag when climbing and the energy
+	// lost to drag at idle thrust (which is what the book descent rate
+	// measures); speedbrakes help when expediting.
+	thrust := nav.maxClimbRate()
+	drag := nav.Perf.Rate.Descent
+	if nav.Altitude.Expedite {
+		drag *= 1.5
+	}
 
+	scale := func(v, s float32, positive bool) float32 {
+		if (v > 0) == positive {
+			return v * s
+		}
+		return v
+	}
+	if gain > 0 && gain-loss > thrust {
+		s := (thrust + loss) / gain
+		accel, vs = scale(accel, s, true), scale(vs, s, true)
+	} else if loss > 0 && loss-gain > drag {
+		s := (drag + gain) / loss
+		accel, vs = scale(accel, s, false), scale(vs, s, false)
+	}
+	return accel, vs
+}
+
+// maxClimbRate returns the rate of climb in feet per minute that the
+// aircraft can sustain at its current speed. Performance decreases with
+// density altitude, with weight, and at lower airspeeds.
+func (nav *Nav) maxClimbRate() float32 {
+	climb := nav.Perf.Rate.Climb
+
+	da := DensityAltitude(nav.PressureAltitude(), nav.FlightState.ISADeviation)
+	if nav.Perf.Ceiling > 0 {
+		// Thrust lapses with altitude so that by the aircraft's ceiling
+		// there's not much left.
+		climb *= clamp(1-0.75*da/nav.Perf.Ceiling, 0.15, 1.1)
+	}
+
+	switch nav.Perf.WeightClass {
+	case "H":
+		climb *= 0.9
+	case "J":
+		climb *= 0.85
+	}
+
+	if nav.FlightState.IAS < 250 {
+		climb *= clamp(nav.FlightState.IAS/250, 0.7, 1)
+	}
+
+	if nav.FlightState.IsDeparture &&
+		nav.TrueAltitude()-nav.FlightState.DepartureAirportElevation < 2*initialClimbAltitude {
+		// Still using takeoff thrust
+		climb *= 1.5
+	}
+
+	return climb
+}
+
+// machLimitedIAS returns the indicated airspeed corresponding to the
+// aircraft's cruise Mach number (or its maximum, if that's all that is
+// known) at its current altitude; above the crossover altitude, this is
+// what limits its speed.
+func (nav *Nav) machLimitedIAS() float32 {
+	mach := Select(nav.Perf.Speed.CruiseMach != 0, nav.Perf.Speed.CruiseMach, nav.Perf.Speed.MaxMach)
+	if mach == 0 {
+		return MaxIAS
+	}
+	tas := MachToTAS(mach, nav.PressureAltitude(), nav.FlightState.ISADeviation)
+	return TASToIAS(tas, nav.FlightState.Altitude)
+}
+
+// maxTurnRate returns the aircraft's rate of turn in degrees per second
+// when banked at the 25 degrees that is typical in normal operations;
+// slower aircraft are limited to standard rate turns.
+func (nav *Nav) maxTurnRate() float32 {
+	const maxBank = 25
+	tas := max(nav.TAS(), 1)
+	return min(StandardTurnRate, 1091*tan(radians(maxBank))/tas)
+}
+
+func (nav *Nav) updateAirspeed(targetSpeed, accel float32, lg *Logger) {
 	setSpeed := func(next float32) {
 		if nav.Altitude.AfterSpeed != nil &&
 			(nav.Altitude.Assigned == nil || *nav.Altitude.Assigned == nav.FlightState.Altitude) {
@@ -570,32 +800,14 @@ func (nav *Nav) updateAirspeed(lg *Logger) {
 		nav.FlightState.IAS = next
 	}
 
-	if nav.FlightState.IAS < targetSpeed {
-		accel := nav.Perf.Rate.Accelerate / 2 // Accel is given in "per 2 seconds..."
-		accel = min(accel, targetRate/60)
-		if nav.Altitude.Assigned != nil && nav.FlightState.Altitude < *nav.Altitude.Assigned {
-			// Reduce acceleration since also climbing
-			accel *= 0.6
-		}
+	if accel > 0 {
 		setSpeed(min(targetSpeed, nav.FlightState.IAS+accel))
-	} else if nav.FlightState.IAS > targetSpeed {
-		decel := nav.Perf.Rate.Decelerate / 2 // Decel is given in "per 2 seconds..."
-		decel = min(decel, targetRate/60)
-		if nav.Altitude.Assigned != nil && nav.FlightState.Altitude > *nav.Altitude.Assigned {
-			// Reduce deceleration since also descending
-			decel *= 0.6
-		}
-		setSpeed(max(targetSpeed, nav.FlightState.IAS-decel))
+	} else if accel < 0 {
+		setSpeed(max(targetSpeed, nav.FlightState.IAS+accel))
 	}
 }
 
-func (nav *Nav) updateAltitude(lg *Logger) {
-	targetAltitude, targetRate := nav.TargetAltitude(lg)
-
-	if nav.FinalAltitude != 0 { // allow 0 for backwards compatability with saved
-		targetAltitude = min(targetAltitude, nav.FinalAltitude)
-	}
-
+func (nav *Nav) updateAltitude(targetAltitude, vs float32, lg *Logger) {
 	if targetAltitude == nav.FlightState.Altitude {
 		nav.Altitude.Expedite = false
 		return
@@ -604,9 +816,9 @@ func (nav *Nav) updateAltitude(lg *Logger) {
 	// Wrap altitude setting in a lambda so we can detect when we pass
 	// through an altitude for "at alt, reduce speed" sort of assignments.
 	setAltitude := func(next float32) {
+		cur := nav.FlightState.Altitude
 		if nav.Speed.AfterAltitude != nil &&
 			(nav.Speed.Assigned == nil || *nav.Speed.Assigned == nav.FlightState.IAS) {
-			cur := nav.FlightState.Altitude
 			at := *nav.Speed.AfterAltitudeAltitude
 			if (cur > at && next <= at) || (cur < at && next >= at) {
 				// Reached or passed the altitude, now go for speed
@@ -617,6 +829,17 @@ func (nav *Nav) updateAltitude(lg *Logger) {
 			}
 		}
 		nav.FlightState.Altitude = next
+
+		// Reset the altimeter when climbing through the transition
+		// altitude or descending through the transition level. Note
+		// that the indicated altitude changes when this happens.
+		if nav.FlightState.Altimeter != 0 {
+			if cur < TransitionAltitude && next >= TransitionAltitude {
+				nav.setAltimeter(StandardAltimeter)
+			} else if cur >= TransitionAltitude && next < TransitionAltitude {
+				nav.setAltimeter(nav.FlightState.LocalAltimeter + nav.FlightState.AltimeterError)
+			}
+		}
 	}
 
 	if abs(targetAltitude-nav.FlightState.Altitude) < 3 {
@@ -625,42 +848,16 @@ func (nav *Nav) updateAltitude(lg *Logger) {
 		return
 	}
 
-	// Baseline climb and descent capabilities in ft/minute
-	climb, descent := nav.Perf.Rate.Climb, nav.Perf.Rate.Descent
-
-	// Reduce rates from highest possible to be more realistic.
-	if !nav.Altitude.Expedite {
-		// For high performing aircraft, reduce climb rate after 5,000'
-		if climb >= 2500 && nav.FlightState.Altitude > 5000 {
-			climb -= 500
-		}
-		if nav.FlightState.Altitude < 10000 {
-			// Have a slower baseline rate of descent on approach
-			descent = min(descent, 2000)
-			// And reduce it based on airspeed as well
-			descent *= min(nav.FlightState.IAS/250, 1)
-		}
-		climb = min(climb, targetRate)
-		descent = min(descent, targetRate)
-	}
-
-	if nav.FlightState.Altitude < targetAltitude {
-		if nav.Speed.Assigned != nil && nav.FlightState.IAS < *nav.Speed.Assigned {
-			// Reduce rate due to concurrent acceleration
-			climb *= 0.7
-		}
-		setAltitude(min(targetAltitude, nav.FlightState.Altitude+climb/60))
-	} else if nav.FlightState.Altitude > targetAltitude {
-		if nav.Speed.Assigned != nil && nav.FlightState.IAS > *nav.Speed.Assigned {
-			// Reduce rate due to concurrent deceleration
-			descent *= 0.7
-		}
-		setAltitude(max(targetAltitude, nav.FlightState.Altitude-descent/60))
+	if vs > 0 {
+		setAltitude(min(targetAltitude, nav.FlightState.Altitude+vs/60))
+	} else if vs < 0 {
+		setAltitude(max(targetAltitude, nav.FlightState.Altitude+vs/60))
 	}
 }
 
 func (nav *Nav) updateHeading(wind WindModel, lg *Logger) {
 	targetHeading, turnDirection, turnRate := nav.TargetHeading(wind, lg)
+	turnRate = min(turnRate, nav.maxTurnRate())
 
 	if nav.FlightState.Heading == targetHeading {
 		return
@@ -706,7 +903,7 @@ func (nav *Nav) updatePositionAndGS(wind WindModel, lg *Logger) {
 	// Further offset based on the wind
 	var windVector [2]float32
 	if nav.IsAirborne() && wind != nil {
-		windVector = wind.GetWindVector(nav.FlightState.Position, nav.FlightState.Altitude)
+		windVector = wind.GetWindVector(nav.FlightState.Position, nav.TrueAltitude())
 	}
 
 	// Update the aircraft's state
@@ -754,8 +951,7 @@ func (nav *Nav) Check(lg *Logger) {
 
 // returns passed waypoint if any
 func (nav *Nav) Update(wind WindModel, lg *Logger) *Waypoint {
-	nav.updateAirspeed(lg)
-	nav.updateAltitude(lg)
+	nav.updateAirspeedAndAltitude(lg)
 	nav.updateHeading(wind, lg)
 	nav.updatePositionAndGS(wind, lg)
 
@@ -930,12 +1126,14 @@ func (nav *Nav) LocalizerHeading(wind WindModel, lg *Logger) (heading float32, t
 			// 1. In front of the aircraft.
 			// 2. Closer to the threshold than the aircraft.
 			// 3. On the localizer
+			// (The threshold isn't on offset localizers.)
 			if i+1 < len(ap.Waypoints[0]) {
-				wpToThresholdHeading := headingp2ll(wp.Location, ap.Waypoints[0][n-1].Location,
-					nav.FlightState.NmPerLongitude, nav.FlightState.MagneticVariation)
-				lg.Debugf("heading: fix %s wpToThresholdHeading %f", wp.Fix, wpToThresholdHeading)
-				if headingDifference(wpToThresholdHeading,
-					ap.Heading(nav.FlightState.NmPerLongitude, nav.FlightState.MagneticVariation)) > 3 {
+				nmPerLongitude := nav.FlightState.NmPerLongitude
+				loc := ap.Line()
+				d := PointLineDistance(ll2nm(wp.Location, nmPerLongitude), ll2nm(loc[0], nmPerLongitude),
+					ll2nm(loc[1], nmPerLongitude))
+				lg.Debugf("heading: fix %s is %.2fnm from the localizer", wp.Fix, d)
+				if d > 0.5 {
 					lg.Debugf("heading: fix %s is in front but not on the localizer", wp.Fix)
 					continue
 				}
@@ -994,10 +1192,10 @@ func (nav *Nav) TargetAltitude(lg *Logger) (alt, rate float32) {
 		}
 
 		elev := nav.FlightState.DepartureAirportElevation
-		if nav.FlightState.Altitude-elev < initialClimbAltitude {
+		if nav.TrueAltitude()-elev < initialClimbAltitude {
 			// Just airborne; prioritize climb, though slightly nerf the rate
 			// so aircraft are not too high too soon
-			alt := elev + initialClimbAltitude
+			alt := elev + initialClimbAltitude + nav.altimeterOffset()
 			lg.Debugf("alt: initial climb to %.0f", alt)
 			return alt, 0.8 * maxClimb
 		}
@@ -1032,6 +1230,27 @@ func (nav *Nav) TargetAltitude(lg *Logger) (alt, rate float32) {
 		alt, rate = *nav.Altitude.Assigned, getAssignedRate()
 		lg.Debugf("alt: assigned %.0f, rate %.0f", alt, rate)
 		return
+	} else if gsAlt, ok := nav.glideslopeAltitude(); ok {
+		// The glideslope is a fixed path in space, so it's compared to
+		// the true altitude; the altitude to fly is returned in terms of
+		// what the altimeter shows, like everything else here.
+		trueAlt, indicatedGSAlt := nav.TrueAltitude(), gsAlt+nav.altimeterOffset()
+		if trueAlt > gsAlt+50 {
+			// Intercepting from above; get down to it as quickly as
+			// possible.
+			lg.Debugf("alt: %.0f above the glideslope", trueAlt-gsAlt)
+			return indicatedGSAlt, MaximumRate
+		} else if trueAlt >= gsAlt-50 {
+			// On the glideslope; descend along it, with a little extra
+			// rate so that we don't fall behind it.
+			angle := nav.Approach.Assigned.Localizer.GlideslopeAngle
+			rate = 1.2 * nav.FlightState.GS * NauticalMilesToFeet / 60 * tan(radians(angle))
+			lg.Debugf("alt: on the glideslope at %.0f, rate %.0f", gsAlt, rate)
+			return indicatedGSAlt, rate
+		}
+		// Below it: level off and wait to intercept it.
+		lg.Debugf("alt: %.0f below the glideslope", gsAlt-trueAlt)
+		return nav.FlightState.Altitude, 0
 	} else if c := nav.getWaypointAltitudeConstraint(); c != nil && !nav.flyingPT() {
 		lg.Debugf("alt: altitude %.0f for waypoint %s in %.0f seconds", c.Altitude, c.Fix, c.ETA)
 		if c.ETA < 5 {
@@ -1052,6 +1271,22 @@ func (nav *Nav) TargetAltitude(lg *Logger) (alt, rate float32) {
 	return
 }
 
+// glideslopeAltitude returns the true altitude of the assigned approach's
+// glideslope at the aircraft's position if it's cleared for the approach,
+// established on the localizer, and the approach has a glideslope.
+func (nav *Nav) glideslopeAltitude() (float32, bool) {
+	ap := nav.Approach.Assigned
+	if ap == nil || !nav.Approach.Cleared || nav.flyingPT() ||
+		(nav.Approach.InterceptState != HoldingLocalizer && !nav.Approach.PassedApproachFix) {
+		return 0, false
+	}
+	hdg := ap.Heading(nav.FlightState.NmPerLongitude, nav.FlightState.MagneticVariation)
+	if headingDifference(hdg, nav.FlightState.Heading) > 30 || !nav.OnExtendedCenterline(.5) {
+		return 0, false
+	}
+	return ap.GlideslopeAltitude(nav.FlightState.Position)
+}
+
 func (nav *Nav) flyingPT() bool {
 	return (nav.Heading.RacetrackPT != nil && nav.Heading.RacetrackPT.State != PTStateApproaching) ||
 		(nav.Heading.Standard45PT != nil && nav.Heading.Standard45PT.State != PT45StateApproaching)
@@ -1261,7 +1496,7 @@ func (nav *Nav) TargetSpeed(lg *Logger) (float32, float32) {
 
 		if !nav.IsAirborne() {
 			return targetSpeed, 0.8 * maxAccel
-		} else if agl := nav.FlightState.Altitude - nav.FlightState.DepartureAirportElevation; agl < initialClimbAltitude {
+		} else if agl := nav.TrueAltitude() - nav.FlightState.DepartureAirportElevation; agl < initialClimbAltitude {
 			// Just airborne; prioritize climb, though be aware of any
 			// upcoming speed restrictions.
 			if wp, speed, _ := nav.getUpcomingSpeedRestrictionWaypoint(); nav.Heading.Assigned == nil && wp != nil {
@@ -1535,8 +1770,9 @@ func (nav *Nav) shouldTurnForOutbound(p Point2LL, hdg float32, turn TurnMethod,
 	// Alternatively, if we're far away w.r.t. the needed turn, don't even
 	// consider it. This is both for performance but also so that we don't
 	// make tiny turns miles away from fixes in some cases.
-	turnAngle := TurnAngle(nav.FlightState.Heading, hdg, turn)
-	if turnAngle/2 < eta {
+	// (Allow some slop, since the turn may need to start a bit early.)
+	turnTime := TurnAngle(nav.FlightState.Heading, hdg, turn) / nav.maxTurnRate() // seconds
+	if 1.5*turnTime < eta {
 		return false
 	}
 
@@ -1555,7 +1791,7 @@ func (nav *Nav) shouldTurnForOutbound(p Point2LL, hdg float32, turn TurnMethod,
 		nav2.FlightState.NmPerLongitude), p0, p1)
 
 	// Don't simulate the turn longer than it will take to do it.
-	n := int(1 + turnAngle/3)
+	n := int(1 + turnTime)
 	for i := 0; i < n; i++ {
 		nav2.Update(wind, nil)
 		curDist := SignedPointLineDistance(ll2nm(nav2.FlightState.Position,
@@ -1584,8 +1820,8 @@ func (nav *Nav) shouldTurnToIntercept(p0 Point2LL, hdg float32, turn TurnMethod,
 	}
 
 	// As above, don't consider starting the turn if we're far away.
-	turnAngle := TurnAngle(nav.FlightState.Heading, hdg, turn)
-	if turnAngle/2 < eta {
+	turnTime := TurnAngle(nav.FlightState.Heading, hdg, turn) / nav.maxTurnRate() // seconds
+	if 1.5*turnTime < eta {
 		return false
 	}
 
@@ -1594,7 +1830,7 @@ func (nav *Nav) shouldTurnToIntercept(p0 Point2LL, hdg float32, turn TurnMethod,
 	nav2.DeferredHeading = nil
 	nav2.Approach.InterceptState = NotIntercepting // avoid recursive calls..
 
-	n := int(1 + turnAngle/3)
+	n := int(1 + turnTime)
 	for i := 0; i < n; i++ {
 		nav2.Update(wind, nil)
 		curDist := SignedPointLineDistance(ll2nm(nav2.FlightState.Position, nav2.FlightState.NmPerLongitude), p0, p1)
@@ -1628,6 +1864,13 @@ func (nav *Nav) AssignAltitude(alt float32, afterSpeed bool) PilotResponse {
 	if alt > nav.Perf.Ceiling {
 		return PilotResponse{Message: "unable. That altitude is above our ceiling.", Unexpected: true}
 	}
+	if local := nav.FlightState.LocalAltimeter; local != 0 && alt >= TransitionAltitude &&
+		alt < LowestUsableFlightLevel(local) {
+		return PilotResponse{
+			Message:    "unable. " + FormatAltitude(alt) + " isn't usable with altimeter " + FormatAltimeter(local),
+			Unexpected: true,
+		}
+	}
 
 	var response string
 	if alt > nav.FlightState.Altitude {
@@ -1650,6 +1893,14 @@ func (nav *Nav) AssignAltitude(alt float32, afterSpeed bool) PilotResponse {
 	return PilotResponse{Message: response}
 }
 
+func (nav *Nav) AssignAltimeter(alt float32) PilotResponse {
+	nav.FlightState.AltimeterError = alt - nav.FlightState.LocalAltimeter
+	if nav.FlightState.Altitude < TransitionAltitude {
+		nav.setAltimeter(alt)
+	}
+	return PilotResponse{Message: "altimeter " + FormatAltimeter(alt)}
+}
+
 func (nav *Nav) AssignSpeed(speed float32, afterAltitude bool) PilotResponse {
 	maxIAS := TASToIAS(nav.Perf.Speed.MaxTAS, nav.FlightState.Altitude)
 	maxIAS = 10 * float32(int((maxIAS+5)/10)) // round to 10s
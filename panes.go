@@ -915,6 +915,7 @@ func (mp *MessagesPane) processEvents(w *World) {
 		}
 		lg.Debug("radio_transmission", slog.String("callsign", callsign), slog.Any("message", msg))
 		mp.messages = append(mp.messages, msg)
+		globalConfig.Speech.Speak(msg.contents)
 	}
 
 	for _, event := range mp.events.Get() {
@@ -932,6 +933,31 @@ func (mp *MessagesPane) processEvents(w *World) {
 				}
 				transmissions = append(transmissions, event.Message)
 				unexpectedTransmission = unexpectedTransmission || (event.RadioTransmissionType == RadioTransmissionUnexpected)
+			} else if w.Instructor || w.PseudoPilot {
+				// The instructor and pseudo-pilots hear everything.
+				mp.messages = append(mp.messages, Message{
+					contents: event.ToController + ": " + event.Callsign + ": " + event.Message,
+					error:    event.RadioTransmissionType == RadioTransmissionUnexpected,
+				})
+			}
+
+		case OfferedHandoffEvent, AcceptedHandoffEvent, CanceledHandoffEvent, RejectedHandoffEvent,
+			PointOutEvent, AcknowledgedPointOutEvent, RejectedPointOutEvent, InitiatedTrackEvent,
+			DroppedTrackEvent:
+			if w.Instructor {
+				msg := event.FromController + ": " + event.Type.String() + " " + event.Callsign
+				if event.ToController != "" {
+					msg += " to " + event.ToController
+				}
+				mp.messages = append(mp.messages, Message{contents: msg, system: true})
+			}
+
+		case PrivateMessageEvent:
+			if event.ToController == w.Callsign || event.FromController == w.Callsign {
+				mp.messages = append(mp.messages, Message{
+					contents: "[" + event.FromController + " to " + event.ToController + "] " + event.Message,
+					system:   true,
+				})
 			}
 
 		case StatusMessageEvent:
@@ -0,0 +1,726 @@
+// phraseology.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ParsePhraseology parses a control instruction given in standard
+// phraseology, such as "american twelve thirty four turn left heading two
+// seven zero, descend and maintain four thousand". It returns the
+// callsign of the aircraft that the instruction is addressed to and the
+// equivalent commands in the syntax that RunAircraftCommands accepts
+// (here, "L270 D40").
+func ParsePhraseology(text string, w *World) (string, string, error) {
+	p := &phraseParser{toks: tokenizePhraseology(text), w: w}
+
+	callsign, n := matchCallsign(p.toks, w.Aircraft)
+	if n == 0 {
+		return "", "", fmt.Errorf("%w: \"%s\"", ErrNoAircraftForCallsign, strings.Join(p.toks[:min(3, len(p.toks))], " "))
+	}
+	p.pos = n
+	p.ac = w.Aircraft[callsign]
+
+	var commands []string
+	for {
+		// Skip the words that join instructions.
+		for p.accept(",") || p.accept("and") || p.accept("then") {
+		}
+		if p.done() {
+			break
+		}
+
+		start := p.pos
+		if cmds, ok := p.command(); ok {
+			commands = append(commands, cmds...)
+		} else {
+			// Report the phrase up to the next comma.
+			end := start
+			for end < len(p.toks) && p.toks[end] != "," {
+				end++
+			}
+			return "", "", fmt.Errorf("%w: \"%s\"", ErrUnknownPhraseology, strings.Join(p.toks[start:end], " "))
+		}
+	}
+
+	if len(commands) == 0 {
+		return "", "", fmt.Errorf("%w: no instructions given", ErrUnknownPhraseology)
+	}
+	return callsign, strings.Join(commands, " "), nil
+}
+
+var (
+	reThousandsSeparator = regexp.MustCompile(`([0-9]),([0-9]{3})`)
+	rePhrasePunctuation  = regexp.MustCompile(`[,;]|\.($|\s)`)
+)
+
+func tokenizePhraseology(text string) []string {
+	text = strings.ToLower(text)
+	text = reThousandsSeparator.ReplaceAllString(text, "$1$2")
+	text = rePhrasePunctuation.ReplaceAllString(text, " , ")
+	text = strings.ReplaceAll(text, "x-ray", "xray")
+	text = strings.ReplaceAll(text, "-", " ")
+	return strings.Fields(text)
+}
+
+var phraseUnits = map[string]string{"zero": "0", "oh": "0", "one": "1", "two": "2", "three": "3", "tree": "3",
+	"four": "4", "five": "5", "fife": "5", "six": "6", "seven": "7", "eight": "8", "nine": "9", "niner": "9"}
+
+var phraseTeens = map[string]string{"ten": "10", "eleven": "11", "twelve": "12", "thirteen": "13",
+	"fourteen": "14", "fifteen": "15", "sixteen": "16", "seventeen": "17", "eighteen": "18", "nineteen": "19"}
+
+var phraseTens = map[string]string{"twenty": "2", "thirty": "3", "forty": "4", "fifty": "5", "sixty": "6",
+	"seventy": "7", "eighty": "8", "ninety": "9"}
+
+var phraseLetters = func() map[string]string {
+	m := make(map[string]string)
+	for i, l := range spokenLetters {
+		m[l] = string(rune('A' + i))
+	}
+	m["xray"] = "X"
+	m["juliett"] = "J"
+	return m
+}()
+
+// parsePhraseDigits parses a sequence of spoken digits and numbers in the
+// forms used for callsigns, headings, and the like: "two seven zero",
+// "twelve thirty four", and "270" are all accepted. It returns the
+// digits and the number of tokens consumed.
+func parsePhraseDigits(toks []string) (string, int) {
+	digits, n := "", 0
+	for n < len(toks) {
+		tok := toks[n]
+		if isAllNumbers(tok) {
+			digits += tok
+		} else if d, ok := phraseUnits[tok]; ok {
+			digits += d
+		} else if d, ok := phraseTeens[tok]; ok {
+			digits += d
+		} else if d, ok := phraseTens[tok]; ok {
+			if n+1 < len(toks) && phraseUnits[toks[n+1]] != "" && toks[n+1] != "zero" && toks[n+1] != "oh" {
+				digits += d + phraseUnits[toks[n+1]]
+				n++
+			} else {
+				digits += d + "0"
+			}
+		} else {
+			break
+		}
+		n++
+	}
+	return digits, n
+}
+
+// matchCallsign finds the aircraft whose callsign is given at the start
+// of toks, returning its callsign and the number of tokens used. The
+// longest match wins; ties go to the alphabetically-first callsign so
+// that the result doesn't depend on map iteration order.
+func matchCallsign(toks []string, aircraft map[string]*Aircraft) (string, int) {
+	best, bestN := "", 0
+	for _, callsign := range SortedMapKeys(aircraft) {
+		if n := matchOneCallsign(toks, callsign); n > bestN {
+			best, bestN = callsign, n
+		}
+	}
+	// Allow the weight class to follow.
+	if bestN > 0 && bestN < len(toks) && (toks[bestN] == "heavy" || toks[bestN] == "super") {
+		bestN++
+	}
+	return best, bestN
+}
+
+func matchOneCallsign(toks []string, callsign string) int {
+	if len(toks) == 0 {
+		return 0
+	}
+	if toks[0] == strings.ToLower(callsign) {
+		return 1
+	}
+
+	// Airline telephony followed by the flight number.
+	if idx := strings.IndexAny(callsign, "0123456789"); idx > 0 {
+		icao, flight := callsign[:idx], callsign[idx:]
+		if telephony, ok := database.Callsigns[icao]; ok {
+			words := strings.Fields(strings.ToLower(telephony))
+			if len(toks) > len(words) && slices.Equal(toks[:len(words)], words) {
+				// Try the flight number both as a whole and as spelled out.
+				if strings.ToLower(flight) == toks[len(words)] {
+					return len(words) + 1
+				}
+				_, n := parsePhraseDigits(toks[len(words):])
+				for ; n > 0; n-- {
+					// The flight number may be followed by other numbers.
+					if digits, _ := parsePhraseDigits(toks[len(words) : len(words)+n]); digits == flight {
+						return len(words) + n
+					}
+				}
+			}
+		}
+	}
+
+	// Otherwise it may be spelled out phonetically, as with general
+	// aviation callsigns ("november one two three alpha bravo").
+	spelled, n := "", 0
+	for n < len(toks) && len(spelled) < len(callsign) {
+		if l, ok := phraseLetters[toks[n]]; ok {
+			spelled += l
+		} else if digits, nd := parsePhraseDigits(toks[n : n+1]); nd == 1 {
+			spelled += digits
+		} else {
+			break
+		}
+		n++
+	}
+	if spelled == callsign {
+		return n
+	}
+	return 0
+}
+
+///////////////////////////////////////////////////////////////////////////
+// phraseParser
+
+type phraseParser struct {
+	toks []string
+	pos  int
+	w    *World
+	ac   *Aircraft
+}
+
+func (p *phraseParser) done() bool {
+	return p.pos >= len(p.toks)
+}
+
+// accept advances past the given sequence of words if they are next.
+func (p *phraseParser) accept(words ...string) bool {
+	if p.pos+len(words) > len(p.toks) || !slices.Equal(p.toks[p.pos:p.pos+len(words)], words) {
+		return false
+	}
+	p.pos += len(words)
+	return true
+}
+
+// skip advances past any of the given filler words.
+func (p *phraseParser) skip(words ...string) {
+	for !p.done() && slices.Contains(words, p.toks[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *phraseParser) digits() (string, bool) {
+	d, n := parsePhraseDigits(p.toks[p.pos:])
+	p.pos += n
+	return d, n > 0
+}
+
+// value parses a number that may be given with "thousand" and "hundred".
+func (p *phraseParser) value() (int, bool) {
+	d, ok := p.digits()
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.Atoi(d)
+	if err != nil {
+		return 0, false
+	}
+
+	if p.accept("thousand") {
+		v *= 1000
+		save := p.pos
+		if h, ok := p.digits(); ok && p.accept("hundred") {
+			hv, _ := strconv.Atoi(h)
+			v += 100 * hv
+		} else {
+			p.pos = save
+		}
+	} else if p.accept("hundred") {
+		v *= 100
+	}
+	return v, true
+}
+
+func (p *phraseParser) altitude() (int, bool) {
+	if p.accept("flight", "level") {
+		if d, ok := p.digits(); ok {
+			fl, err := strconv.Atoi(d)
+			return 100 * fl, err == nil
+		}
+		return 0, false
+	}
+	alt, ok := p.value()
+	if !ok || alt < 100 || p.accept("knots") {
+		return 0, false
+	}
+	p.skip("feet")
+	return alt, true
+}
+
+func (p *phraseParser) heading() (int, bool) {
+	hdg, ok := p.value()
+	return hdg, ok && hdg >= 1 && hdg <= 360
+}
+
+func (p *phraseParser) speed() (int, bool) {
+	spd, ok := p.value()
+	p.skip("knots")
+	return spd, ok
+}
+
+func (p *phraseParser) fix() (string, bool) {
+	if p.done() {
+		return "", false
+	}
+	fix := strings.ToUpper(p.toks[p.pos])
+	if _, ok := p.w.Locate(fix); !ok {
+		return "", false
+	}
+	p.pos++
+	return fix, true
+}
+
+func (p *phraseParser) runway() (string, bool) {
+	p.accept("runway")
+	d, ok := p.digits()
+	if !ok {
+		// e.g., "22l"
+		if p.done() {
+			return "", false
+		}
+		tok := p.toks[p.pos]
+		if i := strings.IndexFunc(tok, func(r rune) bool { return r < '0' || r > '9' }); i > 0 &&
+			strings.Contains("lrc", tok[i:]) && len(tok[i:]) == 1 {
+			p.pos++
+			return strings.ToUpper(tok), true
+		}
+		return "", false
+	}
+	if p.accept("left") {
+		d += "L"
+	} else if p.accept("right") {
+		d += "R"
+	} else if p.accept("center") {
+		d += "C"
+	}
+	return d, true
+}
+
+// approach parses an approach description such as "the ILS runway two
+// two left" and returns the approach's id at the aircraft's arrival
+// airport.
+func (p *phraseParser) approach() (string, bool) {
+	if p.ac.FlightPlan == nil {
+		return "", false
+	}
+	ap := p.w.GetAirport(p.ac.FlightPlan.ArrivalAirport)
+	if ap == nil {
+		return "", false
+	}
+
+	p.skip("the", "for")
+	var at ApproachType
+	switch {
+	case p.accept("ils") || p.accept("i", "l", "s") || p.accept("localizer"):
+		at = ILSApproach
+	case p.accept("rnav") || p.accept("r", "nav") || p.accept("gps"):
+		at = RNAVApproach
+		p.skip("gps")
+	case p.accept("visual"):
+		at = ChartedVisualApproach
+	default:
+		return "", false
+	}
+
+	// RNAV approaches may have a variant letter: "rnav zulu runway...".
+	variant := ""
+	if !p.done() {
+		if l, ok := phraseLetters[p.toks[p.pos]]; ok {
+			variant = l
+			p.pos++
+		} else if len(p.toks[p.pos]) == 1 && p.toks[p.pos][0] >= 'w' && p.toks[p.pos][0] <= 'z' {
+			variant = strings.ToUpper(p.toks[p.pos])
+			p.pos++
+		}
+	}
+
+	rwy, ok := p.runway()
+	if !ok {
+		return "", false
+	}
+	p.skip("approach")
+
+	for _, id := range SortedMapKeys(ap.Approaches) {
+		appr := ap.Approaches[id]
+		if appr.Type == at && appr.Runway == rwy &&
+			(variant == "" || strings.Contains(appr.FullName, " "+variant+" ")) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// command parses a single instruction, returning the equivalent command
+// or commands.
+func (p *phraseParser) command() ([]string, bool) {
+	for _, parse := range []func() ([]string, bool){
+		p.triggerCommand, p.headingCommand, p.altitudeCommand, p.speedCommand, p.routeCommand,
+		p.approachCommand, p.reportCommand, p.trafficCommand, p.miscCommand,
+	} {
+		start := p.pos
+		if cmds, ok := parse(); ok {
+			return cmds, true
+		}
+		p.pos = start
+	}
+	return nil, false
+}
+
+// triggerCommand parses a condition for the instructions that follow,
+// as in "after MERIT, turn left heading two five zero".
+func (p *phraseParser) triggerCommand() ([]string, bool) {
+	if p.accept("after", "passing") || p.accept("after") {
+		if fix, ok := p.fix(); ok {
+			return []string{"@" + fix}, true
+		}
+		return nil, false
+	}
+
+	if p.accept("upon", "reaching") || p.accept("reaching") {
+		if alt, ok := p.altitude(); ok {
+			return []string{fmt.Sprintf("@A%d", alt/100)}, true
+		}
+		return nil, false
+	}
+
+	if p.accept("in") {
+		if m, ok := p.value(); ok && (p.accept("minutes") || p.accept("minute")) {
+			return []string{fmt.Sprintf("@T%d", m)}, true
+		}
+		return nil, false
+	}
+
+	if p.accept("at") {
+		if dme, ok := p.value(); ok && p.accept("dme") {
+			// The distance is from the arrival airport unless a fix is
+			// given.
+			if p.accept("from") {
+				if fix, ok := p.fix(); ok {
+					return []string{fmt.Sprintf("@%s/%d", fix, dme)}, true
+				}
+				return nil, false
+			}
+			if p.ac != nil && p.ac.FlightPlan != nil {
+				return []string{fmt.Sprintf("@%s/%d", p.ac.FlightPlan.ArrivalAirport, dme)}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (p *phraseParser) headingCommand() ([]string, bool) {
+	if p.accept("fly", "present", "heading") || p.accept("maintain", "present", "heading") {
+		return []string{"H"}, true
+	}
+
+	prefix := "H"
+	if p.accept("turn", "left") {
+		prefix = "L"
+	} else if p.accept("turn", "right") {
+		prefix = "R"
+	} else {
+		// "fly heading two seven zero" or just "heading two seven zero"
+		p.accept("fly")
+	}
+
+	if prefix != "H" {
+		// "turn left two zero degrees"
+		save := p.pos
+		if deg, ok := p.value(); ok && p.accept("degrees") {
+			return []string{fmt.Sprintf("%s%dD", prefix, deg)}, true
+		}
+		p.pos = save
+	}
+
+	if !p.accept("heading") {
+		return nil, false
+	}
+	if hdg, ok := p.heading(); ok {
+		return []string{fmt.Sprintf("%s%03d", prefix, hdg)}, true
+	}
+	return nil, false
+}
+
+func (p *phraseParser) altitudeCommand() ([]string, bool) {
+	switch {
+	case p.accept("expedite", "climb"):
+		return []string{"EC"}, true
+	case p.accept("expedite", "descent"):
+		return []string{"ED"}, true
+	case p.accept("climb", "via", "the", "sid") || p.accept("climb", "via", "sid"):
+		return []string{"CVS"}, true
+	case p.accept("descend", "via", "the", "star") || p.accept("descend", "via", "star") ||
+		p.accept("descend", "via", "the", "arrival"):
+		return []string{"DVS"}, true
+	}
+
+	// "climb and maintain", "descend to", or just "maintain".
+	verb := ""
+	if p.accept("climb") {
+		verb = "C"
+	} else if p.accept("descend") {
+		verb = "D"
+	}
+	p.skip("and", "to")
+	if !p.accept("maintain") && verb == "" {
+		return nil, false
+	}
+
+	alt, ok := p.altitude()
+	if !ok {
+		return nil, false
+	}
+	if verb == "" {
+		verb = "C"
+	}
+	cmds := []string{fmt.Sprintf("%s%d", verb, alt/100)}
+	if p.accept("expedite") || p.accept(",", "expedite") {
+		cmds = append(cmds, Select(verb == "C", "EC", "ED"))
+	}
+	return cmds, true
+}
+
+func (p *phraseParser) speedCommand() ([]string, bool) {
+	switch {
+	case p.accept("resume", "normal", "speed") || p.accept("cancel", "speed", "restrictions") ||
+		p.accept("cancel", "speed", "restriction"):
+		return []string{"S"}, true
+	case p.accept("maintain", "slowest", "practical", "speed"):
+		return []string{"SMIN"}, true
+	case p.accept("maintain", "maximum", "forward", "speed"):
+		return []string{"SMAX"}, true
+	}
+
+	if p.accept("reduce", "speed") || p.accept("increase", "speed") || p.accept("slow") ||
+		p.accept("reduce") || p.accept("increase") || p.accept("speed") {
+		p.skip("to")
+		if spd, ok := p.speed(); ok {
+			return []string{fmt.Sprintf("S%d", spd)}, true
+		}
+		return nil, false
+	}
+
+	// "maintain two one zero knots"
+	if p.accept("maintain") {
+		if spd, ok := p.value(); ok && p.accept("knots") {
+			return []string{fmt.Sprintf("S%d", spd)}, true
+		}
+	}
+	return nil, false
+}
+
+func (p *phraseParser) routeCommand() ([]string, bool) {
+	if p.accept("cleared", "as", "filed") {
+		return []string{"CAF"}, true
+	}
+
+	if p.accept("proceed", "direct") || p.accept("cleared", "direct") || p.accept("direct") {
+		if fix, ok := p.fix(); ok {
+			return []string{"D" + fix}, true
+		}
+		return nil, false
+	}
+
+	if p.accept("depart") {
+		fix, ok := p.fix()
+		if !ok {
+			return nil, false
+		}
+		if p.accept("direct") {
+			if fix2, ok := p.fix(); ok {
+				return []string{"D" + fix + "/D" + fix2}, true
+			}
+		} else if p.accept("heading") || p.accept("fly", "heading") {
+			if hdg, ok := p.heading(); ok {
+				return []string{fmt.Sprintf("D%s/H%03d", fix, hdg)}, true
+			}
+		}
+		return nil, false
+	}
+
+	if p.accept("cross") {
+		fix, ok := p.fix()
+		if !ok {
+			return nil, false
+		}
+		cmd := "C" + fix
+		for {
+			save := p.pos
+			p.skip(",", "and")
+			if p.accept("at", "or", "above") || p.accept("at", "or", "below") || p.accept("at", "and", "maintain") ||
+				p.accept("at") {
+				suffix := ""
+				if p.toks[p.pos-1] == "above" {
+					suffix = "+"
+				} else if p.toks[p.pos-1] == "below" {
+					suffix = "-"
+				}
+				save2 := p.pos
+				if alt, ok := p.altitude(); ok && !p.accept("knots") {
+					cmd += fmt.Sprintf("/A%d%s", alt/100, suffix)
+					continue
+				}
+				p.pos = save2
+				if spd, ok := p.speed(); ok {
+					cmd += fmt.Sprintf("/S%d", spd)
+					continue
+				}
+			}
+			p.pos = save
+			break
+		}
+		return []string{cmd}, cmd != "C"+fix
+	}
+
+	return nil, false
+}
+
+func (p *phraseParser) approachCommand() ([]string, bool) {
+	if p.accept("expect") {
+		if id, ok := p.approach(); ok {
+			return []string{"E" + id}, true
+		}
+		return nil, false
+	}
+
+	if p.accept("at") {
+		fix, ok := p.fix()
+		if !ok {
+			return nil, false
+		}
+		p.skip(",")
+		if p.accept("cleared") {
+			if id, ok := p.approach(); ok {
+				return []string{"A" + fix + "/C" + id}, true
+			}
+		}
+		return nil, false
+	}
+
+	if p.accept("cleared", "straight", "in") {
+		if id, ok := p.approach(); ok {
+			return []string{"CSI" + id}, true
+		}
+		return nil, false
+	}
+	if p.accept("cleared") {
+		if id, ok := p.approach(); ok {
+			return []string{"C" + id}, true
+		}
+	}
+	return nil, false
+}
+
+func (p *phraseParser) reportCommand() ([]string, bool) {
+	switch {
+	case p.accept("say", "altitude") || p.accept("verify", "altitude"):
+		return []string{"SA"}, true
+	case p.accept("say", "heading"):
+		return []string{"SH"}, true
+	case p.accept("say", "speed") || p.accept("say", "airspeed"):
+		return []string{"SS"}, true
+	case !p.accept("report"):
+		return nil, false
+	case p.accept("established"):
+		p.skip("on", "the", "localizer", "final", "approach", "course")
+		return []string{"RE"}, true
+	case p.accept("passing"):
+		if fix, ok := p.fix(); ok {
+			return []string{"RP" + fix}, true
+		}
+	case p.accept("reaching") || p.accept("leaving"):
+		verb := Select(p.toks[p.pos-1] == "reaching", "RR", "RL")
+		if alt, ok := p.altitude(); ok {
+			return []string{fmt.Sprintf("%s%d", verb, alt/100)}, true
+		}
+	}
+	return nil, false
+}
+
+// trafficCommand parses traffic advisories like "traffic two o'clock, five
+// miles, opposite direction, three thousand".
+func (p *phraseParser) trafficCommand() ([]string, bool) {
+	if p.accept("maintain", "visual", "separation") {
+		return []string{"VS"}, true
+	}
+
+	if !p.accept("traffic") {
+		return nil, false
+	}
+	clock, ok := p.value()
+	if !ok || clock < 1 || clock > 12 || !(p.accept("o'clock") || p.accept("oclock")) {
+		return nil, false
+	}
+	p.skip(",")
+	dist, ok := p.value()
+	if !ok || !(p.accept("miles") || p.accept("mile")) {
+		return nil, false
+	}
+
+	cmd := fmt.Sprintf("TR%d/%d", clock, dist)
+	save := p.pos
+	p.skip(",")
+	switch {
+	case p.accept("opposite", "direction"):
+		cmd += "/OPP"
+	case p.accept("same", "direction"):
+		cmd += "/SAME"
+	case p.accept("northbound"):
+		cmd += "/N"
+	case p.accept("southbound"):
+		cmd += "/S"
+	case p.accept("eastbound"):
+		cmd += "/E"
+	case p.accept("westbound"):
+		cmd += "/W"
+	default:
+		p.pos = save
+	}
+
+	save = p.pos
+	p.skip(",")
+	p.accept("altitude", "indicates")
+	if alt, ok := p.altitude(); ok {
+		cmd += fmt.Sprintf("/%d", alt/100)
+	} else {
+		p.pos = save
+	}
+	return []string{cmd}, true
+}
+
+func (p *phraseParser) miscCommand() ([]string, bool) {
+	switch {
+	case p.accept("contact", "tower") || p.accept("contact", "the", "tower"):
+		return []string{"TO"}, true
+	case p.accept("cancel", "approach", "clearance"):
+		return []string{"CAC"}, true
+	case p.accept("intercept", "the", "localizer") || p.accept("intercept", "localizer"):
+		return []string{"I"}, true
+	case p.accept("squawk", "ident") || p.accept("ident"):
+		return []string{"ID"}, true
+	case p.accept("squawk"):
+		if d, ok := p.digits(); ok && len(d) == 4 {
+			return []string{"SQ" + d}, true
+		}
+	case p.accept("altimeter"):
+		if d, ok := p.digits(); ok && len(d) == 4 {
+			return []string{"ALT" + d}, true
+		}
+	}
+	return nil, false
+}
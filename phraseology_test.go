@@ -0,0 +1,98 @@
+// phraseology_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParsePhraseDigits(t *testing.T) {
+	for _, test := range []struct {
+		s      []string
+		digits string
+		n      int
+	}{
+		{[]string{"two", "seven", "zero"}, "270", 3},
+		{[]string{"twelve", "thirty", "four", "turn"}, "1234", 3},
+		{[]string{"niner", "twenty"}, "920", 2},
+		{[]string{"270"}, "270", 1},
+		{[]string{"heading"}, "", 0},
+	} {
+		if d, n := parsePhraseDigits(test.s); d != test.digits || n != test.n {
+			t.Errorf("parsePhraseDigits(%v) = %q, %d; expected %q, %d", test.s, d, n, test.digits, test.n)
+		}
+	}
+}
+
+func TestParsePhraseology(t *testing.T) {
+	saved := database
+	defer func() { database = saved }()
+	database = &StaticDatabase{Callsigns: map[string]string{"AAL": "AMERICAN"}}
+
+	w := &World{
+		Aircraft: map[string]*Aircraft{
+			"AAL1234": &Aircraft{Callsign: "AAL1234", FlightPlan: &FlightPlan{ArrivalAirport: "KJFK"}},
+			"N123AB":  &Aircraft{Callsign: "N123AB"},
+		},
+		Fixes: map[string]Point2LL{"MERIT": Point2LL{}, "CAMRN": Point2LL{}},
+	}
+
+	for _, test := range [][3]string{
+		[3]string{"american twelve thirty four turn left heading two seven zero, descend and maintain four thousand, reduce speed to one eight zero",
+			"AAL1234", "L270 D40 S180"},
+		[3]string{"American 1234 heavy climb and maintain flight level two three zero", "AAL1234", "C230"},
+		[3]string{"november one two three alpha bravo fly heading 090 maintain 3,500", "N123AB", "H090 C35"},
+		[3]string{"n123ab turn right two zero degrees then resume normal speed", "N123AB", "R20D S"},
+		[3]string{"AAL1234 maintain two one zero knots, expedite descent, squawk ident", "AAL1234", "S210 ED ID"},
+		[3]string{"AAL1234 fly present heading, maintain slowest practical speed, contact tower", "AAL1234", "H SMIN TO"},
+		[3]string{"american twelve thirty four after merit turn left heading two five zero", "AAL1234", "@MERIT L250"},
+		[3]string{"AAL1234 at four dme reduce speed to one seven zero", "AAL1234", "@KJFK/4 S170"},
+		[3]string{"AAL1234 at six dme from camrn, descend and maintain two thousand", "AAL1234", "@CAMRN/6 D20"},
+		[3]string{"n123ab upon reaching five thousand, maintain two one zero knots", "N123AB", "@A50 S210"},
+		[3]string{"n123ab in two minutes turn right heading three six zero", "N123AB", "@T2 R360"},
+		[3]string{"AAL1234 descend and maintain three thousand, report leaving four thousand", "AAL1234", "D30 RL40"},
+		[3]string{"AAL1234 report established on the localizer, say speed", "AAL1234", "RE SS"},
+		[3]string{"n123ab report passing camrn, verify altitude", "N123AB", "RPCAMRN SA"},
+		[3]string{"AAL1234 traffic two o'clock, five miles, opposite direction, three thousand", "AAL1234", "TR2/5/OPP/30"},
+		[3]string{"n123ab squawk four five two one", "N123AB", "SQ4521"},
+		[3]string{"AAL1234 traffic eleven o'clock 3 miles northbound, maintain visual separation", "AAL1234", "TR11/3/N VS"},
+		[3]string{"n123ab cleared as filed, climb and maintain five thousand", "N123AB", "CAF C50"},
+	} {
+		callsign, cmds, err := ParsePhraseology(test[0], w)
+		if err != nil {
+			t.Errorf("ParsePhraseology(%q): unexpected error %v", test[0], err)
+		} else if callsign != test[1] || cmds != test[2] {
+			t.Errorf("ParsePhraseology(%q) = %q, %q; expected %q, %q", test[0], callsign, cmds, test[1], test[2])
+		}
+	}
+
+	if _, _, err := ParsePhraseology("united one turn left heading two seven zero", w); !errors.Is(err, ErrNoAircraftForCallsign) {
+		t.Errorf("expected ErrNoAircraftForCallsign for unknown aircraft, got %v", err)
+	}
+	if _, _, err := ParsePhraseology("american twelve thirty four say intentions", w); !errors.Is(err, ErrUnknownPhraseology) {
+		t.Errorf("expected ErrUnknownPhraseology for unknown phrase, got %v", err)
+	}
+}
+
+func TestMatchCallsign(t *testing.T) {
+	saved := database
+	defer func() { database = saved }()
+	database = &StaticDatabase{Callsigns: map[string]string{"AAL": "AMERICAN", "AAY": "ALLEGIANT", "AAX": "AMERICAN"}}
+
+	aircraft := map[string]*Aircraft{
+		"AAL12": &Aircraft{Callsign: "AAL12"},
+		"AAX12": &Aircraft{Callsign: "AAX12"},
+		"AAY12": &Aircraft{Callsign: "AAY12"},
+	}
+	toks := strings.Fields("american one two descend and maintain four thousand")
+	// Run it a few times since map iteration order varies.
+	for i := 0; i < 10; i++ {
+		if callsign, n := matchCallsign(toks, aircraft); callsign != "AAL12" || n != 3 {
+			t.Fatalf("matchCallsign(%q) = %q, %d; expected \"AAL12\", 3", toks, callsign, n)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+// pseudopilot.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/mmp/imgui-go/v4"
+)
+
+// Pseudo-pilots sign on to a multi-controller sim to fly aircraft the
+// way they are flown in training labs. Control instructions to aircraft
+// that a pseudo-pilot has taken aren't executed immediately; rather,
+// they're queued until a pseudo-pilot reads them back and executes them
+// (possibly after changing them, in order to introduce the sort of errors
+// that real pilots make).
+
+const PseudoPilotCallsign = "Pilot"
+
+// PilotInstruction is a controller's instruction to an aircraft flown by
+// a pseudo-pilot that is waiting for a response.
+type PilotInstruction struct {
+	Id         int
+	Callsign   string
+	Controller string
+	Commands   string
+	IssueTime  time.Time // sim time
+
+	// The issuing controller's token, used to execute the commands once
+	// the pseudo-pilot responds.
+	controllerToken string
+}
+
+func (s *Sim) pseudoPilotIsSignedIn() bool {
+	for _, ctrl := range s.controllers {
+		if ctrl.PseudoPilot {
+			return true
+		}
+	}
+	return false
+}
+
+// flownByPseudoPilot returns true if the given aircraft should wait for a
+// pseudo-pilot to respond to instructions.
+func (s *Sim) flownByPseudoPilot(ac *Aircraft) bool {
+	return ac.PseudoPilot && s.pseudoPilotIsSignedIn()
+}
+
+func (s *Sim) SignOnPseudoPilot() (*World, string, error) {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	s.eventStream.Post(Event{
+		Type:    StatusMessageEvent,
+		Message: PseudoPilotCallsign + " has signed on.",
+	})
+	s.lg.Info("pseudo-pilot signed on")
+
+	return s.addServerController(ServerController{
+		Callsign:    PseudoPilotCallsign,
+		PseudoPilot: true,
+	})
+}
+
+func (s *Sim) checkPseudoPilot(token string) error {
+	if sc, ok := s.controllers[token]; !ok {
+		return ErrInvalidControllerToken
+	} else if !sc.PseudoPilot {
+		return ErrNotPseudoPilot
+	}
+	return nil
+}
+
+// releasePseudoPilotAircraft is called when the last pseudo-pilot signs
+// off; the aircraft they were flying go back to responding automatically
+// and any instructions that were still waiting are dropped.
+func (s *Sim) releasePseudoPilotAircraft() {
+	for _, ac := range s.World.Aircraft {
+		ac.PseudoPilot = false
+	}
+
+	for _, pi := range s.PilotInstructions {
+		s.eventStream.Post(Event{
+			Type:    StatusMessageEvent,
+			Message: fmt.Sprintf("%s: %s: instruction \"%s\" was not executed", pi.Controller, pi.Callsign, pi.Commands),
+		})
+	}
+	s.PilotInstructions = nil
+}
+
+// prunePilotInstructions removes instructions to aircraft that are no
+// longer in the sim.
+func (s *Sim) prunePilotInstructions() {
+	s.PilotInstructions = FilterSlice(s.PilotInstructions, func(pi PilotInstruction) bool {
+		_, ok := s.World.Aircraft[pi.Callsign]
+		return ok
+	})
+}
+
+func (s *Sim) TogglePseudoPilot(token, callsign string) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	if err := s.checkPseudoPilot(token); err != nil {
+		return err
+	}
+	ac, ok := s.World.Aircraft[callsign]
+	if !ok {
+		return ErrNoAircraftForCallsign
+	}
+
+	ac.PseudoPilot = !ac.PseudoPilot
+	s.lg.Info("toggled pseudo-pilot", slog.String("callsign", callsign), slog.Bool("pseudo_pilot", ac.PseudoPilot))
+	return nil
+}
+
+// HoldForPseudoPilot is called before control commands are issued to an
+// aircraft. If the aircraft is flown by a pseudo-pilot, the commands are
+// queued for the pseudo-pilot and true is returned.
+func (s *Sim) HoldForPseudoPilot(token, callsign, commands string) (bool, error) {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	sc, ok := s.controllers[token]
+	if !ok {
+		return false, ErrInvalidControllerToken
+	}
+	ac, ok := s.World.Aircraft[callsign]
+	if !ok {
+		return false, ErrNoAircraftForCallsign
+	}
+	if !s.flownByPseudoPilot(ac) {
+		return false, nil
+	}
+	if ac.ControllingController != sc.Callsign {
+		return false, ErrOtherControllerHasTrack
+	}
+
+	s.NextPilotInstructionId++
+	s.PilotInstructions = append(s.PilotInstructions, PilotInstruction{
+		Id:              s.NextPilotInstructionId,
+		Callsign:        callsign,
+		Controller:      sc.Callsign,
+		Commands:        commands,
+		IssueTime:       s.SimTime,
+		controllerToken: token,
+	})
+	s.lg.Info("holding instruction for pseudo-pilot", slog.String("callsign", callsign),
+		slog.String("commands", commands))
+
+	return true, nil
+}
+
+// TakePilotInstruction removes the instruction with the given id from the
+// queue and returns it so that it can be executed.
+func (s *Sim) TakePilotInstruction(token string, id int) (PilotInstruction, error) {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	if err := s.checkPseudoPilot(token); err != nil {
+		return PilotInstruction{}, err
+	}
+
+	idx := slices.IndexFunc(s.PilotInstructions, func(pi PilotInstruction) bool { return pi.Id == id })
+	if idx == -1 {
+		return PilotInstruction{}, ErrNoPilotInstruction
+	}
+	pi := s.PilotInstructions[idx]
+	s.PilotInstructions = slices.Delete(s.PilotInstructions, idx, idx+1)
+
+	return pi, nil
+}
+
+// beginPilotResponse and endPilotResponse bracket the execution of a
+// pseudo-pilot's response to an instruction; readbacks from the aircraft
+// in between are discarded since the pseudo-pilot gave their own.
+func (s *Sim) beginPilotResponse(callsign string) {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	if s.pseudoPilotResponses == nil {
+		s.pseudoPilotResponses = make(map[string]interface{})
+	}
+	s.pseudoPilotResponses[callsign] = nil
+}
+
+func (s *Sim) endPilotResponse(callsign string) {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	delete(s.pseudoPilotResponses, callsign)
+}
+
+func (s *Sim) PostPilotReadback(pi PilotInstruction, readback string) {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	s.PostEvent(Event{
+		Type:                  RadioTransmissionEvent,
+		Callsign:              pi.Callsign,
+		ToController:          pi.Controller,
+		Message:               readback,
+		RadioTransmissionType: RadioTransmissionReadback,
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////
+// PseudoPilotWindow
+
+type PseudoPilotWindow struct {
+	callsign string
+
+	// Edited commands and readbacks, indexed by PilotInstruction Id.
+	commands  map[int]*string
+	readbacks map[int]*string
+
+	err error
+}
+
+func MakePseudoPilotWindow() *PseudoPilotWindow {
+	return &PseudoPilotWindow{
+		commands:  make(map[int]*string),
+		readbacks: make(map[int]*string),
+	}
+}
+
+func (pw *PseudoPilotWindow) onErr(err error) {
+	pw.err = err
+}
+
+func (pw *PseudoPilotWindow) Draw(w *World) {
+	imgui.SetNextWindowSizeConstraints(imgui.Vec2{400, 100}, imgui.Vec2{-1, float32(platform.WindowSize()[1]) * 19 / 20})
+	imgui.BeginV("Pseudo-pilot", nil, imgui.WindowFlagsAlwaysAutoResize)
+
+	if imgui.BeginComboV("Aircraft", pw.callsign, imgui.ComboFlagsHeightLarge) {
+		for _, callsign := range SortedMapKeys(w.Aircraft) {
+			label := callsign
+			if w.Aircraft[callsign].PseudoPilot {
+				label += " (flown)"
+			}
+			if imgui.SelectableV(label, callsign == pw.callsign, 0, imgui.Vec2{}) {
+				pw.callsign = callsign
+			}
+		}
+		imgui.EndCombo()
+	}
+	ac, ok := w.Aircraft[pw.callsign]
+	uiStartDisable(!ok)
+	imgui.SameLine()
+	if imgui.Button(Select(ok && ac.PseudoPilot, "Release", "Fly")) {
+		pw.err = nil
+		w.TogglePseudoPilot(pw.callsign, pw.onErr)
+	}
+	uiEndDisable(!ok)
+
+	if imgui.Button("Fly all") {
+		pw.err = nil
+		for _, callsign := range SortedMapKeys(w.Aircraft) {
+			if !w.Aircraft[callsign].PseudoPilot {
+				w.TogglePseudoPilot(callsign, pw.onErr)
+			}
+		}
+	}
+
+	imgui.Separator()
+
+	if len(w.PilotInstructions) == 0 {
+		imgui.Text("No pending instructions")
+	} else {
+		pw.drawInstructions(w)
+	}
+
+	if pw.err != nil {
+		imgui.PushStyleColor(imgui.StyleColorText, imgui.Vec4{1, .5, .5, 1})
+		imgui.Text(pw.err.Error())
+		imgui.PopStyleColor()
+	}
+
+	imgui.End()
+}
+
+func (pw *PseudoPilotWindow) drawInstructions(w *World) {
+	flags := imgui.TableFlagsBordersV | imgui.TableFlagsBordersOuterH | imgui.TableFlagsRowBg | imgui.TableFlagsSizingStretchProp
+	if !imgui.BeginTableV("instructions", 6, flags, imgui.Vec2{}, 0) {
+		return
+	}
+	imgui.TableSetupColumn("Time")
+	imgui.TableSetupColumn("Aircraft")
+	imgui.TableSetupColumn("Controller")
+	imgui.TableSetupColumn("Commands")
+	imgui.TableSetupColumn("Readback")
+	imgui.TableSetupColumn("")
+	imgui.TableHeadersRow()
+
+	live := make(map[int]interface{})
+	for _, pi := range w.PilotInstructions {
+		live[pi.Id] = nil
+		if _, ok := pw.commands[pi.Id]; !ok {
+			cmds, rb := pi.Commands, ""
+			pw.commands[pi.Id], pw.readbacks[pi.Id] = &cmds, &rb
+		}
+
+		imgui.PushID(fmt.Sprintf("%d", pi.Id))
+		imgui.TableNextRow()
+		imgui.TableNextColumn()
+		imgui.Text(fmt.Sprintf("%ds", int(w.SimTime.Sub(pi.IssueTime).Seconds())))
+		imgui.TableNextColumn()
+		imgui.Text(pi.Callsign)
+		imgui.TableNextColumn()
+		imgui.Text(pi.Controller)
+		imgui.TableNextColumn()
+		imgui.InputTextV("##commands", pw.commands[pi.Id], imgui.InputTextFlagsCharsUppercase, nil)
+		imgui.TableNextColumn()
+		imgui.InputTextV("##readback", pw.readbacks[pi.Id], 0, nil)
+		imgui.TableNextColumn()
+		if imgui.Button("Execute") {
+			pw.err = nil
+			w.RespondToPilotInstruction(pi.Id, *pw.commands[pi.Id], *pw.readbacks[pi.Id], pw.onErr)
+		}
+		imgui.SameLine()
+		if imgui.Button("Unable") {
+			pw.err = nil
+			rb := *pw.readbacks[pi.Id]
+			if rb == "" {
+				rb = "unable"
+			}
+			w.RespondToPilotInstruction(pi.Id, "", rb, pw.onErr)
+		}
+		imgui.PopID()
+	}
+	imgui.EndTable()
+
+	// Forget about instructions that have been taken care of.
+	for id := range pw.commands {
+		if _, ok := live[id]; !ok {
+			delete(pw.commands, id)
+			delete(pw.readbacks, id)
+		}
+	}
+}
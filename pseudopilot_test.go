@@ -0,0 +1,45 @@
+// pseudopilot_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+)
+
+func TestPseudoPilotReadbacks(t *testing.T) {
+	s := &Sim{
+		World: &World{Aircraft: map[string]*Aircraft{
+			"AAL1": &Aircraft{Callsign: "AAL1", PseudoPilot: true},
+		}},
+		controllers: map[string]*ServerController{"token": &ServerController{Callsign: "Pilot", PseudoPilot: true}},
+		eventStream: NewEventStream(),
+	}
+	sub := s.eventStream.Subscribe()
+	readback := func(msg string) {
+		s.PostEvent(Event{
+			Type:                  RadioTransmissionEvent,
+			Callsign:              "AAL1",
+			Message:               msg,
+			RadioTransmissionType: RadioTransmissionReadback,
+		})
+	}
+
+	// Readbacks that aren't for instructions the pseudo-pilot responded
+	// to, e.g. when switching it to the next controller, are still sent.
+	readback("contact departure, good day")
+
+	s.beginPilotResponse("AAL1")
+	readback("climb and maintain 5000")
+	s.endPilotResponse("AAL1")
+	s.PostPilotReadback(PilotInstruction{Callsign: "AAL1"}, "up to 5000")
+
+	var msgs []string
+	for _, e := range sub.Get() {
+		msgs = append(msgs, e.Message)
+	}
+	if len(msgs) != 2 || msgs[0] != "contact departure, good day" || msgs[1] != "up to 5000" {
+		t.Errorf("got readbacks %q, expected the handoff and the pseudo-pilot's", msgs)
+	}
+}
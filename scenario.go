@@ -62,12 +62,15 @@ type STARSFacilityAdaptation struct {
 	ScratchpadRules   [2]bool               `json:"allow_long_scratchpad"` // [0] is for the primary. [1] is for the secondary
 	Maps              []STARSMap            `json:"stars_maps"`
 	InhibitCAVolumes  []AirspaceVolume      `json:"inhibit_ca_volumes"`
+	MCIVolumes        []AirspaceVolume      `json:"mci_volumes"` // e.g., Class B and C airspace
+	InhibitMCIVolumes []AirspaceVolume      `json:"inhibit_mci_volumes"`
 	RadarSites        map[string]*RadarSite `json:"radar_sites"`
 	Center            Point2LL              `json:"-"`
 	CenterString      string                `json:"center"`
 	Range             float32               `json:"range"`
 	Scratchpads       map[string]string     `json:"scratchpads"`
 	VideoMapFile      string                `json:"video_map_file"`
+	BeaconCodes       BeaconCodeBanks       `json:"beacon_codes"`
 }
 
 type Airspace struct {
@@ -725,6 +728,9 @@ func (sg *ScenarioGroup) PostDeserialize(e *ErrorLogger, simConfigurations map[s
 		if ctrl.FullName == "" {
 			e.ErrorString("no \"full_name\" specified")
 		}
+		if ctrl.Behavior != nil {
+			ctrl.Behavior.PostDeserialize(sg, e)
+		}
 		e.Pop()
 	}
 
@@ -796,6 +802,8 @@ func (s *STARSFacilityAdaptation) PostDeserialize(e *ErrorLogger, sg *ScenarioGr
 		e.Pop()
 	}
 
+	s.BeaconCodes.PostDeserialize(e)
+
 	e.Pop() // stars_config
 }
 
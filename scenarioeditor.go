@@ -0,0 +1,1128 @@
+// scenarioeditor.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/iancoleman/orderedmap"
+	"github.com/mmp/imgui-go/v4"
+)
+
+// The scenario editor edits a scenario group's JSON file on a map. The
+// file is kept as a generic JSON tree so that the parts of it that the
+// editor doesn't know about are written back as they were. After each
+// change, the JSON is loaded into a ScenarioGroup and checked with the
+// same PostDeserialize validation that is done when vice starts.
+
+///////////////////////////////////////////////////////////////////////////
+// JSON trees
+
+// Objects in the JSON tree are orderedmap.OrderedMaps so that their
+// members stay in the same order as in the file. Paths to values in the
+// tree are given by member names for objects and indices for arrays.
+
+func jsonObject(v any) orderedmap.OrderedMap {
+	switch o := v.(type) {
+	case orderedmap.OrderedMap:
+		return o
+	case *orderedmap.OrderedMap:
+		return *o
+	default:
+		return *orderedmap.New()
+	}
+}
+
+// jsonGet returns the value at the given path in the tree.
+func jsonGet(v any, path ...any) (any, bool) {
+	for _, p := range path {
+		switch p := p.(type) {
+		case string:
+			o, ok := v.(orderedmap.OrderedMap)
+			if !ok {
+				return nil, false
+			}
+			if v, ok = o.Get(p); !ok {
+				return nil, false
+			}
+		case int:
+			a, ok := v.([]any)
+			if !ok || p < 0 || p >= len(a) {
+				return nil, false
+			}
+			v = a[p]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// jsonKeys returns the member names of the object at the given path.
+func jsonKeys(v any, path ...any) []string {
+	v, _ = jsonGet(v, path...)
+	o, ok := v.(orderedmap.OrderedMap)
+	if !ok {
+		return nil
+	}
+	return o.Keys()
+}
+
+// jsonUpdate returns the tree with the value at the given path replaced
+// by the result of calling f with the current value, which is nil if it
+// isn't present. Objects along the path are created if necessary and
+// an index equal to an array's length appends to it. If f returns
+// jsonDeleted, the value is removed from its parent.
+func jsonUpdate(v any, path []any, f func(any) any) any {
+	if len(path) == 0 {
+		return f(v)
+	}
+
+	switch p := path[0].(type) {
+	case string:
+		o := jsonObject(v)
+		child, _ := o.Get(p)
+		if n := jsonUpdate(child, path[1:], f); n == jsonDeleted {
+			o.Delete(p)
+		} else {
+			o.Set(p, n)
+		}
+		return o
+
+	case int:
+		a, _ := v.([]any)
+		if p == len(a) {
+			a = append(a, nil)
+		} else if p < 0 || p > len(a) {
+			return a
+		}
+		if n := jsonUpdate(a[p], path[1:], f); n == jsonDeleted {
+			a = slices.Delete(a, p, p+1)
+		} else {
+			a[p] = n
+		}
+		return a
+
+	default:
+		return v
+	}
+}
+
+var jsonDeleted any = &struct{ deleted bool }{true}
+
+// jsonRename returns the tree with a member of the object at the given
+// path renamed; it keeps its position in the object.
+func jsonRename(v any, from, to string, path ...any) any {
+	return jsonUpdate(v, path, func(old any) any {
+		o := jsonObject(old)
+		n := orderedmap.New()
+		for _, k := range o.Keys() {
+			val, _ := o.Get(k)
+			n.Set(Select(k == from, to, k), val)
+		}
+		return *n
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////
+// scenarioDocument
+
+// scenarioDocument is a scenario group's JSON file being edited.
+type scenarioDocument struct {
+	filename string
+	root     any
+
+	// sg is the result of loading and validating the JSON; it is nil if
+	// the JSON couldn't be loaded.
+	sg     *ScenarioGroup
+	errors []string
+
+	dirty bool // there are unsaved changes
+	stale bool // sg and errors need to be updated
+}
+
+func loadScenarioDocument(filename string) (*scenarioDocument, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	root := orderedmap.New()
+	if err := UnmarshalJSON(b, root); err != nil {
+		return nil, err
+	}
+
+	d := &scenarioDocument{filename: filename, root: *root, stale: true}
+	d.validate()
+	return d, nil
+}
+
+func (d *scenarioDocument) get(path ...any) (any, bool) {
+	return jsonGet(d.root, path...)
+}
+
+func (d *scenarioDocument) getString(path ...any) string {
+	v, _ := d.get(path...)
+	s, _ := v.(string)
+	return s
+}
+
+// set updates the value at the given path. The document isn't validated
+// until validate is called so that edits that are made over a number of
+// frames (e.g., dragging a point) don't cause repeated validation.
+func (d *scenarioDocument) set(value any, path ...any) {
+	d.root = jsonUpdate(d.root, path, func(any) any { return value })
+	d.dirty, d.stale = true, true
+}
+
+func (d *scenarioDocument) delete(path ...any) {
+	d.set(jsonDeleted, path...)
+}
+
+// renameFix renames the fix and updates the references to it in other
+// fixes' definitions, routes and approaches, and the other places where
+// a location may be given by a fix. Departure exits are also named in
+// the departures and elsewhere, so fixes that are exits can't be
+// renamed.
+func (d *scenarioDocument) renameFix(from, to string) error {
+	if d.hasFix(to) && !strings.EqualFold(to, from) {
+		return fmt.Errorf("%s: fix already exists", to)
+	}
+	for _, icao := range jsonKeys(d.root, "airports") {
+		for _, rwy := range jsonKeys(d.root, "airports", icao, "departure_routes") {
+			if slices.ContainsFunc(jsonKeys(d.root, "airports", icao, "departure_routes", rwy),
+				func(exit string) bool { return strings.EqualFold(exit, from) }) {
+				return fmt.Errorf("%s: fix is a departure exit at %s and can't be renamed", from, icao)
+			}
+		}
+	}
+
+	d.root = jsonRename(d.root, from, to, "fixes")
+	for _, fix := range d.fixNames() {
+		loc := d.getString("fixes", fix)
+		if strs := reFixHeadingDistance.FindStringSubmatch(loc); len(strs) >= 4 && strings.EqualFold(strs[1], from) {
+			d.root = jsonUpdate(d.root, []any{"fixes", fix}, func(any) any { return to + loc[len(strs[1]):] })
+		}
+	}
+	for _, path := range d.waypointsPaths() {
+		d.root = jsonUpdate(d.root, path, func(wps any) any {
+			if s, ok := wps.(string); ok {
+				return renameWaypointsFix(s, from, to)
+			}
+			return wps
+		})
+	}
+	for _, path := range d.locationPaths() {
+		if strings.EqualFold(d.getString(path...), from) {
+			d.root = jsonUpdate(d.root, path, func(any) any { return to })
+		}
+	}
+
+	d.dirty, d.stale = true, true
+	return nil
+}
+
+// moveFix moves the fix to the given location. Fixes that are defined
+// relative to another one ("FIX@HDG/DIST") keep that form, with the
+// heading and distance updated.
+func (d *scenarioDocument) moveFix(fix string, p Point2LL) error {
+	strs := reFixHeadingDistance.FindStringSubmatch(d.getString("fixes", fix))
+	if len(strs) < 4 {
+		d.set(p.DMSString(), "fixes", fix)
+		return nil
+	}
+
+	var base Point2LL
+	ok := false
+	if d.sg != nil {
+		base, ok = d.sg.locate(strs[1])
+	}
+	if !ok {
+		return fmt.Errorf("%s: can't move fix defined relative to unknown fix %s", fix, strs[1])
+	}
+	nmPerLongitude := d.sg.NmPerLongitude
+	hdg := int(headingp2ll(base, p, nmPerLongitude, 0) + 0.5)
+	dist := length2f(sub2f(ll2nm(p, nmPerLongitude), ll2nm(base, nmPerLongitude)))
+	d.set(fmt.Sprintf("%s@%03d/%.1f", strs[1], Select(hdg == 0, 360, hdg), dist), "fixes", fix)
+	return nil
+}
+
+// arrayLength returns the number of elements in the array at the given
+// path, or zero if there isn't one.
+func (d *scenarioDocument) arrayLength(path ...any) int {
+	v, _ := d.get(path...)
+	a, _ := v.([]any)
+	return len(a)
+}
+
+// existingPaths returns the paths that are present in the document.
+func (d *scenarioDocument) existingPaths(paths [][]any) [][]any {
+	return FilterSlice(paths, func(path []any) bool {
+		_, ok := d.get(path...)
+		return ok
+	})
+}
+
+// waypointsPaths returns the paths to all of the waypoints strings in
+// arrival routes, departure exit routes, and approaches as well as the
+// departures' filed routes.
+func (d *scenarioDocument) waypointsPaths() [][]any {
+	var paths [][]any
+	for _, group := range jsonKeys(d.root, "arrival_groups") {
+		for i := 0; i < d.arrayLength("arrival_groups", group); i++ {
+			paths = append(paths, []any{"arrival_groups", group, i, "waypoints"})
+			for _, ap := range jsonKeys(d.root, "arrival_groups", group, i, "runway_waypoints") {
+				for _, rwy := range jsonKeys(d.root, "arrival_groups", group, i, "runway_waypoints", ap) {
+					paths = append(paths, []any{"arrival_groups", group, i, "runway_waypoints", ap, rwy})
+				}
+			}
+		}
+	}
+	for _, icao := range jsonKeys(d.root, "airports") {
+		for _, rwy := range jsonKeys(d.root, "airports", icao, "departure_routes") {
+			for _, exit := range jsonKeys(d.root, "airports", icao, "departure_routes", rwy) {
+				paths = append(paths, []any{"airports", icao, "departure_routes", rwy, exit, "waypoints"})
+			}
+		}
+		for _, appr := range jsonKeys(d.root, "airports", icao, "approaches") {
+			for i := 0; i < d.arrayLength("airports", icao, "approaches", appr, "waypoints"); i++ {
+				paths = append(paths, []any{"airports", icao, "approaches", appr, "waypoints", i})
+			}
+		}
+		for i := 0; i < d.arrayLength("airports", icao, "departures"); i++ {
+			paths = append(paths, []any{"airports", icao, "departures", i, "route"})
+		}
+	}
+	return d.existingPaths(paths)
+}
+
+// locationPaths returns the paths to the strings that give a single
+// location, which may be a fix: centers, radar site positions, reporting
+// points, and so forth.
+func (d *scenarioDocument) locationPaths() [][]any {
+	paths := [][]any{[]any{"stars_config", "center"}}
+	for _, site := range jsonKeys(d.root, "stars_config", "radar_sites") {
+		paths = append(paths, []any{"stars_config", "radar_sites", site, "position"})
+	}
+	for i := 0; i < d.arrayLength("stars_config", "airspace_awareness"); i++ {
+		for j := 0; j < d.arrayLength("stars_config", "airspace_awareness", i, "fixes"); j++ {
+			paths = append(paths, []any{"stars_config", "airspace_awareness", i, "fixes", j})
+		}
+	}
+	for i := 0; i < d.arrayLength("reporting_points"); i++ {
+		paths = append(paths, []any{"reporting_points", i})
+	}
+	for _, name := range jsonKeys(d.root, "scenarios") {
+		paths = append(paths, []any{"scenarios", name, "center"})
+	}
+	for _, icao := range jsonKeys(d.root, "airports") {
+		for _, rwy := range jsonKeys(d.root, "airports", icao, "approach_regions") {
+			paths = append(paths, []any{"airports", icao, "approach_regions", rwy, "reference_point"})
+		}
+		for _, rwy := range jsonKeys(d.root, "airports", icao, "atpa_volumes") {
+			paths = append(paths, []any{"airports", icao, "atpa_volumes", rwy, "runway_threshold"})
+		}
+	}
+	return d.existingPaths(paths)
+}
+
+// renameWaypointsFix returns the waypoints with the fix renamed; any
+// modifiers after it (e.g., "/a50") are kept.
+func renameWaypointsFix(wps, from, to string) string {
+	f := strings.Fields(wps)
+	renamed := false
+	for i, field := range f {
+		if fix, _, _ := strings.Cut(field, "/"); strings.EqualFold(fix, from) {
+			f[i] = to + field[len(fix):]
+			renamed = true
+		}
+	}
+	return Select(renamed, strings.Join(f, " "), wps)
+}
+
+// validate loads the JSON into a ScenarioGroup and runs the usual checks
+// on it if it has changed since the last time it was validated.
+func (d *scenarioDocument) validate() {
+	if !d.stale {
+		return
+	}
+	d.stale = false
+
+	var e ErrorLogger
+	b, err := json.Marshal(d.root)
+	if err != nil {
+		e.Error(err)
+		d.errors = e.errors
+		return
+	}
+
+	CheckJSONVsSchema[ScenarioGroup](b, &e)
+	var sg ScenarioGroup
+	if err := UnmarshalJSON(b, &sg); err != nil {
+		e.Error(err)
+		d.errors = e.errors
+		return
+	}
+
+	// Keep the last good ScenarioGroup if validation doesn't make it all
+	// the way through; the partial edit may be something that the
+	// checks don't expect.
+	ok := func() (ok bool) {
+		defer func() {
+			if err := recover(); err != nil {
+				e.ErrorString("%v", err)
+			}
+		}()
+		sg.PostDeserialize(&e, make(map[string]map[string]*SimConfiguration))
+		return true
+	}()
+	if ok {
+		d.sg = &sg
+	}
+	d.errors = e.errors
+}
+
+func (d *scenarioDocument) save() error {
+	b, err := json.MarshalIndent(d.root, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(d.filename, b, 0o644); err != nil {
+		return err
+	}
+	d.dirty = false
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////
+// ScenarioEditorPane
+
+const (
+	ScenarioEditorToolSelect = iota
+	ScenarioEditorToolAddFix
+	ScenarioEditorToolDrawBoundary
+	ScenarioEditorToolAddRouteFix
+	ScenarioEditorToolPlaceReference
+)
+
+// scenarioEditorSelection records the selected item; at most one of its
+// fields is set.
+type scenarioEditorSelection struct {
+	fix      string // as in the JSON, which may not be upper case
+	boundary string
+	volume   string
+	route    []any // path to a route's "waypoints" string
+	region   []any // path to an approach region or ATPA volume
+}
+
+// scenarioEditorDrag records a fix or boundary vertex being dragged; the
+// document is updated when the mouse button is released.
+type scenarioEditorDrag struct {
+	fix      string
+	boundary string
+	vertex   int
+	pos      Point2LL
+	moved    bool
+}
+
+// ScenarioEditorPane shows a scenario group on a map and allows editing
+// its fixes, airspace, routes, and approach geometry. It takes over the
+// whole window while it's open; its controls are in a separate window
+// drawn by DrawWindow.
+type ScenarioEditorPane struct {
+	doc *scenarioDocument
+
+	center  Point2LL
+	rangenm float32
+
+	tool      int
+	selection scenarioEditorSelection
+	drag      *scenarioEditorDrag
+
+	newName    string
+	renameTo   string
+	err        error
+	showErrors bool
+
+	font *Font
+}
+
+const scenarioEditorPickDistance = 8 // pixels
+
+func NewScenarioEditorPane(filename string) (*ScenarioEditorPane, error) {
+	doc, err := loadScenarioDocument(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	ep := &ScenarioEditorPane{doc: doc, rangenm: 50, font: GetDefaultFont()}
+	if sg := doc.sg; sg != nil {
+		ep.center = sg.STARSFacilityAdaptation.Center
+		if sg.STARSFacilityAdaptation.Range != 0 {
+			ep.rangenm = sg.STARSFacilityAdaptation.Range
+		}
+	}
+	return ep, nil
+}
+
+func (ep *ScenarioEditorPane) Name() string { return "Scenario Editor" }
+
+func (ep *ScenarioEditorPane) Activate(w *World, r Renderer, eventStream *EventStream) {}
+func (ep *ScenarioEditorPane) Deactivate()                                             {}
+func (ep *ScenarioEditorPane) ResetWorld(w *World)                                     {}
+func (ep *ScenarioEditorPane) CanTakeKeyboardFocus() bool                              { return false }
+
+func (ep *ScenarioEditorPane) transforms(ctx *PaneContext) ScopeTransformations {
+	nmPerLongitude, magneticVariation := nmPerLatitude*cos(radians(ep.center[1])), float32(0)
+	if sg := ep.doc.sg; sg != nil && sg.NmPerLongitude != 0 {
+		nmPerLongitude, magneticVariation = sg.NmPerLongitude, sg.MagneticVariation
+	}
+	return GetScopeTransformations(ctx.paneExtent, magneticVariation, nmPerLongitude, ep.center, ep.rangenm, 0)
+}
+
+func (ep *ScenarioEditorPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
+	transforms := ep.transforms(ctx)
+	ep.consumeMouseEvents(ctx, transforms)
+
+	sg := ep.doc.sg
+	if sg == nil {
+		return
+	}
+
+	ld := GetColoredLinesDrawBuilder()
+	defer ReturnColoredLinesDrawBuilder(ld)
+	td := GetTextDrawBuilder()
+	defer ReturnTextDrawBuilder(td)
+
+	var (
+		normalColor   = RGB{.5, .5, .5}
+		selectedColor = RGB{1, 1, .2}
+		arrivalColor  = RGB{.3, .7, .3}
+		exitColor     = RGB{.3, .5, .9}
+		regionColor   = RGB{.8, .4, .8}
+		volumeColor   = RGB{.2, .9, .9}
+	)
+	window := transforms.WindowFromLatLongP
+	polyline := func(pts []Point2LL, color RGB) {
+		for i := 0; i+1 < len(pts); i++ {
+			ld.AddLine(window(pts[i]), window(pts[i+1]), color)
+		}
+	}
+	closedPolyline := func(pts []Point2LL, color RGB) {
+		polyline(append(slices.Clone(pts), pts[0]), color)
+	}
+	label := func(s string, p Point2LL, color RGB) {
+		pw := add2f(window(p), [2]float32{6, -2})
+		td.AddText(s, pw, TextStyle{Font: ep.font, Color: color})
+	}
+
+	// Airspace boundaries, with vertices shown for the selected one.
+	var volumeBoundaries []string
+	if ep.selection.volume != "" {
+		for _, v := range sg.Airspace.Volumes[ep.selection.volume] {
+			volumeBoundaries = append(volumeBoundaries, v.BoundaryNames...)
+		}
+	}
+	for _, name := range SortedMapKeys(sg.Airspace.Boundaries) {
+		pts := sg.Airspace.Boundaries[name]
+		if d := ep.drag; d != nil && d.moved && d.boundary == name && d.vertex < len(pts) {
+			pts = slices.Clone(pts)
+			pts[d.vertex] = d.pos
+		}
+
+		color := normalColor
+		if name == ep.selection.boundary {
+			color = selectedColor
+			for _, p := range pts {
+				pw := window(p)
+				ld.AddPolyline(pw, color, [][2]float32{{-3, -3}, {3, -3}, {3, 3}, {-3, 3}, {-3, -3}})
+			}
+			if len(pts) > 0 {
+				label(name, pts[0], color)
+			}
+		} else if slices.Contains(volumeBoundaries, name) {
+			color = volumeColor
+		}
+		polyline(pts, color)
+	}
+	if vols := sg.Airspace.Volumes[ep.selection.volume]; len(vols) > 0 {
+		for _, v := range vols {
+			if len(v.Boundaries) > 0 && len(v.Boundaries[0]) > 0 {
+				label(fmt.Sprintf("%03d-%03d", v.LowerLimit/100, v.UpperLimit/100), v.Boundaries[0][0], volumeColor)
+			}
+		}
+	}
+
+	// Routes: arrivals and departure exits.
+	routeLocations := func(wps WaypointArray) []Point2LL {
+		var pts []Point2LL
+		for _, wp := range wps {
+			if !wp.Location.IsZero() {
+				pts = append(pts, wp.Location)
+			}
+		}
+		return pts
+	}
+	for _, group := range SortedMapKeys(sg.ArrivalGroups) {
+		for i, ar := range sg.ArrivalGroups[group] {
+			selected := slices.Equal(ep.selection.route, []any{"arrival_groups", group, i, "waypoints"})
+			polyline(routeLocations(ar.Waypoints), Select(selected, selectedColor, arrivalColor))
+		}
+	}
+	for _, icao := range SortedMapKeys(sg.Airports) {
+		ap := sg.Airports[icao]
+		for _, rwy := range SortedMapKeys(ap.DepartureRoutes) {
+			for _, exit := range SortedMapKeys(ap.DepartureRoutes[rwy]) {
+				route := ap.DepartureRoutes[rwy][exit]
+				selected := slices.Equal(ep.selection.route,
+					[]any{"airports", icao, "departure_routes", rwy, exit, "waypoints"})
+				polyline(routeLocations(route.Waypoints), Select(selected, selectedColor, exitColor))
+			}
+		}
+
+		// Approach regions and ATPA volumes
+		for _, rwy := range SortedMapKeys(ap.ApproachRegions) {
+			line, quad := ap.ApproachRegions[rwy].GetLateralGeometry(sg.NmPerLongitude, sg.MagneticVariation)
+			selected := slices.Equal(ep.selection.region, []any{"airports", icao, "approach_regions", rwy})
+			color := Select(selected, selectedColor, regionColor)
+			polyline(line[:], color)
+			closedPolyline(quad[:], color)
+		}
+		for _, id := range SortedMapKeys(ap.ATPAVolumes) {
+			rect := ap.ATPAVolumes[id].GetRect(sg.NmPerLongitude, sg.MagneticVariation)
+			selected := slices.Equal(ep.selection.region, []any{"airports", icao, "atpa_volumes", id})
+			closedPolyline(rect[:], Select(selected, selectedColor, regionColor))
+		}
+	}
+
+	// Fixes
+	tri := EquilateralTriangleVertices(10)
+	triangle := [][2]float32{tri[0], tri[1], tri[2], tri[0]}
+	for _, name := range ep.doc.fixNames() {
+		p, ok := sg.Fixes[strings.ToUpper(name)]
+		if d := ep.drag; d != nil && d.moved && d.fix == name {
+			p, ok = d.pos, true
+		}
+		if ok {
+			color := Select(name == ep.selection.fix, selectedColor, normalColor)
+			ld.AddPolyline(window(p), color, triangle)
+			label(name, p, color)
+		}
+	}
+
+	transforms.LoadWindowViewingMatrices(cb)
+	cb.LineWidth(1)
+	ld.GenerateCommands(cb)
+	td.GenerateCommands(cb)
+}
+
+func (ep *ScenarioEditorPane) consumeMouseEvents(ctx *PaneContext, transforms ScopeTransformations) {
+	mouse := ctx.mouse
+	if mouse == nil {
+		return
+	}
+
+	// Pan and zoom as in the STARS scope.
+	if mouse.Dragging[MouseButtonSecondary] {
+		ep.center = sub2f(ep.center, transforms.LatLongFromWindowV(mouse.DragDelta))
+	}
+	if mouse.Wheel[1] != 0 {
+		r := ep.rangenm
+		ep.rangenm = clamp(ep.rangenm+mouse.Wheel[1], 2, 400)
+
+		mouseLL := transforms.LatLongFromWindowP(mouse.Pos)
+		scale := ep.rangenm / r
+		centerTransform := Identity3x3().
+			Translate(mouseLL[0], mouseLL[1]).
+			Scale(scale, scale).
+			Translate(-mouseLL[0], -mouseLL[1])
+		ep.center = centerTransform.TransformPoint(ep.center)
+	}
+
+	mouseLL := transforms.LatLongFromWindowP(mouse.Pos)
+
+	if d := ep.drag; d != nil {
+		if mouse.Down[MouseButtonPrimary] {
+			if mouse.Dragging[MouseButtonPrimary] {
+				d.pos, d.moved = mouseLL, true
+			}
+		} else {
+			// Nothing changes if it was just clicked to select it.
+			if d.moved && d.fix != "" {
+				ep.err = ep.doc.moveFix(d.fix, d.pos)
+			} else if d.moved {
+				ep.doc.set(d.pos.DMSString(), "airspace", "boundaries", d.boundary, d.vertex)
+			}
+			ep.drag = nil
+		}
+		return
+	}
+
+	if !mouse.Clicked[MouseButtonPrimary] {
+		return
+	}
+
+	switch ep.tool {
+	case ScenarioEditorToolSelect:
+		if fix, ok := ep.pickFix(mouse.Pos, transforms); ok {
+			ep.selection = scenarioEditorSelection{fix: fix}
+			ep.drag = &scenarioEditorDrag{fix: fix, pos: mouseLL}
+		} else if b, v, ok := ep.pickBoundaryVertex(mouse.Pos, transforms); ok {
+			ep.selection = scenarioEditorSelection{boundary: b}
+			ep.drag = &scenarioEditorDrag{boundary: b, vertex: v, pos: mouseLL}
+		} else {
+			ep.selection = scenarioEditorSelection{}
+		}
+
+	case ScenarioEditorToolAddFix:
+		name := strings.ToUpper(strings.TrimSpace(ep.newName))
+		if name == "" {
+			for i := 1; ; i++ {
+				if name = fmt.Sprintf("FIX%d", i); !ep.doc.hasFix(name) {
+					break
+				}
+			}
+		}
+		if ep.doc.hasFix(name) {
+			ep.err = fmt.Errorf("%s: fix already exists", name)
+		} else {
+			ep.doc.set(mouseLL.DMSString(), "fixes", name)
+			ep.selection = scenarioEditorSelection{fix: name}
+			ep.newName, ep.err = "", nil
+		}
+
+	case ScenarioEditorToolDrawBoundary:
+		if b := ep.selection.boundary; b != "" {
+			v, _ := ep.doc.get("airspace", "boundaries", b)
+			pts, _ := v.([]any)
+			ep.doc.set(mouseLL.DMSString(), "airspace", "boundaries", b, len(pts))
+		}
+
+	case ScenarioEditorToolAddRouteFix:
+		if ep.selection.route != nil {
+			if fix, ok := ep.pickAnyFix(mouse.Pos, transforms); ok {
+				wps := strings.TrimSpace(ep.doc.getString(ep.selection.route...))
+				ep.doc.set(strings.TrimSpace(wps+" "+fix), ep.selection.route...)
+			}
+		}
+
+	case ScenarioEditorToolPlaceReference:
+		if r := ep.selection.region; len(r) == 4 {
+			key := Select(r[2] == "approach_regions", "reference_point", "runway_threshold")
+			ep.doc.set(mouseLL.DMSString(), append(slices.Clone(r), key)...)
+		}
+		ep.tool = ScenarioEditorToolSelect
+	}
+}
+
+// pickFix returns the scenario group fix closest to the given window
+// position, if one is close enough.
+func (ep *ScenarioEditorPane) pickFix(pw [2]float32, transforms ScopeTransformations) (string, bool) {
+	sg := ep.doc.sg
+	if sg == nil {
+		return "", false
+	}
+	fix, dist := "", float32(scenarioEditorPickDistance)
+	for _, name := range ep.doc.fixNames() {
+		if p, ok := sg.Fixes[strings.ToUpper(name)]; ok {
+			if d := distance2f(pw, transforms.WindowFromLatLongP(p)); d < dist {
+				fix, dist = name, d
+			}
+		}
+	}
+	return fix, fix != ""
+}
+
+// pickAnyFix is like pickFix but also considers fixes and navaids from
+// the FAA database.
+func (ep *ScenarioEditorPane) pickAnyFix(pw [2]float32, transforms ScopeTransformations) (string, bool) {
+	if fix, ok := ep.pickFix(pw, transforms); ok {
+		return strings.ToUpper(fix), true
+	}
+
+	fix, dist := "", float32(scenarioEditorPickDistance)
+	check := func(id string, p Point2LL) {
+		if d := distance2f(pw, transforms.WindowFromLatLongP(p)); d < dist {
+			fix, dist = id, d
+		}
+	}
+	for id, f := range database.Fixes {
+		check(id, f.Location)
+	}
+	for id, n := range database.Navaids {
+		check(id, n.Location)
+	}
+	return fix, fix != ""
+}
+
+// pickBoundaryVertex returns the airspace boundary vertex closest to the
+// given window position. Vertices of the selected boundary are preferred.
+func (ep *ScenarioEditorPane) pickBoundaryVertex(pw [2]float32, transforms ScopeTransformations) (string, int, bool) {
+	sg := ep.doc.sg
+	if sg == nil {
+		return "", 0, false
+	}
+	names := SortedMapKeys(sg.Airspace.Boundaries)
+	if b := ep.selection.boundary; b != "" {
+		names = append([]string{b}, names...)
+	}
+	for _, name := range names {
+		for i, p := range sg.Airspace.Boundaries[name] {
+			if distance2f(pw, transforms.WindowFromLatLongP(p)) < scenarioEditorPickDistance {
+				return name, i, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+func (d *scenarioDocument) fixNames() []string {
+	return jsonKeys(d.root, "fixes")
+}
+
+func (d *scenarioDocument) hasFix(name string) bool {
+	return slices.ContainsFunc(d.fixNames(), func(f string) bool { return strings.EqualFold(f, name) })
+}
+
+///////////////////////////////////////////////////////////////////////////
+// Editor controls
+
+// DrawWindow draws the editor's controls; it returns false when the
+// editor has been closed.
+func (ep *ScenarioEditorPane) DrawWindow() bool {
+	open := true
+	title := "Scenario Editor: " + ep.doc.filename + Select(ep.doc.dirty, " (modified)", "")
+	imgui.SetNextWindowSizeV(imgui.Vec2{450, 600}, imgui.ConditionFirstUseEver)
+	imgui.BeginV(title+"###ScenarioEditor", &open, 0)
+
+	uiStartDisable(!ep.doc.dirty)
+	if imgui.Button("Save") {
+		ep.err = ep.doc.save()
+	}
+	uiEndDisable(!ep.doc.dirty)
+	imgui.SameLine()
+	if imgui.Button(Select(ep.doc.dirty, "Discard changes and close", "Close")) {
+		open = false
+	} else if !open && ep.doc.dirty {
+		// Don't lose changes if the window is closed via its close box.
+		open = true
+		ep.err = fmt.Errorf("there are unsaved changes")
+	}
+
+	imgui.Text("Tool:")
+	imgui.SameLine()
+	imgui.RadioButtonInt("Select", &ep.tool, ScenarioEditorToolSelect)
+	imgui.SameLine()
+	imgui.RadioButtonInt("Add fix", &ep.tool, ScenarioEditorToolAddFix)
+	imgui.SameLine()
+	imgui.RadioButtonInt("Draw boundary", &ep.tool, ScenarioEditorToolDrawBoundary)
+	imgui.SameLine()
+	imgui.RadioButtonInt("Add route fixes", &ep.tool, ScenarioEditorToolAddRouteFix)
+	if ep.tool == ScenarioEditorToolAddFix {
+		imgui.InputTextV("New fix name", &ep.newName, imgui.InputTextFlagsCharsUppercase, nil)
+	}
+	imgui.Separator()
+
+	if imgui.CollapsingHeader("Fixes") {
+		ep.drawFixesUI()
+	}
+	if imgui.CollapsingHeader("Airspace boundaries") {
+		ep.drawBoundariesUI()
+	}
+	if imgui.CollapsingHeader("Airspace volumes") {
+		ep.drawVolumesUI()
+	}
+	if imgui.CollapsingHeader("Arrival routes") {
+		ep.drawArrivalsUI()
+	}
+	if imgui.CollapsingHeader("Departure exit routes") {
+		ep.drawExitsUI()
+	}
+	if imgui.CollapsingHeader("Approach regions and ATPA volumes") {
+		ep.drawRegionsUI()
+	}
+
+	imgui.Separator()
+	if ep.err != nil {
+		imgui.PushStyleColor(imgui.StyleColorText, imgui.Vec4{1, .5, .5, 1})
+		imgui.Text(ep.err.Error())
+		imgui.PopStyleColor()
+	}
+	if n := len(ep.doc.errors); n == 0 {
+		imgui.Text("No errors")
+	} else {
+		imgui.PushStyleColor(imgui.StyleColorText, imgui.Vec4{1, .5, .5, 1})
+		imgui.Checkbox(fmt.Sprintf("Show %d errors", n), &ep.showErrors)
+		if ep.showErrors {
+			for _, err := range ep.doc.errors {
+				imgui.Text(err)
+			}
+		}
+		imgui.PopStyleColor()
+	}
+
+	imgui.End()
+
+	// Validate once editing has settled down.
+	if !imgui.IsAnyItemActive() && ep.drag == nil {
+		ep.doc.validate()
+	}
+
+	return open
+}
+
+func (ep *ScenarioEditorPane) stringField(label string, path ...any) {
+	s := ep.doc.getString(path...)
+	if imgui.InputText(label, &s) {
+		ep.doc.set(s, path...)
+	}
+}
+
+func (ep *ScenarioEditorPane) floatField(label string, speed float32, path ...any) {
+	v, _ := ep.doc.get(path...)
+	f, _ := v.(float64)
+	f32 := float32(f)
+	if imgui.DragFloatV(label, &f32, speed, 0, 0, "%.2f", 0) {
+		ep.doc.set(float64(f32), path...)
+	}
+}
+
+func (ep *ScenarioEditorPane) intField(label string, path ...any) {
+	v, _ := ep.doc.get(path...)
+	f, _ := v.(float64)
+	i := int32(f)
+	if imgui.InputIntV(label, &i, 100, 1000, 0) {
+		ep.doc.set(float64(i), path...)
+	}
+}
+
+func (ep *ScenarioEditorPane) drawFixesUI() {
+	for _, name := range ep.doc.fixNames() {
+		if imgui.SelectableV(name+"##fix", name == ep.selection.fix, 0, imgui.Vec2{}) {
+			ep.selection = scenarioEditorSelection{fix: name}
+			ep.renameTo = name
+		}
+	}
+
+	if fix := ep.selection.fix; fix != "" {
+		imgui.Separator()
+		ep.stringField("Location##"+fix, "fixes", fix)
+		imgui.InputTextV("##rename", &ep.renameTo, imgui.InputTextFlagsCharsUppercase, nil)
+		imgui.SameLine()
+		if imgui.Button("Rename") {
+			if to := strings.TrimSpace(ep.renameTo); to != "" && to != fix {
+				if ep.err = ep.doc.renameFix(fix, to); ep.err == nil {
+					ep.selection.fix = to
+				}
+			}
+		}
+		imgui.SameLine()
+		if imgui.Button("Delete fix") {
+			ep.doc.delete("fixes", fix)
+			ep.selection = scenarioEditorSelection{}
+		}
+	}
+}
+
+func (ep *ScenarioEditorPane) drawBoundariesUI() {
+	for _, name := range jsonKeys(ep.doc.root, "airspace", "boundaries") {
+		if imgui.SelectableV(name+"##boundary", name == ep.selection.boundary, 0, imgui.Vec2{}) {
+			ep.selection = scenarioEditorSelection{boundary: name}
+		}
+	}
+
+	imgui.InputTextV("##newboundary", &ep.newName, imgui.InputTextFlagsCharsUppercase, nil)
+	imgui.SameLine()
+	if imgui.Button("New boundary") {
+		if name := strings.TrimSpace(ep.newName); name != "" {
+			if _, ok := ep.doc.get("airspace", "boundaries", name); ok {
+				ep.err = fmt.Errorf("%s: boundary already exists", name)
+			} else {
+				ep.doc.set([]any{}, "airspace", "boundaries", name)
+				ep.selection = scenarioEditorSelection{boundary: name}
+				ep.tool = ScenarioEditorToolDrawBoundary
+				ep.newName, ep.err = "", nil
+			}
+		}
+	}
+	if imgui.IsItemHovered() {
+		imgui.SetTooltip("Create a new boundary and then click on the map to add its vertices")
+	}
+
+	if b := ep.selection.boundary; b != "" {
+		imgui.Separator()
+		v, _ := ep.doc.get("airspace", "boundaries", b)
+		pts, _ := v.([]any)
+		imgui.Text(fmt.Sprintf("%s: %d vertices", b, len(pts)))
+		if len(pts) > 0 {
+			if imgui.Button("Remove last vertex") {
+				ep.doc.delete("airspace", "boundaries", b, len(pts)-1)
+			}
+			imgui.SameLine()
+			if imgui.Button("Close boundary") {
+				ep.doc.set(pts[0], "airspace", "boundaries", b, len(pts))
+			}
+			imgui.SameLine()
+		}
+		if imgui.Button("Delete boundary") {
+			ep.doc.delete("airspace", "boundaries", b)
+			ep.selection = scenarioEditorSelection{}
+		}
+	}
+}
+
+func (ep *ScenarioEditorPane) drawVolumesUI() {
+	for _, name := range jsonKeys(ep.doc.root, "airspace", "volumes") {
+		if imgui.SelectableV(name+"##volume", name == ep.selection.volume, 0, imgui.Vec2{}) {
+			ep.selection = scenarioEditorSelection{volume: name}
+		}
+	}
+
+	imgui.InputTextV("##newvolume", &ep.newName, imgui.InputTextFlagsCharsUppercase, nil)
+	imgui.SameLine()
+	if imgui.Button("New volume") {
+		if name := strings.TrimSpace(ep.newName); name != "" {
+			ep.doc.set([]any{}, "airspace", "volumes", name)
+			ep.selection = scenarioEditorSelection{volume: name}
+			ep.newName = ""
+		}
+	}
+
+	name := ep.selection.volume
+	if name == "" {
+		return
+	}
+	imgui.Separator()
+
+	v, _ := ep.doc.get("airspace", "volumes", name)
+	entries, _ := v.([]any)
+	for i := range entries {
+		imgui.PushIDInt(i)
+		imgui.Text(fmt.Sprintf("Part %d", i+1))
+		ep.intField("Floor", "airspace", "volumes", name, i, "lower")
+		ep.intField("Ceiling", "airspace", "volumes", name, i, "upper")
+
+		// Boundaries are edited as a comma-separated list.
+		var bounds []string
+		if b, ok := ep.doc.get("airspace", "volumes", name, i, "boundaries"); ok {
+			for _, s := range b.([]any) {
+				if s, ok := s.(string); ok {
+					bounds = append(bounds, s)
+				}
+			}
+		}
+		s := strings.Join(bounds, ", ")
+		if imgui.InputText("Boundaries", &s) {
+			var b []any
+			for _, f := range strings.Split(s, ",") {
+				if f = strings.TrimSpace(f); f != "" {
+					b = append(b, f)
+				}
+			}
+			ep.doc.set(b, "airspace", "volumes", name, i, "boundaries")
+		}
+		if imgui.Button("Delete part") {
+			ep.doc.delete("airspace", "volumes", name, i)
+		}
+		imgui.PopID()
+	}
+
+	if imgui.Button("Add part") {
+		part := orderedmap.New()
+		part.Set("lower", float64(0))
+		part.Set("upper", float64(10000))
+		part.Set("boundaries", []any{})
+		ep.doc.set(*part, "airspace", "volumes", name, len(entries))
+	}
+	imgui.SameLine()
+	if imgui.Button("Delete volume") {
+		ep.doc.delete("airspace", "volumes", name)
+		ep.selection = scenarioEditorSelection{}
+	}
+}
+
+// routeField shows the waypoints at the given path along with a button
+// to select it so that fixes can be added to it from the map.
+func (ep *ScenarioEditorPane) routeField(label string, path ...any) {
+	selected := slices.Equal(ep.selection.route, path)
+	if imgui.SelectableV(label, selected, 0, imgui.Vec2{}) {
+		ep.selection = scenarioEditorSelection{route: path}
+		ep.tool = ScenarioEditorToolAddRouteFix
+	}
+	if selected {
+		ep.stringField("Waypoints##"+label, path...)
+	}
+}
+
+func (ep *ScenarioEditorPane) drawArrivalsUI() {
+	for _, group := range jsonKeys(ep.doc.root, "arrival_groups") {
+		v, _ := ep.doc.get("arrival_groups", group)
+		arrivals, _ := v.([]any)
+		for i := range arrivals {
+			// Arrivals may be specified using "route" or "star" instead
+			// of waypoints; those aren't editable here.
+			if _, ok := ep.doc.get("arrival_groups", group, i, "waypoints"); ok {
+				ep.routeField(fmt.Sprintf("%s #%d", group, i+1), "arrival_groups", group, i, "waypoints")
+			}
+		}
+	}
+}
+
+func (ep *ScenarioEditorPane) drawExitsUI() {
+	for _, icao := range jsonKeys(ep.doc.root, "airports") {
+		for _, rwy := range jsonKeys(ep.doc.root, "airports", icao, "departure_routes") {
+			for _, exit := range jsonKeys(ep.doc.root, "airports", icao, "departure_routes", rwy) {
+				ep.routeField(icao+" "+rwy+" "+exit, "airports", icao, "departure_routes", rwy, exit, "waypoints")
+			}
+		}
+	}
+}
+
+func (ep *ScenarioEditorPane) drawRegionsUI() {
+	regionFields := []string{"reference_heading", "reference_length", "reference_altitude", "near_distance", "near_half_width",
+		"far_half_width", "region_length", "heading_tolerance"}
+	atpaFields := []string{"heading", "max_heading_deviation", "length", "left_width", "right_width",
+		"floor", "ceiling"}
+
+	for _, icao := range jsonKeys(ep.doc.root, "airports") {
+		for _, kind := range []string{"approach_regions", "atpa_volumes"} {
+			for _, id := range jsonKeys(ep.doc.root, "airports", icao, kind) {
+				path := []any{"airports", icao, kind, id}
+				selected := slices.Equal(ep.selection.region, path)
+				label := icao + " " + id + Select(kind == "approach_regions", " approach region", " ATPA volume")
+				if imgui.SelectableV(label, selected, 0, imgui.Vec2{}) {
+					ep.selection = scenarioEditorSelection{region: path}
+				}
+				if !selected {
+					continue
+				}
+
+				imgui.PushID(label)
+				if kind == "approach_regions" {
+					ep.stringField("Reference point", append(slices.Clone(path), "reference_point")...)
+					for _, f := range regionFields {
+						ep.floatField(f, 0.1, append(slices.Clone(path), f)...)
+					}
+				} else {
+					ep.stringField("Runway threshold", append(slices.Clone(path), "runway_threshold")...)
+					for _, f := range atpaFields {
+						ep.floatField(f, Select(f == "floor" || f == "ceiling", float32(10), float32(0.1)),
+							append(slices.Clone(path), f)...)
+					}
+				}
+				if imgui.Button("Place on map") {
+					ep.tool = ScenarioEditorToolPlaceReference
+				}
+				if imgui.IsItemHovered() {
+					imgui.SetTooltip("Click on the map to set the reference point or threshold")
+				}
+				imgui.PopID()
+			}
+		}
+	}
+}
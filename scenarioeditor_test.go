@@ -0,0 +1,193 @@
+// scenarioeditor_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/iancoleman/orderedmap"
+)
+
+func TestScenarioEditorJSON(t *testing.T) {
+	in := `{"name": "PHL", "fixes": {"_FOO": "N039.52.00.000,W075.14.00.000", "BAR": "KPHL-090-5"},
+"airspace": {"boundaries": {"A": ["N040.00.00.000,W075.00.00.000"]}}, "arrival_groups": {"X": [{"waypoints": "A B"}]}}`
+	root := orderedmap.New()
+	if err := json.Unmarshal([]byte(in), root); err != nil {
+		t.Fatal(err)
+	}
+	var v any = *root
+
+	check := func(expected string) {
+		t.Helper()
+		if b, err := json.Marshal(v); err != nil {
+			t.Fatal(err)
+		} else if string(b) != expected {
+			t.Errorf("got %s\nexpected %s", string(b), expected)
+		}
+	}
+
+	if wps, ok := jsonGet(v, "arrival_groups", "X", 0, "waypoints"); !ok || wps != "A B" {
+		t.Errorf("got %v for waypoints", wps)
+	}
+	for _, path := range [][]any{{"fixes", "BAZ"}, {"arrival_groups", "X", 1}, {"name", "foo"}, {"fixes", 0}} {
+		if _, ok := jsonGet(v, path...); ok {
+			t.Errorf("%v: unexpectedly found value", path)
+		}
+	}
+
+	set := func(value any, path ...any) {
+		v = jsonUpdate(v, path, func(any) any { return value })
+	}
+
+	// Renaming keeps the order of the fixes.
+	v = jsonRename(v, "_FOO", "FOO", "fixes")
+	set("N040.00.00.000,W075.00.00.000", "fixes", "BAR")
+	set("N040.00.00.000,W074.00.00.000", "fixes", "BAZ")
+	set("N041.00.00.000,W075.00.00.000", "airspace", "boundaries", "A", 1)
+	set("N042.00.00.000,W075.00.00.000", "airspace", "boundaries", "A", 5) // out of range; ignored
+	set(float64(3000), "airspace", "volumes", "V", 0, "upper")
+	set(jsonDeleted, "arrival_groups", "X", 0)
+	set(jsonDeleted, "name")
+	check(`{"fixes":{"FOO":"N039.52.00.000,W075.14.00.000","BAR":"N040.00.00.000,W075.00.00.000","BAZ":"N040.00.00.000,W074.00.00.000"},` +
+		`"airspace":{"boundaries":{"A":["N040.00.00.000,W075.00.00.000","N041.00.00.000,W075.00.00.000"]},"volumes":{"V":[{"upper":3000}]}},` +
+		`"arrival_groups":{"X":[]}}`)
+
+	if keys := jsonKeys(v, "fixes"); len(keys) != 3 || keys[0] != "FOO" || keys[2] != "BAZ" {
+		t.Errorf("got fix keys %v", keys)
+	}
+}
+
+func TestScenarioEditorRenameFix(t *testing.T) {
+	in := `{"fixes": {"_FOO": "N039.52.00.000,W075.14.00.000", "_BAR": "_FOO@090/5", "OOD": "N039.50.00.000,W075.10.00.000"},
+"reporting_points": ["_FOO", "OOD"],
+"stars_config": {"center": "_FOO", "airspace_awareness": [{"fixes": ["ALL", "_FOO"]}]},
+"arrival_groups": {"X": [{"waypoints": "A _FOO/a50 _FOOD", "runway_waypoints": {"KPHL": {"9R": "_foo B"}}}]},
+"airports": {"KPHL": {"departure_routes": {"9R": {"OOD": {"waypoints": "_RWY9R _FOO/h090 OOD"}}},
+"departures": [{"exit": "OOD", "route": "_FOO OOD J60 PSB"}],
+"approach_regions": {"9R": {"reference_point": "_FOO"}},
+"approaches": {"I9R": {"waypoints": ["C _FOO _RWY9R", "D _RWY9R"]}, "R9R": {"cifp_id": "R9R"}}}},
+"scenarios": {"S": {"center": "_foo"}}}`
+	root := orderedmap.New()
+	if err := json.Unmarshal([]byte(in), root); err != nil {
+		t.Fatal(err)
+	}
+	d := &scenarioDocument{root: *root}
+
+	if err := d.renameFix("_FOO", "_BAR"); err == nil {
+		t.Errorf("expected error renaming to an existing fix")
+	}
+	if err := d.renameFix("OOD", "OODS"); err == nil {
+		t.Errorf("expected error renaming a departure exit")
+	}
+	if d.dirty {
+		t.Errorf("document modified by failed renames")
+	}
+
+	if err := d.renameFix("_FOO", "FOO"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !d.dirty || !d.stale {
+		t.Errorf("document not marked as modified after rename")
+	}
+	b, err := json.Marshal(d.root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"fixes":{"FOO":"N039.52.00.000,W075.14.00.000","_BAR":"FOO@090/5","OOD":"N039.50.00.000,W075.10.00.000"},` +
+		`"reporting_points":["FOO","OOD"],` +
+		`"stars_config":{"center":"FOO","airspace_awareness":[{"fixes":["ALL","FOO"]}]},` +
+		`"arrival_groups":{"X":[{"waypoints":"A FOO/a50 _FOOD","runway_waypoints":{"KPHL":{"9R":"FOO B"}}}]},` +
+		`"airports":{"KPHL":{"departure_routes":{"9R":{"OOD":{"waypoints":"_RWY9R FOO/h090 OOD"}}},` +
+		`"departures":[{"exit":"OOD","route":"FOO OOD J60 PSB"}],` +
+		`"approach_regions":{"9R":{"reference_point":"FOO"}},` +
+		`"approaches":{"I9R":{"waypoints":["C FOO _RWY9R","D _RWY9R"]},"R9R":{"cifp_id":"R9R"}}}},` +
+		`"scenarios":{"S":{"center":"FOO"}}}`
+	if string(b) != expected {
+		t.Errorf("got %s\nexpected %s", string(b), expected)
+	}
+}
+
+func TestScenarioEditorMoveFix(t *testing.T) {
+	saved := database
+	defer func() { database = saved }()
+	database = &StaticDatabase{}
+
+	const nmPerLongitude = 45
+	base := Point2LL{-75.2, 39.9}
+	root := orderedmap.New()
+	in := `{"fixes": {"_FOO": "_BASE@090/5", "_BAR": "_NONE@090/5", "_QUX": "N039.50.00.000,W075.10.00.000"}}`
+	if err := json.Unmarshal([]byte(in), root); err != nil {
+		t.Fatal(err)
+	}
+	d := &scenarioDocument{root: *root, sg: &ScenarioGroup{
+		Fixes:          map[string]Point2LL{"_BASE": base},
+		NmPerLongitude: nmPerLongitude,
+	}}
+
+	// Relative fixes stay relative to their base fix.
+	north := nm2ll(add2f(ll2nm(base, nmPerLongitude), [2]float32{0, 5}), nmPerLongitude)
+	if err := d.moveFix("_FOO", north); err != nil {
+		t.Errorf("unexpected error %v", err)
+	} else if loc := d.getString("fixes", "_FOO"); loc != "_BASE@360/5.0" {
+		t.Errorf("got %q for moved relative fix, expected \"_BASE@360/5.0\"", loc)
+	}
+
+	if err := d.moveFix("_BAR", north); err == nil {
+		t.Errorf("expected error moving fix relative to an unknown fix")
+	} else if loc := d.getString("fixes", "_BAR"); loc != "_NONE@090/5" {
+		t.Errorf("fix relative to an unknown fix was changed to %q", loc)
+	}
+
+	if err := d.moveFix("_QUX", base); err != nil {
+		t.Errorf("unexpected error %v", err)
+	} else if loc := d.getString("fixes", "_QUX"); loc != base.DMSString() {
+		t.Errorf("got %q for moved fix, expected %q", loc, base.DMSString())
+	}
+}
+
+func TestScenarioEditorValidate(t *testing.T) {
+	saved := database
+	defer func() { database = saved }()
+	database = &StaticDatabase{}
+
+	root := orderedmap.New()
+	root.Set("tracon", float64(5))
+	d := &scenarioDocument{root: *root, stale: true}
+
+	// JSON that can't be loaded into a ScenarioGroup.
+	d.validate()
+	if d.stale || d.sg != nil || len(d.errors) == 0 {
+		t.Errorf("expected errors and no ScenarioGroup; got stale %v sg %v errors %v", d.stale, d.sg, d.errors)
+	}
+
+	hasError := func(s string) bool {
+		return slices.ContainsFunc(d.errors, func(e string) bool { return strings.Contains(e, s) })
+	}
+	d.set("PHL", "tracon")
+	d.set("_BAR@090/5", "fixes", "_FOO")
+	if !d.stale {
+		t.Errorf("document not stale after edits")
+	}
+	d.validate()
+	if !hasError(`base fix "_BAR" unknown`) || !hasError("TRACON PHL is unknown") {
+		t.Errorf("expected errors for the fix and TRACON; got %v", d.errors)
+	}
+
+	// Validation is only done after changes.
+	d.errors = nil
+	d.validate()
+	if d.errors != nil {
+		t.Errorf("document revalidated without changes")
+	}
+
+	d.set("N039.52.00.000,W075.14.00.000", "fixes", "_FOO")
+	d.validate()
+	if hasError(`base fix "_BAR" unknown`) {
+		t.Errorf("fix error not cleared after giving the fix a location: %v", d.errors)
+	}
+}
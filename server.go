@@ -18,14 +18,13 @@ import (
 	"os/exec"
 	"runtime"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/shirou/gopsutil/cpu"
 )
 
-const ViceRPCVersion = 12
+const ViceRPCVersion = 16
 
 type SimServer struct {
 	*RPCClient
@@ -243,6 +242,37 @@ func (s *SimProxy) SetTemporaryAltitude(callsign string, alt int) *rpc.Call {
 	}, nil, nil)
 }
 
+func (s *SimProxy) SetSquawk(callsign string, squawk Squawk) *rpc.Call {
+	return s.Client.Go("Sim.SetSquawk", &SquawkArgs{
+		ControllerToken: s.ControllerToken,
+		Callsign:        callsign,
+		Squawk:          squawk,
+	}, nil, nil)
+}
+
+func (s *SimProxy) SetSquawkAutomatic(callsign string) *rpc.Call {
+	return s.Client.Go("Sim.SetSquawkAutomatic", &AircraftSpecifier{
+		ControllerToken: s.ControllerToken,
+		Callsign:        callsign,
+	}, nil, nil)
+}
+
+func (s *SimProxy) CreateFlightPlan(callsign string, fp FlightPlan) *rpc.Call {
+	return s.Client.Go("Sim.CreateFlightPlan", &FlightPlanArgs{
+		ControllerToken: s.ControllerToken,
+		Callsign:        callsign,
+		FlightPlan:      fp,
+	}, nil, nil)
+}
+
+func (s *SimProxy) AmendFlightPlan(callsign string, fp FlightPlan) *rpc.Call {
+	return s.Client.Go("Sim.AmendFlightPlan", &FlightPlanArgs{
+		ControllerToken: s.ControllerToken,
+		Callsign:        callsign,
+		FlightPlan:      fp,
+	}, nil, nil)
+}
+
 func (s *SimProxy) DeleteAircraft(callsign string) *rpc.Call {
 	return s.Client.Go("Sim.DeleteAircraft", &DeleteAircraftArgs{
 		ControllerToken: s.ControllerToken,
@@ -250,11 +280,12 @@ func (s *SimProxy) DeleteAircraft(callsign string) *rpc.Call {
 	}, nil, nil)
 }
 
-func (s *SimProxy) RunAircraftCommands(callsign string, cmds string) *rpc.Call {
+func (s *SimProxy) RunAircraftCommands(callsign string, cmds string, macros []CommandMacro) *rpc.Call {
 	return s.Client.Go("Sim.RunAircraftCommands", &AircraftCommandsArgs{
 		ControllerToken: s.ControllerToken,
 		Callsign:        callsign,
 		Commands:        cmds,
+		Macros:          macros,
 	}, nil, nil)
 }
 
@@ -265,6 +296,52 @@ func (s *SimProxy) LaunchAircraft(ac Aircraft) *rpc.Call {
 	}, nil, nil)
 }
 
+func (s *SimProxy) TogglePseudoPilot(callsign string) *rpc.Call {
+	return s.Client.Go("Sim.TogglePseudoPilot", &AircraftSpecifier{
+		ControllerToken: s.ControllerToken,
+		Callsign:        callsign,
+	}, nil, nil)
+}
+
+func (s *SimProxy) RespondToPilotInstruction(id int, commands, readback string, macros []CommandMacro) *rpc.Call {
+	return s.Client.Go("Sim.RespondToPilotInstruction", &PilotInstructionResponseArgs{
+		ControllerToken: s.ControllerToken,
+		Id:              id,
+		Commands:        commands,
+		Readback:        readback,
+		Macros:          macros,
+	}, nil, nil)
+}
+
+func (s *SimProxy) DeclareEmergency(callsign string) *rpc.Call {
+	return s.Client.Go("Sim.DeclareEmergency", &AircraftSpecifier{
+		ControllerToken: s.ControllerToken,
+		Callsign:        callsign,
+	}, nil, nil)
+}
+
+func (s *SimProxy) ToggleNORDO(callsign string) *rpc.Call {
+	return s.Client.Go("Sim.ToggleNORDO", &AircraftSpecifier{
+		ControllerToken: s.ControllerToken,
+		Callsign:        callsign,
+	}, nil, nil)
+}
+
+func (s *SimProxy) SetWind(wind Wind) *rpc.Call {
+	return s.Client.Go("Sim.SetWind", &SetWindArgs{
+		ControllerToken: s.ControllerToken,
+		Wind:            wind,
+	}, nil, nil)
+}
+
+func (s *SimProxy) SendPrivateMessage(controller, message string) *rpc.Call {
+	return s.Client.Go("Sim.SendPrivateMessage", &PrivateMessageArgs{
+		ControllerToken: s.ControllerToken,
+		Controller:      controller,
+		Message:         message,
+	}, nil, nil)
+}
+
 ///////////////////////////////////////////////////////////////////////////
 // SimManager
 
@@ -333,6 +410,45 @@ func (sm *SimManager) New(config *NewSimConfiguration, result *NewSimResult) err
 	}
 }
 
+// SignOnInstructor signs on to a running sim as its instructor, who
+// doesn't work a position but can control the sim and inject events.
+func (sm *SimManager) SignOnInstructor(config *NewSimConfiguration, result *NewSimResult) error {
+	return sm.signOnRole(config, result, (*Sim).SignOnInstructor)
+}
+
+// SignOnPseudoPilot signs on to a running sim as a pseudo-pilot, who
+// flies aircraft on behalf of the controllers.
+func (sm *SimManager) SignOnPseudoPilot(config *NewSimConfiguration, result *NewSimResult) error {
+	return sm.signOnRole(config, result, (*Sim).SignOnPseudoPilot)
+}
+
+func (sm *SimManager) signOnRole(config *NewSimConfiguration, result *NewSimResult,
+	signOn func(*Sim) (*World, string, error)) error {
+	sm.mu.Lock(sm.lg)
+	defer sm.mu.Unlock(sm.lg)
+
+	sim, ok := sm.activeSims[config.SelectedRemoteSim]
+	if !ok {
+		return ErrNoNamedSim
+	}
+	if sim.RequirePassword && config.RemoteSimPassword != sim.Password {
+		return ErrInvalidPassword
+	}
+
+	world, token, err := signOn(sim)
+	if err != nil {
+		return err
+	}
+
+	sm.controllerTokenToSim[token] = sim
+
+	*result = NewSimResult{
+		World:           world,
+		ControllerToken: token,
+	}
+	return nil
+}
+
 func (sm *SimManager) Add(sim *Sim, result *NewSimResult) error {
 	sim.Activate(sm.lg)
 
@@ -421,6 +537,7 @@ func (sm *SimManager) GetRunningSims(_ int, result *map[string]*RemoteSim) error
 			ScenarioName:       s.Scenario,
 			PrimaryController:  s.World.PrimaryController,
 			RequirePassword:    s.RequirePassword,
+			HaveInstructor:     s.instructorIsSignedIn(),
 			AvailablePositions: make(map[string]struct{}),
 			CoveredPositions:   make(map[string]struct{}),
 		}
@@ -858,6 +975,50 @@ func (sd *SimDispatcher) SetTemporaryAltitude(alt *AssignAltitudeArgs, _ *struct
 	}
 }
 
+type SquawkArgs struct {
+	ControllerToken string
+	Callsign        string
+	Squawk          Squawk
+}
+
+func (sd *SimDispatcher) SetSquawk(sq *SquawkArgs, _ *struct{}) error {
+	if sim, ok := sd.sm.controllerTokenToSim[sq.ControllerToken]; !ok {
+		return ErrNoSimForControllerToken
+	} else {
+		return sim.SetSquawk(sq.ControllerToken, sq.Callsign, sq.Squawk)
+	}
+}
+
+func (sd *SimDispatcher) SetSquawkAutomatic(as *AircraftSpecifier, _ *struct{}) error {
+	if sim, ok := sd.sm.controllerTokenToSim[as.ControllerToken]; !ok {
+		return ErrNoSimForControllerToken
+	} else {
+		return sim.SetSquawkAutomatic(as.ControllerToken, as.Callsign)
+	}
+}
+
+type FlightPlanArgs struct {
+	ControllerToken string
+	Callsign        string
+	FlightPlan      FlightPlan
+}
+
+func (sd *SimDispatcher) CreateFlightPlan(fp *FlightPlanArgs, _ *struct{}) error {
+	if sim, ok := sd.sm.controllerTokenToSim[fp.ControllerToken]; !ok {
+		return ErrNoSimForControllerToken
+	} else {
+		return sim.CreateFlightPlan(fp.ControllerToken, fp.Callsign, fp.FlightPlan)
+	}
+}
+
+func (sd *SimDispatcher) AmendFlightPlan(fp *FlightPlanArgs, _ *struct{}) error {
+	if sim, ok := sd.sm.controllerTokenToSim[fp.ControllerToken]; !ok {
+		return ErrNoSimForControllerToken
+	} else {
+		return sim.AmendFlightPlan(fp.ControllerToken, fp.Callsign, fp.FlightPlan)
+	}
+}
+
 type DeleteAircraftArgs AircraftSpecifier
 
 func (sd *SimDispatcher) DeleteAircraft(da *DeleteAircraftArgs, _ *struct{}) error {
@@ -872,6 +1033,7 @@ type AircraftCommandsArgs struct {
 	ControllerToken string
 	Callsign        string
 	Commands        string
+	Macros          []CommandMacro // the controller's, expanded in Commands here
 }
 
 func (sd *SimDispatcher) RunAircraftCommands(cmds *AircraftCommandsArgs, _ *struct{}) error {
@@ -881,375 +1043,119 @@ func (sd *SimDispatcher) RunAircraftCommands(cmds *AircraftCommandsArgs, _ *stru
 		return ErrNoSimForControllerToken
 	}
 
-	commands := strings.Fields(cmds.Commands)
-
-	for i, command := range commands {
-		switch command[0] {
-		case 'A', 'C':
-			if command == "CAC" {
-				// Cancel approach clearance
-				if err := sim.CancelApproachClearance(token, callsign); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else if command == "CVS" {
-				if err := sim.ClimbViaSID(token, callsign); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else if len(command) > 4 && command[:3] == "CSI" && !isAllNumbers(command[3:]) {
-				// Cleared straight in approach.
-				if err := sim.ClearedApproach(token, callsign, command[3:], true); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else if command[0] == 'C' && len(command) > 2 && !isAllNumbers(command[1:]) {
-				if components := strings.Split(command, "/"); len(components) > 1 {
-					// Cross fix [at altitude] [at speed]
-					fix := components[0][1:]
-					var ar *AltitudeRestriction
-					speed := 0
-
-					for _, cmd := range components[1:] {
-						if len(cmd) == 0 {
-							sim.SetSTARSInput(strings.Join(commands[i:], " "))
-							return ErrInvalidCommandSyntax
-						}
-
-						var err error
-						if cmd[0] == 'A' && len(cmd) > 1 {
-							if ar, err = ParseAltitudeRestriction(cmd[1:]); err != nil {
-								sim.SetSTARSInput(strings.Join(commands[i:], " "))
-								return ErrInvalidCommandSyntax
-							}
-							// User input here is 100s of feet, while AltitudeRestriction is feet...
-							ar.Range[0] *= 100
-							ar.Range[1] *= 100
-						} else if cmd[0] == 'S' {
-							if speed, err = strconv.Atoi(cmd[1:]); err != nil {
-								sim.SetSTARSInput(strings.Join(commands[i:], " "))
-								return err
-							}
-						} else {
-							sim.SetSTARSInput(strings.Join(commands[i:], " "))
-							return ErrInvalidCommandSyntax
-						}
-					}
-
-					if err := sim.CrossFixAt(token, callsign, fix, ar, speed); err != nil {
-						sim.SetSTARSInput(strings.Join(commands[i:], " "))
-						return err
-					}
-				} else if err := sim.ClearedApproach(token, callsign, command[1:], false); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else {
-				if command[0] == 'A' {
-					components := strings.Split(command, "/")
-					if len(components) != 2 || len(components[1]) == 0 || components[1][0] != 'C' {
-						sim.SetSTARSInput(strings.Join(commands[i:], " "))
-						return ErrInvalidCommandSyntax
-					}
-
-					fix := strings.ToUpper(components[0][1:])
-					approach := components[1][1:]
-					if err := sim.AtFixCleared(token, callsign, fix, approach); err != nil {
-						sim.SetSTARSInput(strings.Join(commands[i:], " "))
-						return err
-					} else {
-						continue
-					}
-				}
-
-				// Otherwise look for an altitude
-				if alt, err := strconv.Atoi(command[1:]); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				} else if err := sim.AssignAltitude(token, callsign, 100*alt, false); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			}
+	// Expand macros before anything else sees the commands so that held
+	// instructions and conditional clearances have the actual commands.
+	commands, err := ExpandCommandMacros(cmds.Macros, cmds.Commands)
+	if err != nil {
+		return err
+	}
 
-		case 'D':
-			if command == "DVS" {
-				if err := sim.DescendViaSTAR(token, callsign); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else if components := strings.Split(command, "/"); len(components) > 1 && len(components[1]) > 1 {
-				fix := components[0][1:]
-
-				switch components[1][0] {
-				case 'D':
-					// Depart <fix1> direct <fix2>
-					if err := sim.DepartFixDirect(token, callsign, fix, components[1][1:]); err != nil {
-						sim.SetSTARSInput(strings.Join(commands[i:], " "))
-						return err
-					}
-				case 'H':
-					// Depart <fix> at heading <hdg>
-					if hdg, err := strconv.Atoi(components[1][1:]); err != nil {
-						sim.SetSTARSInput(strings.Join(commands[i:], " "))
-						return err
-					} else if err := sim.DepartFixHeading(token, callsign, fix, hdg); err != nil {
-						sim.SetSTARSInput(strings.Join(commands[i:], " "))
-						return err
-					}
-
-				default:
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return ErrInvalidCommandSyntax
-				}
-			} else if len(command) > 1 && command[1] >= '0' && command[1] <= '9' {
-				// Looks like an altitude.
-				if alt, err := strconv.Atoi(command[1:]); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				} else if err := sim.AssignAltitude(token, callsign, 100*alt, false); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else if _, ok := sim.World.Locate(string(command[1:])); ok {
-				if err := sim.DirectFix(token, callsign, command[1:]); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else {
-				sim.SetSTARSInput(strings.Join(commands[i:], " "))
-				return ErrInvalidCommandSyntax
-			}
+	if held, err := sim.HoldForPseudoPilot(token, callsign, commands); held || err != nil {
+		return err
+	}
 
-		case 'E':
-			if command == "ED" {
-				if err := sim.ExpediteDescent(token, callsign); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else if command == "EC" {
-				if err := sim.ExpediteClimb(token, callsign); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else if len(command) > 1 {
-				// Expect approach.
-				if err := sim.ExpectApproach(token, callsign, command[1:]); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else {
-				sim.SetSTARSInput(strings.Join(commands[i:], " "))
-				return ErrInvalidCommandSyntax
-			}
+	return runAircraftCommands(sim, token, callsign, commands)
+}
 
-		case 'H':
-			if len(command) == 1 {
-				if err := sim.AssignHeading(&HeadingArgs{
-					ControllerToken: token,
-					Callsign:        callsign,
-					Present:         true,
-				}); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else if hdg, err := strconv.Atoi(command[1:]); err != nil {
-				sim.SetSTARSInput(strings.Join(commands[i:], " "))
-				return err
-			} else if err := sim.AssignHeading(&HeadingArgs{
-				ControllerToken: token,
-				Callsign:        callsign,
-				Heading:         hdg,
-				Turn:            TurnClosest,
-			}); err != nil {
-				sim.SetSTARSInput(strings.Join(commands[i:], " "))
-				return err
-			}
+type LaunchAircraftArgs struct {
+	ControllerToken string
+	Aircraft        Aircraft
+}
 
-		case 'I':
-			if len(command) == 1 {
-				if err := sim.InterceptLocalizer(token, callsign); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else if command == "ID" {
-				if err := sim.Ident(token, callsign); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else {
-				sim.SetSTARSInput(strings.Join(commands[i:], " "))
-				return ErrInvalidCommandSyntax
-			}
+func (sd *SimDispatcher) LaunchAircraft(ls *LaunchAircraftArgs, _ *struct{}) error {
+	sim, ok := sd.sm.controllerTokenToSim[ls.ControllerToken]
+	if !ok {
+		return ErrNoSimForControllerToken
+	}
+	return sim.LaunchAircraft(ls.Aircraft)
+}
 
-		case 'L':
-			if l := len(command); l > 2 && command[l-1] == 'D' {
-				// turn left x degrees
-				if deg, err := strconv.Atoi(command[1 : l-1]); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				} else if err := sim.AssignHeading(&HeadingArgs{
-					ControllerToken: token,
-					Callsign:        callsign,
-					LeftDegrees:     deg,
-				}); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else {
-				// turn left heading...
-				if hdg, err := strconv.Atoi(command[1:]); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				} else if err := sim.AssignHeading(&HeadingArgs{
-					ControllerToken: token,
-					Callsign:        callsign,
-					Heading:         hdg,
-					Turn:            TurnLeft,
-				}); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			}
+func (sd *SimDispatcher) DeclareEmergency(a *AircraftSpecifier, _ *struct{}) error {
+	if sim, ok := sd.sm.controllerTokenToSim[a.ControllerToken]; !ok {
+		return ErrNoSimForControllerToken
+	} else {
+		return sim.DeclareEmergency(a.ControllerToken, a.Callsign)
+	}
+}
 
-		case 'R':
-			if l := len(command); l > 2 && command[l-1] == 'D' {
-				// turn right x degrees
-				if deg, err := strconv.Atoi(command[1 : l-1]); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				} else if err := sim.AssignHeading(&HeadingArgs{
-					ControllerToken: token,
-					Callsign:        callsign,
-					RightDegrees:    deg,
-				}); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else {
-				// turn right heading...
-				if hdg, err := strconv.Atoi(command[1:]); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				} else if err := sim.AssignHeading(&HeadingArgs{
-					ControllerToken: token,
-					Callsign:        callsign,
-					Heading:         hdg,
-					Turn:            TurnRight,
-				}); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			}
+func (sd *SimDispatcher) ToggleNORDO(a *AircraftSpecifier, _ *struct{}) error {
+	if sim, ok := sd.sm.controllerTokenToSim[a.ControllerToken]; !ok {
+		return ErrNoSimForControllerToken
+	} else {
+		return sim.ToggleNORDO(a.ControllerToken, a.Callsign)
+	}
+}
 
-		case 'S':
-			if len(command) == 1 {
-				// Cancel speed restrictions
-				if err := sim.AssignSpeed(token, callsign, 0, false); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else if command == "SMIN" {
-				if err := sim.MaintainSlowestPractical(token, callsign); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else if command == "SMAX" {
-				if err := sim.MaintainMaximumForward(token, callsign); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else {
-				if kts, err := strconv.Atoi(command[1:]); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				} else if err := sim.AssignSpeed(token, callsign, kts, false); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			}
+type SetWindArgs struct {
+	ControllerToken string
+	Wind            Wind
+}
 
-		case 'T':
-			if command == "TO" {
-				if err := sim.ContactTower(token, callsign); err != nil {
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return err
-				}
-			} else if n := len(command); n > 2 {
-				if deg, err := strconv.Atoi(command[1 : n-1]); err == nil {
-					if command[n-1] == 'L' {
-						// turn x degrees left
-						if err := sim.AssignHeading(&HeadingArgs{
-							ControllerToken: token,
-							Callsign:        callsign,
-							LeftDegrees:     deg,
-						}); err != nil {
-							sim.SetSTARSInput(strings.Join(commands[i:], " "))
-							return err
-						} else {
-							continue
-						}
-					} else if command[n-1] == 'R' {
-						// turn x degrees right
-						if err := sim.AssignHeading(&HeadingArgs{
-							ControllerToken: token,
-							Callsign:        callsign,
-							RightDegrees:    deg,
-						}); err != nil {
-							sim.SetSTARSInput(strings.Join(commands[i:], " "))
-							return err
-						} else {
-							continue
-						}
-					}
-				}
+func (sd *SimDispatcher) SetWind(a *SetWindArgs, _ *struct{}) error {
+	if sim, ok := sd.sm.controllerTokenToSim[a.ControllerToken]; !ok {
+		return ErrNoSimForControllerToken
+	} else {
+		return sim.SetWind(a.ControllerToken, a.Wind)
+	}
+}
 
-				switch command[:2] {
-				case "TS":
-					if kts, err := strconv.Atoi(command[2:]); err != nil {
-						sim.SetSTARSInput(strings.Join(commands[i:], " "))
-						return err
-					} else if err := sim.AssignSpeed(token, callsign, kts, true); err != nil {
-						sim.SetSTARSInput(strings.Join(commands[i:], " "))
-						return err
-					}
-
-				case "TA", "TC", "TD":
-					if alt, err := strconv.Atoi(command[2:]); err != nil {
-						sim.SetSTARSInput(strings.Join(commands[i:], " "))
-						return err
-					} else if err := sim.AssignAltitude(token, callsign, 100*alt, true); err != nil {
-						sim.SetSTARSInput(strings.Join(commands[i:], " "))
-						return err
-					}
-
-				default:
-					sim.SetSTARSInput(strings.Join(commands[i:], " "))
-					return ErrInvalidCommandSyntax
-				}
-			}
+type PrivateMessageArgs struct {
+	ControllerToken string
+	Controller      string
+	Message         string
+}
 
-		default:
-			sim.SetSTARSInput(strings.Join(commands[i:], " "))
-			return ErrInvalidCommandSyntax
-		}
+func (sd *SimDispatcher) SendPrivateMessage(a *PrivateMessageArgs, _ *struct{}) error {
+	if sim, ok := sd.sm.controllerTokenToSim[a.ControllerToken]; !ok {
+		return ErrNoSimForControllerToken
+	} else {
+		return sim.SendPrivateMessage(a.ControllerToken, a.Controller, a.Message)
 	}
+}
 
-	return nil
+func (sd *SimDispatcher) TogglePseudoPilot(a *AircraftSpecifier, _ *struct{}) error {
+	if sim, ok := sd.sm.controllerTokenToSim[a.ControllerToken]; !ok {
+		return ErrNoSimForControllerToken
+	} else {
+		return sim.TogglePseudoPilot(a.ControllerToken, a.Callsign)
+	}
 }
 
-type LaunchAircraftArgs struct {
+type PilotInstructionResponseArgs struct {
 	ControllerToken string
-	Aircraft        Aircraft
+	Id              int
+	Commands        string // possibly not the same as the original ones...
+	Readback        string
+	Macros          []CommandMacro
 }
 
-func (sd *SimDispatcher) LaunchAircraft(ls *LaunchAircraftArgs, _ *struct{}) error {
-	sim, ok := sd.sm.controllerTokenToSim[ls.ControllerToken]
+func (sd *SimDispatcher) RespondToPilotInstruction(a *PilotInstructionResponseArgs, _ *struct{}) error {
+	sim, ok := sd.sm.controllerTokenToSim[a.ControllerToken]
 	if !ok {
 		return ErrNoSimForControllerToken
 	}
-	sim.LaunchAircraft(ls.Aircraft)
-	return nil
+
+	pi, err := sim.TakePilotInstruction(a.ControllerToken, a.Id)
+	if err != nil {
+		return err
+	}
+
+	if a.Readback != "" {
+		sim.PostPilotReadback(pi, a.Readback)
+	}
+	if strings.TrimSpace(a.Commands) == "" {
+		return nil
+	}
+	commands, err := ExpandCommandMacros(a.Macros, a.Commands)
+	if err != nil {
+		return err
+	}
+	// Issue the commands with the controller's token so that they're
+	// checked and executed just as if the aircraft had responded
+	// immediately.
+	sim.beginPilotResponse(pi.Callsign)
+	defer sim.endPilotResponse(pi.Callsign)
+	return runAircraftCommands(sim, pi.controllerToken, pi.Callsign, commands)
 }
 
 func RunSimServer() {
@@ -57,7 +57,8 @@ type LaunchConfig struct {
 	Mode int
 
 	DepartureChallenge float32
-	GoAroundRate       float32
+	// Probability that a pilot has the wrong altimeter setting.
+	AltimeterErrorRate float32
 	// airport -> runway -> category -> rate
 	DepartureRates map[string]map[string]map[string]int
 	// arrival group -> airport -> rate
@@ -70,7 +71,7 @@ type LaunchConfig struct {
 func MakeLaunchConfig(dep []ScenarioGroupDepartureRunway, arr map[string]map[string]int) LaunchConfig {
 	lc := LaunchConfig{
 		DepartureChallenge:          0.25,
-		GoAroundRate:                0.05,
+		AltimeterErrorRate:          0.02,
 		ArrivalGroupRates:           arr,
 		ArrivalPushFrequencyMinutes: 20,
 		ArrivalPushLengthMinutes:    10,
@@ -200,7 +201,7 @@ func (lc *LaunchConfig) DrawArrivalUI() (changed bool) {
 
 	imgui.Text("Arrivals")
 	imgui.Text(fmt.Sprintf("Overall arrival rate: %d / hour", sumRates))
-	changed = imgui.SliderFloatV("Go around probability", &lc.GoAroundRate, 0, 1, "%.02f", 0) || changed
+	changed = imgui.SliderFloatV("Wrong altimeter setting probability", &lc.AltimeterErrorRate, 0, 1, "%.02f", 0) || changed
 
 	changed = imgui.Checkbox("Include random arrival pushes", &lc.ArrivalPushes) || changed
 	uiStartDisable(!lc.ArrivalPushes)
@@ -257,6 +258,10 @@ type NewSimConfiguration struct {
 	Password        string // for create remote only
 	NewSimType      int
 
+	// Have the automation work approach positions that nobody is
+	// signed in to (create remote only).
+	AutomateApproach bool
+
 	LiveWeather               bool
 	SelectedRemoteSim         string
 	SelectedRemoteSimPosition string
@@ -273,6 +278,7 @@ type RemoteSim struct {
 	ScenarioName       string
 	PrimaryController  string
 	RequirePassword    bool
+	HaveInstructor     bool
 	AvailablePositions map[string]struct{}
 	CoveredPositions   map[string]struct{}
 }
@@ -531,6 +537,11 @@ func (c *NewSimConfiguration) DrawUI() bool {
 				imgui.PopStyleColor()
 			}
 
+			if sc := c.Scenario.SplitConfigurations.GetConfiguration(c.Scenario.SelectedSplit); slices.ContainsFunc(
+				SortedMapKeys(sc), func(callsign string) bool { return isAutomatablePosition(sc[callsign]) }) {
+				imgui.Checkbox("Automate unstaffed approach positions", &c.AutomateApproach)
+			}
+
 			imgui.Checkbox("Require Password", &c.RequirePassword)
 			if c.RequirePassword {
 				imgui.InputTextV("Password", &c.Password, 0, nil)
@@ -680,7 +691,8 @@ func (c *NewSimConfiguration) DrawUI() bool {
 		}
 
 		// Handle the case of someone else signing in to the position
-		if _, ok := rs.AvailablePositions[c.SelectedRemoteSimPosition]; c.SelectedRemoteSimPosition != "Observer" && !ok {
+		if _, ok := rs.AvailablePositions[c.SelectedRemoteSimPosition]; c.SelectedRemoteSimPosition != "Observer" &&
+			c.SelectedRemoteSimPosition != InstructorCallsign && c.SelectedRemoteSimPosition != PseudoPilotCallsign && !ok {
 			c.SelectedRemoteSimPosition = SortedMapKeys(rs.AvailablePositions)[0]
 		}
 
@@ -697,6 +709,14 @@ func (c *NewSimConfiguration) DrawUI() bool {
 			if imgui.SelectableV("Observer", "Observer" == c.SelectedRemoteSimPosition, 0, imgui.Vec2{}) {
 				c.SelectedRemoteSimPosition = "Observer"
 			}
+			if !rs.HaveInstructor {
+				if imgui.SelectableV(InstructorCallsign, InstructorCallsign == c.SelectedRemoteSimPosition, 0, imgui.Vec2{}) {
+					c.SelectedRemoteSimPosition = InstructorCallsign
+				}
+			}
+			if imgui.SelectableV(PseudoPilotCallsign, PseudoPilotCallsign == c.SelectedRemoteSimPosition, 0, imgui.Vec2{}) {
+				c.SelectedRemoteSimPosition = PseudoPilotCallsign
+			}
 
 			imgui.EndCombo()
 		}
@@ -761,8 +781,18 @@ func (c *NewSimConfiguration) OkDisabled() bool {
 }
 
 func (c *NewSimConfiguration) Start() error {
+	method := "SimManager.New"
+	if c.NewSimType == NewSimJoinRemote {
+		switch c.SelectedRemoteSimPosition {
+		case InstructorCallsign:
+			method = "SimManager.SignOnInstructor"
+		case PseudoPilotCallsign:
+			method = "SimManager.SignOnPseudoPilot"
+		}
+	}
+
 	var result NewSimResult
-	if err := c.selectedServer.CallWithTimeout("SimManager.New", c, &result); err != nil {
+	if err := c.selectedServer.CallWithTimeout(method, c, &result); err != nil {
 		err = TryDecodeError(err)
 
 		if err == ErrRPCTimeout || err == ErrRPCVersionMismatch || errors.Is(err, rpc.ErrShutdown) {
@@ -822,6 +852,26 @@ type Sim struct {
 	// callsign -> "to" controller
 	PointOuts map[string]map[string]PointOut
 
+	// airport -> tower
+	Towers map[string]*VirtualTower
+
+	// Instructions to aircraft flown by pseudo-pilots that they haven't
+	// responded to yet.
+	PilotInstructions      []PilotInstruction
+	NextPilotInstructionId int
+	// Callsigns of aircraft whose pseudo-pilot has responded to an
+	// instruction that is now being executed; the pseudo-pilot has
+	// already read it back.
+	pseudoPilotResponses map[string]interface{}
+
+	// Conditional clearances whose triggers have fired but that haven't
+	// been issued yet.
+	firedClearances []firedClearance
+
+	// Unstaffed approach positions are worked by the automation.
+	AutomateApproach     bool
+	automationNextAction map[string]time.Time
+
 	TotalDepartures int
 	TotalArrivals   int
 
@@ -853,6 +903,8 @@ type PointOut struct {
 
 type ServerController struct {
 	Callsign            string
+	Instructor          bool
+	PseudoPilot         bool
 	lastUpdateCall      time.Time
 	warnedNoUpdateCalls bool
 	events              *EventsSubscription
@@ -901,6 +953,8 @@ func NewSim(ssc NewSimConfiguration, scenarioGroups map[string]map[string]*Scena
 		Password:        ssc.Password,
 		RequirePassword: ssc.RequirePassword,
 
+		AutomateApproach: ssc.AutomateApproach && *server,
+
 		SimTime:        time.Now(),
 		lastUpdateTime: time.Now(),
 
@@ -988,6 +1042,13 @@ func newWorld(ssc NewSimConfiguration, s *Sim, sg *ScenarioGroup, sc *Scenario)
 	w.SimTime = s.SimTime
 	w.STARSFacilityAdaptation = sg.STARSFacilityAdaptation
 
+	// The world gets its own copies of the scenario group's controllers
+	// so that changes to them don't leak into other sims.
+	addController := func(callsign string, ctrl *Controller) {
+		c := *ctrl
+		w.Controllers[callsign] = &c
+	}
+
 	for _, callsign := range sc.VirtualControllers {
 		// Skip controllers that are in MultiControllers
 		if w.MultiControllers != nil {
@@ -997,12 +1058,22 @@ func newWorld(ssc NewSimConfiguration, s *Sim, sg *ScenarioGroup, sc *Scenario)
 		}
 
 		if ctrl, ok := sg.ControlPositions[callsign]; ok {
-			w.Controllers[callsign] = ctrl
+			addController(callsign, ctrl)
 		} else {
 			s.lg.Errorf("%s: controller not found in ControlPositions??", callsign)
 		}
 	}
 
+	if s.AutomateApproach {
+		// Positions worked by the automation are virtual controllers
+		// until someone signs in to them.
+		for callsign, mc := range w.MultiControllers {
+			if ctrl, ok := sg.ControlPositions[callsign]; ok && isAutomatablePosition(mc) {
+				addController(callsign, ctrl)
+			}
+		}
+	}
+
 	// Make some fake METARs; slightly different for all airports.
 	var alt int
 
@@ -1025,11 +1096,17 @@ func newWorld(ssc NewSimConfiguration, s *Sim, sg *ScenarioGroup, sc *Scenario)
 			wind += "KT"
 		}
 
-		// Just provide the stuff that the STARS display shows
+		// Just provide the stuff that the STARS display shows, plus a
+		// temperature within a few degrees of standard.
+		var temp float32 = 15
+		if ap, ok := database.Airports[icao]; ok {
+			temp = ISATemperature(float32(ap.Elevation))
+		}
 		w.METAR[icao] = &METAR{
 			AirportICAO: icao,
 			Wind:        wind,
 			Altimeter:   fmt.Sprintf("A%d", alt-2+rand.Intn(4)),
+			Temperature: temp - 8 + float32(rand.Intn(17)),
 		}
 	}
 
@@ -1075,6 +1152,7 @@ func newWorld(ssc NewSimConfiguration, s *Sim, sg *ScenarioGroup, sc *Scenario)
 			AirportICAO: icao,
 			Wind:        wind,
 			Altimeter:   "A" + altimiter,
+			Temperature: float32(weather.Temp),
 		}
 	}
 
@@ -1142,22 +1220,29 @@ func (s *Sim) SignOn(callsign string) (*World, string, error) {
 	if err := s.signOn(callsign); err != nil {
 		return nil, "", err
 	}
+	return s.addServerController(ServerController{Callsign: callsign})
+}
 
+// addServerController creates a token for a client that has signed on and
+// returns the World that it should start with. The provided
+// ServerController gives the client's callsign and role.
+func (s *Sim) addServerController(sc ServerController) (*World, string, error) {
 	var buf [16]byte
 	if _, err := crand.Read(buf[:]); err != nil {
 		return nil, "", err
 	}
 	token := base64.StdEncoding.EncodeToString(buf[:])
 
-	s.controllers[token] = &ServerController{
-		Callsign:       callsign,
-		lastUpdateCall: time.Now(),
-		events:         s.eventStream.Subscribe(),
-	}
+	sc.lastUpdateCall = time.Now()
+	sc.events = s.eventStream.Subscribe()
+	s.controllers[token] = &sc
 
 	w := NewWorld()
 	w.Assign(s.World)
-	w.Callsign = callsign
+	w.Callsign = sc.Callsign
+	w.Instructor = sc.Instructor
+	w.PseudoPilot = sc.PseudoPilot
+	w.HaveInstructor = s.instructorIsSignedIn()
 
 	return w, token, nil
 }
@@ -1214,12 +1299,17 @@ func (s *Sim) SignOff(token string) error {
 		ctrl.events.Unsubscribe()
 		delete(s.controllers, token)
 		delete(s.World.Controllers, ctrl.Callsign)
+		s.restoreAutomatedPosition(ctrl.Callsign)
 
 		s.eventStream.Post(Event{
 			Type:    StatusMessageEvent,
 			Message: ctrl.Callsign + " has signed off.",
 		})
 		s.lg.Infof("%s: controller signing off", ctrl.Callsign)
+
+		if ctrl.PseudoPilot && !s.pseudoPilotIsSignedIn() {
+			s.releasePseudoPilotAircraft()
+		}
 	}
 	return nil
 }
@@ -1241,6 +1331,7 @@ func (s *Sim) ChangeControlPosition(token string, callsign string, keepTracks bo
 	ctrl.Callsign = callsign
 
 	delete(s.World.Controllers, oldCallsign)
+	s.restoreAutomatedPosition(oldCallsign)
 
 	s.eventStream.Post(Event{
 		Type:    StatusMessageEvent,
@@ -1262,8 +1353,8 @@ func (s *Sim) TogglePause(token string) error {
 	s.mu.Lock(s.lg)
 	defer s.mu.Unlock(s.lg)
 
-	if _, ok := s.controllers[token]; !ok {
-		return ErrInvalidControllerToken
+	if err := s.checkSimControl(token); err != nil {
+		return err
 	} else {
 		s.Paused = !s.Paused
 		s.lg.Infof("paused: %v", s.Paused)
@@ -1273,6 +1364,16 @@ func (s *Sim) TogglePause(token string) error {
 }
 
 func (s *Sim) PostEvent(e Event) {
+	if e.Type == RadioTransmissionEvent {
+		if ac, ok := s.World.Aircraft[e.Callsign]; ok && ac.NORDO {
+			// Nobody hears from aircraft that have lost their radios.
+			return
+		} else if _, responding := s.pseudoPilotResponses[e.Callsign]; responding &&
+			e.RadioTransmissionType == RadioTransmissionReadback {
+			// The pseudo-pilot provided the readback for the instruction.
+			return
+		}
+	}
 	s.eventStream.Post(e)
 }
 
@@ -1289,6 +1390,12 @@ type SimWorldUpdate struct {
 	Events          []Event
 	TotalDepartures int
 	TotalArrivals   int
+	Wind            Wind
+	METAR           map[string]*METAR
+	HaveInstructor  bool
+
+	// Only sent to pseudo-pilots.
+	PilotInstructions []PilotInstruction
 }
 
 func (wu *SimWorldUpdate) UpdateWorld(w *World, eventStream *EventStream) {
@@ -1305,6 +1412,12 @@ func (wu *SimWorldUpdate) UpdateWorld(w *World, eventStream *EventStream) {
 	w.STARSInputOverride = wu.STARSInput
 	w.TotalDepartures = wu.TotalDepartures
 	w.TotalArrivals = wu.TotalArrivals
+	w.Wind = wu.Wind
+	if wu.METAR != nil {
+		w.METAR = wu.METAR
+	}
+	w.HaveInstructor = wu.HaveInstructor
+	w.PilotInstructions = wu.PilotInstructions
 
 	// Important: do this after updating aircraft, controllers, etc.,
 	// so that they reflect any changes the events are flagging.
@@ -1340,6 +1453,12 @@ func (s *Sim) GetWorldUpdate(token string, update *SimWorldUpdate) error {
 			Events:          ctrl.events.Get(),
 			TotalDepartures: s.TotalDepartures,
 			TotalArrivals:   s.TotalArrivals,
+			Wind:            s.World.Wind,
+			METAR:           s.World.METAR,
+			HaveInstructor:  s.instructorIsSignedIn(),
+		}
+		if ctrl.PseudoPilot {
+			update.PilotInstructions = DuplicateSlice(s.PilotInstructions)
 		}
 
 		return nil
@@ -1377,6 +1496,15 @@ func (s *Sim) SetSTARSInput(input string) {
 	s.STARSInputOverride = input
 }
 
+// lookupAircraft returns the aircraft with the given callsign or nil if
+// there isn't one.
+func (s *Sim) lookupAircraft(callsign string) *Aircraft {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	return s.World.Aircraft[callsign]
+}
+
 ///////////////////////////////////////////////////////////////////////////
 // Simulation
 
@@ -1447,6 +1575,7 @@ func (s *Sim) Update() {
 		s.SimTime = s.SimTime.Add(time.Second)
 		s.updateState()
 	}
+	s.issueFiredClearances()
 	s.updateTimeSlop = elapsed - elapsed.Truncate(time.Second)
 	s.World.SimTime = s.SimTime
 
@@ -1470,18 +1599,7 @@ func (s *Sim) updateState() {
 
 		if ac, ok := s.World.Aircraft[callsign]; ok && ac.HandoffTrackController != "" &&
 			!s.controllerIsSignedIn(ac.HandoffTrackController) {
-			s.eventStream.Post(Event{
-				Type:           AcceptedHandoffEvent,
-				FromController: ac.TrackingController,
-				ToController:   ac.HandoffTrackController,
-				Callsign:       ac.Callsign,
-			})
-			s.lg.Info("automatic handoff accept", slog.String("callsign", ac.Callsign),
-				slog.String("from", ac.TrackingController),
-				slog.String("to", ac.HandoffTrackController))
-
-			ac.TrackingController = ac.HandoffTrackController
-			ac.HandoffTrackController = ""
+			s.respondToHandoff(ac)
 		}
 		delete(s.Handoffs, callsign)
 	}
@@ -1493,18 +1611,7 @@ func (s *Sim) updateState() {
 			}
 
 			if ac, ok := s.World.Aircraft[callsign]; ok && !s.controllerIsSignedIn(toController) {
-				// Note that "to" and "from" are swapped in the event,
-				// since the ack is coming from the "to" controller of the
-				// original point out.
-				s.eventStream.Post(Event{
-					Type:           AcknowledgedPointOutEvent,
-					FromController: toController,
-					ToController:   po.FromController,
-					Callsign:       ac.Callsign,
-				})
-				s.lg.Info("automatic pointout accept", slog.String("callsign", ac.Callsign),
-					slog.String("by", toController), slog.String("to", po.FromController))
-
+				s.respondToPointOut(ac, toController, po)
 				delete(s.PointOuts[callsign], toController)
 			}
 		}
@@ -1513,8 +1620,14 @@ func (s *Sim) updateState() {
 	// Update the simulation state once a second.
 	if now.Sub(s.lastSimUpdate) >= time.Second {
 		s.lastSimUpdate = now
+		var landed []*Aircraft
 		for callsign, ac := range s.World.Aircraft {
 			passedWaypoint := ac.Update(s.World, s, s.lg)
+			s.checkConditionalClearances(ac, passedWaypoint)
+			s.updateTraffic(ac)
+			if passedWaypoint != nil && passedWaypoint.Delete && ac.Nav.Approach.Cleared {
+				landed = append(landed, ac)
+			}
 			if passedWaypoint != nil && passedWaypoint.Handoff {
 				// Handoff from virtual controller to a human controller.
 				ctrl := s.ResolveController(ac.WaypointHandoffController)
@@ -1526,12 +1639,30 @@ func (s *Sim) updateState() {
 					ToController:   ctrl,
 				})
 
-				ac.HandoffTrackController = ctrl
+				if s.isAutomated(ctrl) {
+					// The automation takes the handoff and the aircraft
+					// checks in right away.
+					s.eventStream.Post(Event{
+						Type:           AcceptedHandoffEvent,
+						Callsign:       ac.Callsign,
+						FromController: ac.TrackingController,
+						ToController:   ctrl,
+					})
+					ac.TrackingController = ctrl
+					ac.ControllingController = ctrl
+					PostRadioEvents(ac.Callsign, []RadioTransmission{RadioTransmission{
+						Controller: ctrl,
+						Message:    ac.ContactMessage(s.ReportingPoints),
+						Type:       RadioTransmissionContact,
+					}}, s)
+				} else {
+					ac.HandoffTrackController = ctrl
+				}
 			}
 
 			// Contact the departure controller
 			if ac.IsDeparture() && ac.DepartureContactAltitude != 0 &&
-				ac.Nav.FlightState.Altitude >= ac.DepartureContactAltitude {
+				ac.IndicatedAltitude() >= ac.DepartureContactAltitude {
 				// Time to check in
 				ctrl := s.ResolveController(ac.DepartureContactController)
 				lg.Info("contacting departure controller", slog.String("callsign", ctrl))
@@ -1574,6 +1705,11 @@ func (s *Sim) updateState() {
 				delete(s.World.Aircraft, callsign)
 			}
 		}
+
+		s.updateTowers(landed)
+		s.initiatePointOuts()
+		s.runApproachAutomation()
+		s.prunePilotInstructions()
 	}
 
 	// Don't spawn automatically if someone is spawning manually.
@@ -1593,7 +1729,7 @@ func (s *Sim) ResolveController(callsign string) string {
 	} else {
 		c := s.World.MultiControllers.ResolveController(callsign,
 			func(callsign string) bool {
-				return s.controllerIsSignedIn(callsign)
+				return s.controllerIsSignedIn(callsign) || s.isAutomated(callsign)
 			})
 		if c == "" { // This shouldn't happen...
 			return s.World.PrimaryController
@@ -1747,11 +1883,12 @@ func (s *Sim) spawnAircraft() {
 		if now.After(s.NextArrivalSpawn[group]) {
 			arrivalAirport, rateSum := sampleRateMap(airportRates)
 
-			goAround := rand.Float32() < s.LaunchConfig.GoAroundRate
-			if ac, err := s.World.CreateArrival(group, arrivalAirport, goAround); err != nil {
+			if ac, err := s.World.CreateArrival(group, arrivalAirport); err != nil {
 				s.lg.Error("CreateArrival error: %v", err)
 			} else if ac != nil {
-				s.launchAircraftNoLock(*ac)
+				if err := s.launchAircraftNoLock(*ac); err != nil {
+					s.lg.Errorf("%s: unable to launch arrival: %v", ac.Callsign, err)
+				}
 				s.NextArrivalSpawn[group] = now.Add(randomWait(rateSum, pushActive))
 			}
 		}
@@ -1775,10 +1912,16 @@ func (s *Sim) spawnAircraft() {
 			s.LaunchConfig.DepartureChallenge, prevDep)
 		if err != nil {
 			s.lg.Errorf("CreateDeparture error: %v", err)
+		} else if !s.virtualTower(airport).ReadyForDeparture(s.World, ac, now) {
+			// Drop it and try again next time around; nothing has been
+			// allocated for it until it's launched.
+			continue
 		} else {
 			s.lastDeparture[airport][runway][category] = dep
 			s.lg.Infof("%s/%s/%s: launch departure", airport, runway, category)
-			s.launchAircraftNoLock(*ac)
+			if err := s.launchAircraftNoLock(*ac); err != nil {
+				s.lg.Errorf("%s: unable to launch departure: %v", ac.Callsign, err)
+			}
 			s.NextDepartureSpawn[airport] = now.Add(randomWait(rateSum, false))
 		}
 	}
@@ -1791,8 +1934,8 @@ func (s *Sim) SetSimRate(token string, rate float32) error {
 	s.mu.Lock(s.lg)
 	defer s.mu.Unlock(s.lg)
 
-	if _, ok := s.controllers[token]; !ok {
-		return ErrInvalidControllerToken
+	if err := s.checkSimControl(token); err != nil {
+		return err
 	} else {
 		s.SimRate = rate
 		s.lg.Infof("sim rate set to %f", s.SimRate)
@@ -1869,18 +2012,32 @@ func (s *Sim) TakeOrReturnLaunchControl(token string) error {
 	}
 }
 
-func (s *Sim) LaunchAircraft(ac Aircraft) {
+func (s *Sim) LaunchAircraft(ac Aircraft) error {
 	s.mu.Lock(s.lg)
 	defer s.mu.Unlock(s.lg)
 
-	s.launchAircraftNoLock(ac)
+	return s.launchAircraftNoLock(ac)
 }
 
 // Assumes the lock is already held (as is the case e.g. for automatic spawning...)
-func (s *Sim) launchAircraftNoLock(ac Aircraft) {
+func (s *Sim) launchAircraftNoLock(ac Aircraft) error {
 	if _, ok := s.World.Aircraft[ac.Callsign]; ok {
 		s.lg.Warn("already have an aircraft with that callsign!", slog.String("callsign", ac.Callsign))
-		return
+		return ErrDuplicateCallsign
+	}
+
+	// Aircraft created by the launch control UI were given a code when
+	// they were created but may have waited a while to be launched;
+	// pick a new one if another aircraft has been assigned it since.
+	if s.World.squawkInUse(ac.AssignedSquawk, nil) != nil {
+		sq, err := s.World.AllocateSquawk(beaconCodeCategory(&ac))
+		if err != nil {
+			return err
+		}
+		if ac.Squawk == ac.AssignedSquawk {
+			ac.Squawk = sq
+		}
+		ac.AssignedSquawk = sq
 	}
 
 	s.World.Aircraft[ac.Callsign] = &ac
@@ -1894,6 +2051,7 @@ func (s *Sim) launchAircraftNoLock(ac Aircraft) {
 		s.TotalArrivals++
 		s.lg.Info("launched arrival", slog.String("callsign", ac.Callsign), slog.Any("aircraft", ac))
 	}
+	return nil
 }
 
 func (s *Sim) dispatchCommand(token string, callsign string,
@@ -1904,7 +2062,7 @@ func (s *Sim) dispatchCommand(token string, callsign string,
 	} else if ac, ok := s.World.Aircraft[callsign]; !ok {
 		return ErrNoAircraftForCallsign
 	} else {
-		if sc.Callsign == "Observer" {
+		if sc.Callsign == "Observer" || sc.Instructor || sc.PseudoPilot {
 			return ErrOtherControllerHasTrack
 		}
 
@@ -1940,7 +2098,13 @@ func (s *Sim) dispatchControllingCommand(token string, callsign string,
 			}
 			return nil
 		},
-		cmd)
+		func(ctrl *Controller, ac *Aircraft) []RadioTransmission {
+			if ac.NORDO {
+				// The pilot never hears the instruction.
+				return nil
+			}
+			return cmd(ctrl, ac)
+		})
 }
 
 // Commands that are allowed by tracking controller only.
@@ -2104,8 +2268,7 @@ func (s *Sim) HandoffTrack(token, callsign, controller string) error {
 			// Add them to the auto-accept map even if the target is
 			// covered; this way, if they sign off in the interim, we still
 			// end up accepting it automatically.
-			acceptDelay := 4 + rand.Intn(10)
-			s.Handoffs[ac.Callsign] = s.SimTime.Add(time.Duration(acceptDelay) * time.Second)
+			s.Handoffs[ac.Callsign] = s.handoffAcceptTime(octrl.Callsign)
 			return nil
 		})
 }
@@ -2163,6 +2326,9 @@ func (s *Sim) HandoffControl(token, callsign string) error {
 					slog.Int("final_altitude", ac.FlightPlan.Altitude))
 				ac.DepartOnCourse()
 			}
+			if octrl != nil && !octrl.IsHuman && octrl.Behavior != nil {
+				radioTransmissions = append(radioTransmissions, octrl.Behavior.Restrict(ac)...)
+			}
 
 			return radioTransmissions
 		})
@@ -2301,28 +2467,31 @@ func (s *Sim) PointOut(token, callsign, controller string) error {
 			return nil
 		},
 		func(ctrl *Controller, ac *Aircraft) []RadioTransmission {
-			octrl := s.World.GetController(controller)
-			s.eventStream.Post(Event{
-				Type:           PointOutEvent,
-				FromController: ctrl.Callsign,
-				ToController:   octrl.Callsign,
-				Callsign:       ac.Callsign,
-			})
-
-			// As with handoffs, always add it to the auto-accept list for now.
-			acceptDelay := 4 + rand.Intn(10)
-			if s.PointOuts[ac.Callsign] == nil {
-				s.PointOuts[ac.Callsign] = make(map[string]PointOut)
-			}
-			s.PointOuts[ac.Callsign][octrl.Callsign] = PointOut{
-				FromController: ctrl.Callsign,
-				AcceptTime:     s.SimTime.Add(time.Duration(acceptDelay) * time.Second),
-			}
-
+			s.pointOut(ac, ctrl.Callsign, s.World.GetController(controller).Callsign)
 			return nil
 		})
 }
 
+// pointOut points the aircraft out from one controller to another; virtual
+// controllers respond to it after a delay.
+func (s *Sim) pointOut(ac *Aircraft, from, to string) {
+	s.eventStream.Post(Event{
+		Type:           PointOutEvent,
+		FromController: from,
+		ToController:   to,
+		Callsign:       ac.Callsign,
+	})
+
+	// As with handoffs, always add it to the auto-accept list for now.
+	if s.PointOuts[ac.Callsign] == nil {
+		s.PointOuts[ac.Callsign] = make(map[string]PointOut)
+	}
+	s.PointOuts[ac.Callsign][to] = PointOut{
+		FromController: from,
+		AcceptTime:     s.pointOutAcceptTime(to),
+	}
+}
+
 func (s *Sim) AcknowledgePointOut(token, callsign string) error {
 	return s.dispatchCommand(token, callsign,
 		func(ctrl *Controller, ac *Aircraft) error {
@@ -2400,6 +2569,46 @@ func (s *Sim) AssignAltitude(token, callsign string, altitude int, afterSpeed bo
 		})
 }
 
+func (s *Sim) AssignAltimeter(token, callsign string, altimeter float32) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	return s.dispatchControllingCommand(token, callsign,
+		func(ctrl *Controller, ac *Aircraft) []RadioTransmission {
+			return ac.AssignAltimeter(altimeter)
+		})
+}
+
+func (s *Sim) SayAltitude(token, callsign string) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	return s.dispatchControllingCommand(token, callsign,
+		func(ctrl *Controller, ac *Aircraft) []RadioTransmission {
+			return ac.SayAltitude()
+		})
+}
+
+func (s *Sim) SayHeading(token, callsign string) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	return s.dispatchControllingCommand(token, callsign,
+		func(ctrl *Controller, ac *Aircraft) []RadioTransmission {
+			return ac.SayHeading()
+		})
+}
+
+func (s *Sim) SaySpeed(token, callsign string) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	return s.dispatchControllingCommand(token, callsign,
+		func(ctrl *Controller, ac *Aircraft) []RadioTransmission {
+			return ac.SaySpeed()
+		})
+}
+
 func (s *Sim) SetTemporaryAltitude(token, callsign string, altitude int) error {
 	s.mu.Lock(s.lg)
 	defer s.mu.Unlock(s.lg)
@@ -0,0 +1,328 @@
+// speech.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/mmp/imgui-go/v4"
+)
+
+// Pilot radio transmissions can be spoken as well as shown in the
+// MessagesPane. The text is first converted to spoken phraseology (digits
+// spoken individually, altitudes in thousands, and so forth) and then
+// passed to a SpeechSynthesizer; the resulting audio is played via the
+// AudioEngine.
+
+// SpeechSynthesizer is the interface to text to speech backends.
+// Synthesize returns 16-bit signed mono PCM at AudioSampleRate; it may
+// return no samples if the backend doesn't produce audio.
+type SpeechSynthesizer interface {
+	Synthesize(text string) ([]byte, error)
+}
+
+// NullSynthesizer discards everything it's given.
+type NullSynthesizer struct{}
+
+func (NullSynthesizer) Synthesize(text string) ([]byte, error) {
+	return nil, nil
+}
+
+// TranscriptSynthesizer doesn't produce audio but appends each utterance
+// to a text file; it's useful for testing and when running headless.
+type TranscriptSynthesizer struct {
+	Filename string
+	mu       sync.Mutex
+}
+
+func (t *TranscriptSynthesizer) Synthesize(text string) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f, err := os.OpenFile(t.Filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	_, err = fmt.Fprintln(f, text)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return nil, err
+}
+
+// CommandSynthesizer runs an external program (e.g., "espeak-ng --stdin
+// --stdout") with the text on its standard input; the program should
+// write a WAV file to its standard output.
+type CommandSynthesizer struct {
+	Command string
+}
+
+func (c *CommandSynthesizer) Synthesize(text string) ([]byte, error) {
+	args := strings.Fields(c.Command)
+	if len(args) == 0 {
+		return nil, ErrNoSpeechCommand
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	wav, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", args[0], err, stderr.String())
+	}
+	return decodeWAV(wav)
+}
+
+// decodeWAV converts 16-bit PCM WAV data to mono at AudioSampleRate.
+func decodeWAV(wav []byte) ([]byte, error) {
+	if len(wav) < 12 || string(wav[:4]) != "RIFF" || string(wav[8:12]) != "WAVE" {
+		return nil, ErrInvalidWAV
+	}
+
+	var channels, bitsPerSample int
+	var sampleRate int
+	var data []byte
+	for chunk := wav[12:]; len(chunk) >= 8; {
+		id, size := string(chunk[:4]), int(binary.LittleEndian.Uint32(chunk[4:8]))
+		chunk = chunk[8:]
+		if size > len(chunk) || size < 0 {
+			// Streaming writers may not know the size of the data
+			// chunk; take whatever is there.
+			size = len(chunk)
+		}
+
+		switch id {
+		case "fmt ":
+			if size < 16 || binary.LittleEndian.Uint16(chunk[0:2]) != 1 /* PCM */ {
+				return nil, ErrInvalidWAV
+			}
+			channels = int(binary.LittleEndian.Uint16(chunk[2:4]))
+			sampleRate = int(binary.LittleEndian.Uint32(chunk[4:8]))
+			bitsPerSample = int(binary.LittleEndian.Uint16(chunk[14:16]))
+		case "data":
+			data = chunk[:size]
+		}
+
+		chunk = chunk[min(size+size%2, len(chunk)):]
+	}
+	if channels == 0 || sampleRate == 0 || bitsPerSample != 16 || data == nil {
+		return nil, ErrInvalidWAV
+	}
+
+	// Mix down to mono.
+	n := len(data) / (2 * channels)
+	mono := make([]float32, n)
+	for i := range mono {
+		for c := 0; c < channels; c++ {
+			offset := 2 * (i*channels + c)
+			mono[i] += float32(int16(binary.LittleEndian.Uint16(data[offset:])))
+		}
+		mono[i] /= float32(channels)
+	}
+
+	// Resample with linear interpolation.
+	nout := int(int64(n) * AudioSampleRate / int64(sampleRate))
+	pcm := make([]byte, 2*nout)
+	for i := 0; i < nout; i++ {
+		t := float32(i) * float32(sampleRate) / AudioSampleRate
+		i0 := min(int(t), n-1)
+		i1 := min(i0+1, n-1)
+		v := lerp(t-float32(i0), mono[i0], mono[i1])
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(int16(clamp(v, -32768, 32767))))
+	}
+	return pcm, nil
+}
+
+///////////////////////////////////////////////////////////////////////////
+// Phraseology
+
+var spokenDigits = [10]string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "niner"}
+
+var spokenLetters = [26]string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel",
+	"india", "juliet", "kilo", "lima", "mike", "november", "oscar", "papa", "quebec", "romeo", "sierra",
+	"tango", "uniform", "victor", "whiskey", "x-ray", "yankee", "zulu"}
+
+var (
+	reThousands   = regexp.MustCompile(`^([0-9]{1,2}),([0-9])00$`)
+	reRunway      = regexp.MustCompile(`^([0-9]{1,2})([LRC])$`)
+	reFlightLevel = regexp.MustCompile(`^FL([0-9]{2,3})$`)
+	reNumber      = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+)
+
+func speakDigits(s string) string {
+	var words []string
+	for _, ch := range s {
+		if ch >= '0' && ch <= '9' {
+			words = append(words, spokenDigits[ch-'0'])
+		} else if ch == '.' {
+			words = append(words, "point")
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// spellOut returns the given identifier (e.g., a general aviation
+// callsign) with letters given phonetically and digits spoken
+// individually.
+func spellOut(s string) string {
+	var words []string
+	for _, ch := range strings.ToUpper(s) {
+		if ch >= '0' && ch <= '9' {
+			words = append(words, spokenDigits[ch-'0'])
+		} else if ch >= 'A' && ch <= 'Z' {
+			words = append(words, spokenLetters[ch-'A'])
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// SpokenPhraseology converts the text of a radio transmission to the
+// words that would be spoken: "climb and maintain 12,000" becomes "climb
+// and maintain one two thousand", "runway 22L" becomes "runway two two
+// left", and so forth.
+func SpokenPhraseology(text string) string {
+	var words []string
+	for _, word := range strings.Fields(text) {
+		// Keep trailing punctuation so that the synthesizer pauses.
+		punct := ""
+		if n := len(word); n > 1 && strings.ContainsAny(word[n-1:], ",.") {
+			word, punct = word[:n-1], word[n-1:]
+		}
+
+		if m := reThousands.FindStringSubmatch(word); m != nil {
+			word = speakDigits(m[1]) + " thousand"
+			if m[2] != "0" {
+				word += " " + spokenDigits[m[2][0]-'0'] + " hundred"
+			}
+		} else if m := reRunway.FindStringSubmatch(word); m != nil {
+			word = speakDigits(m[1]) + " " + map[string]string{"L": "left", "R": "right", "C": "center"}[m[2]]
+		} else if m := reFlightLevel.FindStringSubmatch(word); m != nil {
+			word = "flight level " + speakDigits(m[1])
+		} else if reNumber.MatchString(word) {
+			word = speakDigits(word)
+		} else if strings.IndexFunc(word, unicode.IsDigit) != -1 &&
+			strings.IndexFunc(word, unicode.IsLetter) != -1 && word == strings.ToUpper(word) {
+			// Mixed letters and digits: a callsign or some such.
+			word = spellOut(word)
+		}
+
+		words = append(words, word+punct)
+	}
+	return strings.Join(words, " ")
+}
+
+///////////////////////////////////////////////////////////////////////////
+// SpeechEngine
+
+type SpeechBackend int
+
+const (
+	SpeechBackendNone = iota
+	SpeechBackendCommand
+	SpeechBackendTranscript
+)
+
+func (b SpeechBackend) String() string {
+	return [...]string{"None", "External command", "Transcript file"}[b]
+}
+
+type SpeechEngine struct {
+	Enabled        bool
+	Backend        SpeechBackend
+	Command        string
+	TranscriptFile string
+
+	mu          sync.Mutex
+	synthesizer SpeechSynthesizer
+	utterances  chan string
+}
+
+func (s *SpeechEngine) makeSynthesizer() SpeechSynthesizer {
+	switch s.Backend {
+	case SpeechBackendCommand:
+		return &CommandSynthesizer{Command: s.Command}
+	case SpeechBackendTranscript:
+		return &TranscriptSynthesizer{Filename: s.TranscriptFile}
+	default:
+		return NullSynthesizer{}
+	}
+}
+
+// Activate starts the goroutine that synthesizes speech; synthesis may
+// be slow, so it's kept off of the main thread.
+func (s *SpeechEngine) Activate(audio *AudioEngine) {
+	s.mu.Lock()
+	s.synthesizer = s.makeSynthesizer()
+	s.mu.Unlock()
+
+	s.utterances = make(chan string, 16)
+	go func() {
+		for text := range s.utterances {
+			s.mu.Lock()
+			synth := s.synthesizer
+			s.mu.Unlock()
+
+			if pcm, err := synth.Synthesize(SpokenPhraseology(text)); err != nil {
+				lg.Errorf("speech synthesis: %v", err)
+			} else if len(pcm) > 0 {
+				audio.PlaySpeech(pcm)
+			}
+		}
+	}()
+}
+
+// Speak queues the given radio transmission to be spoken.
+func (s *SpeechEngine) Speak(text string) {
+	if !s.Enabled || s.utterances == nil {
+		return
+	}
+
+	select {
+	case s.utterances <- text:
+	default:
+		lg.Warnf("speech: dropping \"%s\"; queue is full", text)
+	}
+}
+
+func (s *SpeechEngine) DrawUI() {
+	changed := imgui.Checkbox("Speak pilot transmissions", &s.Enabled)
+
+	uiStartDisable(!s.Enabled)
+	if imgui.BeginComboV("Speech backend", s.Backend.String(), 0) {
+		for _, b := range []SpeechBackend{SpeechBackendNone, SpeechBackendCommand, SpeechBackendTranscript} {
+			if imgui.SelectableV(b.String(), b == s.Backend, 0, imgui.Vec2{}) {
+				s.Backend = b
+				changed = true
+			}
+		}
+		imgui.EndCombo()
+	}
+	switch s.Backend {
+	case SpeechBackendCommand:
+		changed = imgui.InputTextV("Command", &s.Command, 0, nil) || changed
+		if imgui.IsItemHovered() {
+			imgui.SetTooltip("Program that reads text from standard input and writes a WAV file\n" +
+				"to standard output, e.g. \"espeak-ng --stdin --stdout\"")
+		}
+	case SpeechBackendTranscript:
+		changed = imgui.InputTextV("Transcript file", &s.TranscriptFile, 0, nil) || changed
+	}
+	uiEndDisable(!s.Enabled)
+
+	if changed {
+		s.mu.Lock()
+		s.synthesizer = s.makeSynthesizer()
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,91 @@
+// speech_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpokenPhraseology(t *testing.T) {
+	for _, test := range [][2]string{
+		[2]string{"climb and maintain 12,000", "climb and maintain one two thousand"},
+		[2]string{"descend and maintain 3,500.", "descend and maintain three thousand five hundred."},
+		[2]string{"maintain FL230", "maintain flight level two three zero"},
+		[2]string{"fly heading 090, reduce speed to 210", "fly heading zero niner zero, reduce speed to two one zero"},
+		[2]string{"cleared ILS runway 22L approach", "cleared ILS runway two two left approach"},
+		[2]string{"Kennedy approach, N123AB, with you", "Kennedy approach, november one two three alpha bravo, with you"},
+		[2]string{"altimeter 29.92", "altimeter two niner point niner two"},
+	} {
+		if s := SpokenPhraseology(test[0]); s != test[1] {
+			t.Errorf("SpokenPhraseology(%q) = %q; expected %q", test[0], s, test[1])
+		}
+	}
+}
+
+func TestTranscriptSynthesizer(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "transcript.txt")
+	ts := &TranscriptSynthesizer{Filename: fn}
+
+	for _, s := range []string{"one", "two"} {
+		if pcm, err := ts.Synthesize(s); err != nil {
+			t.Fatalf("Synthesize: %v", err)
+		} else if len(pcm) != 0 {
+			t.Errorf("Synthesize: expected no audio, got %d bytes", len(pcm))
+		}
+	}
+
+	if b, err := os.ReadFile(fn); err != nil {
+		t.Fatalf("%v", err)
+	} else if string(b) != "one\ntwo\n" {
+		t.Errorf("transcript %q; expected %q", string(b), "one\ntwo\n")
+	}
+}
+
+func TestDecodeWAV(t *testing.T) {
+	// Two channels at twice the output rate, so the result should have a
+	// quarter as many bytes.
+	const n = 100
+	var data []byte
+	for i := 0; i < n; i++ {
+		data = binary.LittleEndian.AppendUint16(data, uint16(1000))
+		data = binary.LittleEndian.AppendUint16(data, uint16(3000))
+	}
+
+	var wav []byte
+	wav = append(wav, "RIFF"...)
+	wav = binary.LittleEndian.AppendUint32(wav, uint32(36+len(data)))
+	wav = append(wav, "WAVEfmt "...)
+	wav = binary.LittleEndian.AppendUint32(wav, 16)
+	wav = binary.LittleEndian.AppendUint16(wav, 1) // PCM
+	wav = binary.LittleEndian.AppendUint16(wav, 2) // channels
+	wav = binary.LittleEndian.AppendUint32(wav, 2*AudioSampleRate)
+	wav = binary.LittleEndian.AppendUint32(wav, 2*AudioSampleRate*4)
+	wav = binary.LittleEndian.AppendUint16(wav, 4)
+	wav = binary.LittleEndian.AppendUint16(wav, 16)
+	wav = append(wav, "data"...)
+	wav = binary.LittleEndian.AppendUint32(wav, uint32(len(data)))
+	wav = append(wav, data...)
+
+	pcm, err := decodeWAV(wav)
+	if err != nil {
+		t.Fatalf("decodeWAV: %v", err)
+	}
+	if len(pcm) != n {
+		t.Errorf("decodeWAV: got %d bytes, expected %d", len(pcm), n)
+	}
+	for i := 0; i < len(pcm); i += 2 {
+		if v := int16(binary.LittleEndian.Uint16(pcm[i:])); v != 2000 {
+			t.Errorf("decodeWAV: sample %d = %d, expected 2000", i/2, v)
+			break
+		}
+	}
+
+	if _, err := decodeWAV([]byte("not a wav file")); err != ErrInvalidWAV {
+		t.Errorf("decodeWAV: expected ErrInvalidWAV for invalid input, got %v", err)
+	}
+}
@@ -27,6 +27,11 @@ import (
 const LateralMinimum = 3
 const VerticalMinimum = 1000
 
+// Mode C intruder alert thresholds; they're tighter than the IFR minima
+// since VFR aircraft may pass 500' from IFR traffic.
+const ModeCIntruderLateralMinimum = 1.5
+const ModeCIntruderVerticalMinimum = 500
+
 // STARS ∆ is U+008A in the FixedDemiBold font we use...
 const STARSTriangleCharacter = "\u008A"
 
@@ -107,7 +112,8 @@ type STARSPane struct {
 	RangeBearingLines []STARSRangeBearingLine
 	MinSepAircraft    [2]string
 
-	CAAircraft []CAAircraft
+	CAAircraft  []CAAircraft
+	MCIAircraft []MCIAircraft
 
 	// For CRDA
 	ConvergingRunways []STARSConvergingRunways
@@ -171,6 +177,20 @@ type CAAircraft struct {
 	Acknowledged bool
 }
 
+// MCIAircraft records a Mode C intruder alert: either an unassociated
+// target in conflict with a tracked IFR aircraft or one that has
+// penetrated one of the facility's Mode C intruder volumes.
+type MCIAircraft struct {
+	Callsign     string // tracked aircraft; empty for airspace penetrations
+	Intruder     string
+	Airspace     string // volume name, for airspace penetrations
+	Acknowledged bool
+}
+
+func (mci MCIAircraft) Involves(callsign string) bool {
+	return mci.Callsign == callsign || mci.Intruder == callsign
+}
+
 type QuickLookPosition struct {
 	Callsign string
 	Id       string
@@ -607,8 +627,9 @@ type STARSPreferenceSet struct {
 
 	// DisplayUncorrelatedTargets bool // NOT USED
 
-	DisableCAWarnings bool
-	DisableMSAW       bool
+	DisableCAWarnings  bool
+	DisableMSAW        bool
+	DisableMCIWarnings bool
 
 	OverflightFullDatablocks bool
 	AutomaticFDBOffset       bool
@@ -1197,6 +1218,16 @@ func (sp *STARSPane) makeSystemMaps(w *World) map[int]*STARSMap {
 	ReturnLinesDrawBuilder(ld)
 	maps[401] = mvas
 
+	// MCI suppression filters
+	msf := &STARSMap{
+		Label: "ALLMCISU",
+		Name:  "ALL MCI SUPPRESSION FILTERS",
+	}
+	for _, vol := range w.STARSFacilityAdaptation.InhibitMCIVolumes {
+		vol.GenerateDrawCommands(&msf.CommandBuffer, w.NmPerLongitude)
+	}
+	maps[402] = msf
+
 	// Radar maps
 	radarIndex := 701
 	for _, name := range SortedMapKeys(w.RadarSites) {
@@ -1292,7 +1323,7 @@ func (sp *STARSPane) processEvents(w *World) {
 		}
 
 		warn := slices.ContainsFunc(mvas, func(mva MVA) bool {
-			return mva.Inside(ac.Position()) && ac.Altitude() < float32(mva.MinimumLimit)
+			return mva.Inside(ac.Position()) && sp.modeCAltitude(w, ac) < mva.MinimumLimit
 		})
 
 		if !warn && state.InhibitMSAW {
@@ -1312,6 +1343,11 @@ func (sp *STARSPane) processEvents(w *World) {
 		_, b := w.Aircraft[ca.Callsigns[1]]
 		return a && b
 	})
+	sp.MCIAircraft = FilterSlice(sp.MCIAircraft, func(mci MCIAircraft) bool {
+		_, a := w.Aircraft[mci.Callsign]
+		_, b := w.Aircraft[mci.Intruder]
+		return (a || mci.Callsign == "") && b
+	})
 
 	for _, event := range sp.events.Get() {
 		switch event.Type {
@@ -1602,6 +1638,14 @@ func (sp *STARSPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 		globalConfig.Audio.StopPlayContinuous(AudioConflictAlert)
 	}
 
+	// And the intruder sound for unacknowledged MCIs
+	if !ps.DisableMCIWarnings && slices.ContainsFunc(sp.MCIAircraft,
+		func(mci MCIAircraft) bool { return !mci.Acknowledged }) {
+		globalConfig.Audio.StartPlayContinuous(AudioModeCIntruder)
+	} else {
+		globalConfig.Audio.StopPlayContinuous(AudioModeCIntruder)
+	}
+
 	// Do this at the end of drawing so that we hold on to the tracks we
 	// have for rendering the current frame.
 	if sp.discardTracks {
@@ -1613,6 +1657,12 @@ func (sp *STARSPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 	}
 }
 
+// modeCAltitude returns the altitude to display for an aircraft; see
+// World.ModeCAltitude.
+func (sp *STARSPane) modeCAltitude(w *World, ac *Aircraft) int {
+	return int(w.ModeCAltitude(ac))
+}
+
 func (sp *STARSPane) updateRadarTracks(w *World) {
 	// FIXME: all aircraft radar tracks are updated at the same time.
 	now := w.CurrentTime()
@@ -1637,7 +1687,7 @@ func (sp *STARSPane) updateRadarTracks(w *World) {
 		idx := state.tracksIndex % len(state.tracks)
 		state.tracks[idx] = RadarTrack{
 			Position:    ac.Position(),
-			Altitude:    int(ac.Altitude()),
+			Altitude:    sp.modeCAltitude(w, ac),
 			Groundspeed: int(ac.Nav.FlightState.GS),
 			Time:        now,
 		}
@@ -1650,6 +1700,7 @@ func (sp *STARSPane) updateRadarTracks(w *World) {
 	})
 
 	sp.updateCAAircraft(w, aircraft)
+	sp.updateMCIAircraft(w, aircraft)
 	sp.updateInTrailDistance(aircraft, w)
 }
 
@@ -1701,6 +1752,11 @@ func (sp *STARSPane) processKeyboardInput(ctx *PaneContext) {
 			sp.resetInputState()
 			sp.commandMode = CommandModeMin
 
+		case KeyTab:
+			if sp.commandMode == CommandModeNone {
+				sp.completeAircraftCommand(ctx)
+			}
+
 		case KeyEnter:
 			if status := sp.executeSTARSCommand(sp.previewAreaInput, ctx); status.err != nil {
 				sp.previewAreaOutput = GetSTARSError(status.err).Error()
@@ -1834,6 +1890,41 @@ func (sp *STARSPane) getAircraftIndex(ac *Aircraft) int {
 	}
 }
 
+// completeAircraftCommand completes the fix or approach at the end of a
+// partially-entered aircraft command. If there are multiple possibilities,
+// it completes as much as is common to all of them and lists them.
+func (sp *STARSPane) completeAircraftCommand(ctx *PaneContext) {
+	f := strings.Fields(sp.previewAreaInput)
+	if len(f) < 2 || strings.HasSuffix(sp.previewAreaInput, " ") {
+		return
+	}
+	ac := ctx.world.GetAircraft(f[0], true)
+	if ac == nil {
+		return
+	}
+
+	partial := f[len(f)-1]
+	completions := CompleteAircraftCommand(ctx.world, ac, partial)
+	if len(completions) == 0 {
+		return
+	}
+
+	common := completions[0]
+	for _, c := range completions[1:] {
+		for !strings.HasPrefix(c, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	sp.previewAreaInput = strings.TrimSuffix(sp.previewAreaInput, partial) + common
+	if n := len(completions); n > 16 {
+		sp.previewAreaOutput = strings.Join(completions[:16], " ") + fmt.Sprintf(" (%d MORE)", n-16)
+	} else if n > 1 {
+		sp.previewAreaOutput = strings.Join(completions, " ")
+	} else {
+		sp.previewAreaOutput = ""
+	}
+}
+
 func (sp *STARSPane) executeSTARSCommand(cmd string, ctx *PaneContext) (status STARSCommandStatus) {
 	lookupAircraft := func(callsign string, abbreviated bool) *Aircraft {
 		if ac := ctx.world.GetAircraft(callsign, abbreviated); ac != nil {
@@ -2036,6 +2127,17 @@ func (sp *STARSPane) executeSTARSCommand(cmd string, ctx *PaneContext) (status S
 			return
 		}
 
+		if strings.HasPrefix(cmd, "?") {
+			// Help for aircraft commands, optionally only those starting
+			// with the given characters.
+			if help := AircraftCommandHelp(strings.TrimSpace(cmd[1:])); len(help) > 0 {
+				status.output = strings.Join(help, "\n")
+			} else {
+				status.err = ErrSTARSCommandFormat
+			}
+			return
+		}
+
 		f := strings.Fields(cmd)
 		if len(f) > 1 {
 			if f[0] == ".AUTOTRACK" && len(f) == 2 {
@@ -2059,7 +2161,19 @@ func (sp *STARSPane) executeSTARSCommand(cmd string, ctx *PaneContext) (status S
 					return
 				}
 			} else if ac := lookupAircraft(f[0], true); ac != nil && len(f) > 1 {
+				// The sim expands macros itself; they're expanded here
+				// only so that the commands can be checked first.
 				acCmds := strings.Join(f[1:], " ")
+				expanded, err := globalConfig.Macros.Expand(ctx.world.TRACON, acCmds)
+				if err == nil {
+					err = ValidateAircraftCommands(ctx.world, ac, expanded)
+				}
+				if err != nil {
+					// Leave the input so that it can be corrected.
+					globalConfig.Audio.PlayOnce(AudioCommandError)
+					status.output = strings.ToUpper(err.Error())
+					return
+				}
 				ctx.world.RunAircraftCommands(ac, acCmds,
 					func(err error) {
 						globalConfig.Audio.PlayOnce(AudioCommandError)
@@ -2068,6 +2182,26 @@ func (sp *STARSPane) executeSTARSCommand(cmd string, ctx *PaneContext) (status S
 
 				status.clear = true
 				return
+			} else if len(f) > 2 {
+				// Maybe it's a control instruction given in standard
+				// phraseology: "american twelve thirty four turn left
+				// heading two seven zero".
+				callsign, acCmds, err := ParsePhraseology(cmd, ctx.world)
+				if err == nil {
+					ctx.world.RunAircraftCommands(ctx.world.Aircraft[callsign], acCmds,
+						func(err error) {
+							globalConfig.Audio.PlayOnce(AudioCommandError)
+							sp.previewAreaOutput = GetSTARSError(err).Error()
+						})
+					status.clear = true
+					return
+				} else if errors.Is(err, ErrUnknownPhraseology) {
+					// The callsign matched but some of the rest didn't;
+					// report what couldn't be understood.
+					globalConfig.Audio.PlayOnce(AudioCommandError)
+					status.output = strings.ToUpper(err.Error())
+					return
+				}
 			}
 		}
 		if len(cmd) > 0 {
@@ -2182,8 +2316,16 @@ func (sp *STARSPane) executeSTARSCommand(cmd string, ctx *PaneContext) (status S
 		}
 
 	case CommandModeVP:
-		// TODO
-		status.err = ErrSTARSCommandFormat
+		// Create a VFR flight plan: ACID and then the flight plan fields.
+		f := strings.Fields(cmd)
+		if len(f) < 2 {
+			status.err = ErrSTARSCommandFormat
+		} else if ac := lookupAircraft(f[0], false); ac == nil {
+			status.err = ErrSTARSNoFlight
+		} else {
+			status.err = sp.createFlightPlan(ctx, ac.Callsign, VFR, f[1:])
+		}
+		status.clear = true
 		return
 
 	case CommandModeMultiFunc:
@@ -2393,6 +2535,20 @@ func (sp *STARSPane) executeSTARSCommand(cmd string, ctx *PaneContext) (status S
 			}
 			return
 
+		case "M":
+			// M(ACID) and then the flight plan fields to amend
+			if f := strings.Fields(cmd); len(f) < 2 {
+				status.err = ErrSTARSCommandFormat
+			} else if ac := lookupAircraft(f[0], false); ac == nil {
+				status.err = ErrSTARSNoFlight
+			} else {
+				status.err = sp.amendFlightPlan(ctx, ac.Callsign, func(fp *FlightPlan) error {
+					return parseFlightPlanFields(f[1:], fp)
+				})
+			}
+			status.clear = true
+			return
+
 		case "N":
 			// CRDA...
 			if len(sp.ConvergingRunways) == 0 {
@@ -2908,11 +3064,11 @@ func (sp *STARSPane) executeSTARSCommand(cmd string, ctx *PaneContext) (status S
 		f := strings.Fields(cmd)
 		if len(f) == 1 {
 			callsign := lookupCallsign(f[0], false)
-			status.err = ctx.world.SetSquawkAutomatic(callsign)
+			sp.setSquawkAutomatic(ctx, callsign)
 		} else if len(f) == 2 {
 			if squawk, err := ParseSquawk(f[1]); err == nil {
 				callsign := lookupCallsign(f[0], false)
-				status.err = ctx.world.SetSquawk(callsign, squawk)
+				sp.setSquawk(ctx, callsign, squawk)
 			} else {
 				status.err = ErrSTARSIllegalCode
 			}
@@ -2940,6 +3096,14 @@ func (sp *STARSPane) executeSTARSCommand(cmd string, ctx *PaneContext) (status S
 			ps.DisableCAWarnings = false
 			status.clear = true
 			return
+		} else if cmd == "MI" {
+			ps.DisableMCIWarnings = true
+			status.clear = true
+			return
+		} else if cmd == "ME" {
+			ps.DisableMCIWarnings = false
+			status.clear = true
+			return
 		}
 
 	case CommandModeMin:
@@ -3172,6 +3336,20 @@ func (sp *STARSPane) setTemporaryAltitude(ctx *PaneContext, callsign string, alt
 		})
 }
 
+func (sp *STARSPane) setSquawk(ctx *PaneContext, callsign string, squawk Squawk) {
+	ctx.world.SetSquawk(callsign, squawk, nil,
+		func(err error) {
+			sp.previewAreaOutput = GetSTARSError(err).Error()
+		})
+}
+
+func (sp *STARSPane) setSquawkAutomatic(ctx *PaneContext, callsign string) {
+	ctx.world.SetSquawkAutomatic(callsign, nil,
+		func(err error) {
+			sp.previewAreaOutput = GetSTARSError(err).Error()
+		})
+}
+
 func (sp *STARSPane) setGlobalLeaderLine(ctx *PaneContext, callsign string, dir *CardinalOrdinalDirection) {
 	state := sp.Aircraft[callsign]
 	state.GlobalLeaderLineDirection = dir // hack for instant update
@@ -3499,6 +3677,17 @@ func (sp *STARSPane) executeSTARSClickedCommand(ctx *PaneContext, cmd string, mo
 							return
 						}
 					}
+				} else if slices.ContainsFunc(sp.MCIAircraft, func(mci MCIAircraft) bool {
+					return mci.Involves(ac.Callsign) && !mci.Acknowledged
+				}) {
+					// Acknowledged a MCI
+					for i, mci := range sp.MCIAircraft {
+						if mci.Involves(ac.Callsign) {
+							sp.MCIAircraft[i].Acknowledged = true
+						}
+					}
+					status.clear = true
+					return
 				} else if state.MSAW && !state.MSAWAcknowledged {
 					// Acknowledged a MSAW
 					state.MSAWAcknowledged = true
@@ -3724,8 +3913,9 @@ func (sp *STARSPane) executeSTARSClickedCommand(ctx *PaneContext, cmd string, mo
 				return
 			} else if len(cmd) == 5 && cmd[:2] == "++" {
 				if alt, err := strconv.Atoi(cmd[2:]); err == nil {
-					status.err = amendFlightPlan(ctx.world, ac.Callsign, func(fp *FlightPlan) {
+					status.err = sp.amendFlightPlan(ctx, ac.Callsign, func(fp *FlightPlan) error {
 						fp.Altitude = alt * 100
+						return nil
 					})
 					status.clear = true
 				} else {
@@ -3873,8 +4063,9 @@ func (sp *STARSPane) executeSTARSClickedCommand(ctx *PaneContext, cmd string, mo
 			return
 
 		case CommandModeVP:
-			// TODO: implement
-			status.err = ErrSTARSCommandFormat
+			// Create a VFR flight plan
+			status.err = sp.createFlightPlan(ctx, ac.Callsign, VFR, strings.Fields(cmd))
+			status.clear = true
 			return
 
 		case CommandModeMultiFunc:
@@ -3910,10 +4101,13 @@ func (sp *STARSPane) executeSTARSClickedCommand(ctx *PaneContext, cmd string, mo
 			case "M":
 				if cmd == "" {
 					state.displayPilotAltitude = !state.displayPilotAltitude
-					status.clear = true
 				} else {
-					status.err = ErrSTARSCommandFormat
+					// Amend the flight plan
+					status.err = sp.amendFlightPlan(ctx, ac.Callsign, func(fp *FlightPlan) error {
+						return parseFlightPlanFields(strings.Fields(cmd), fp)
+					})
 				}
+				status.clear = true
 				return
 
 			case "N":
@@ -4056,11 +4250,14 @@ func (sp *STARSPane) executeSTARSClickedCommand(ctx *PaneContext, cmd string, mo
 		case CommandModeFlightData:
 			if cmd == "" {
 				status.clear = true
-				status.err = ctx.world.SetSquawkAutomatic(ac.Callsign)
+				sp.setSquawkAutomatic(ctx, ac.Callsign)
 				return
 			} else {
 				if squawk, err := ParseSquawk(cmd); err == nil {
-					status.err = ctx.world.SetSquawk(ac.Callsign, squawk)
+					sp.setSquawk(ctx, ac.Callsign, squawk)
+				} else if ac.TrackingController == "" && !ac.IsAssociated() {
+					// Create an IFR flight plan for an unassociated target
+					status.err = sp.createFlightPlan(ctx, ac.Callsign, IFR, strings.Fields(cmd))
 				} else {
 					status.err = ErrSTARSIllegalParam
 				}
@@ -4918,6 +5115,9 @@ func (sp *STARSPane) drawSystemLists(aircraft []*Aircraft, ctx *PaneContext, pan
 			if ps.DisableMSAW {
 				disabled = append(disabled, "MSAW")
 			}
+			if ps.DisableMCIWarnings {
+				disabled = append(disabled, "MCI")
+			}
 			// TODO: others?
 			if len(disabled) > 0 {
 				text := "TW OFF: " + strings.Join(disabled, " ")
@@ -5013,6 +5213,10 @@ func (sp *STARSPane) drawSystemLists(aircraft []*Aircraft, ctx *PaneContext, pan
 			lists = append(lists, "CA")
 			n += len(sp.CAAircraft)
 		}
+		if !ps.DisableMCIWarnings {
+			lists = append(lists, "MCI")
+			n += len(sp.MCIAircraft)
+		}
 
 		if len(lists) > 0 {
 			text := strings.Join(lists, "/") + "\n"
@@ -5027,7 +5231,7 @@ func (sp *STARSPane) drawSystemLists(aircraft []*Aircraft, ctx *PaneContext, pan
 						break
 					}
 					if sp.Aircraft[ac.Callsign].MSAW {
-						text += fmt.Sprintf("%-14s%03d LA\n", ac.Callsign, int((ac.Altitude()+50)/100))
+						text += fmt.Sprintf("%-14s%03d LA\n", ac.Callsign, (sp.modeCAltitude(ctx.world, ac)+50)/100)
 						n--
 					}
 				}
@@ -5045,6 +5249,24 @@ func (sp *STARSPane) drawSystemLists(aircraft []*Aircraft, ctx *PaneContext, pan
 				}
 			}
 
+			// MCI
+			if !ps.DisableMCIWarnings {
+				for _, mci := range sp.MCIAircraft {
+					if n == 0 {
+						break
+					}
+
+					// The intruder is identified by its beacon code.
+					sq := ctx.world.Aircraft[mci.Intruder].Squawk.String()
+					if mci.Callsign != "" {
+						text += fmt.Sprintf("%-16s MCI\n", mci.Callsign+"*"+sq)
+					} else {
+						text += fmt.Sprintf("%-16s MCI\n", sq+"*"+mci.Airspace)
+					}
+					n--
+				}
+			}
+
 			drawList(text, ps.AlertList.Position)
 		}
 	}
@@ -5688,7 +5910,8 @@ func (sp *STARSPane) WarnOutsideAirspace(ctx *PaneContext, ac *Aircraft) (alts [
 	state := sp.Aircraft[ac.Callsign]
 	if ac.IsDeparture() {
 		if len(ctx.world.DepartureAirspace) > 0 {
-			inDepartureAirspace, depAlts := InAirspace(ac.Position(), ac.Altitude(), ctx.world.DepartureAirspace)
+			inDepartureAirspace, depAlts := InAirspace(ac.Position(), float32(sp.modeCAltitude(ctx.world, ac)),
+				ctx.world.DepartureAirspace)
 			if !state.HaveEnteredAirspace {
 				state.HaveEnteredAirspace = inDepartureAirspace
 			} else {
@@ -5698,7 +5921,8 @@ func (sp *STARSPane) WarnOutsideAirspace(ctx *PaneContext, ac *Aircraft) (alts [
 		}
 	} else {
 		if len(ctx.world.ApproachAirspace) > 0 {
-			inApproachAirspace, depAlts := InAirspace(ac.Position(), ac.Altitude(), ctx.world.ApproachAirspace)
+			inApproachAirspace, depAlts := InAirspace(ac.Position(), float32(sp.modeCAltitude(ctx.world, ac)),
+				ctx.world.ApproachAirspace)
 			if !state.HaveEnteredAirspace {
 				state.HaveEnteredAirspace = inApproachAirspace
 			} else {
@@ -5763,6 +5987,78 @@ func (sp *STARSPane) updateCAAircraft(w *World, aircraft []*Aircraft) {
 	}
 }
 
+// isModeCIntruder returns whether the aircraft is an unassociated target
+// with Mode C altitude: a VFR 1200 code or a code that doesn't match a
+// flight plan.
+func isModeCIntruder(ac *Aircraft) bool {
+	return ac.Mode == Charlie && ac.TrackingController == "" &&
+		(ac.Squawk == Squawk(0o1200) || !ac.IsAssociated())
+}
+
+func (sp *STARSPane) updateMCIAircraft(w *World, aircraft []*Aircraft) {
+	fa := &w.STARSFacilityAdaptation
+	inInhibitVolume := func(state *STARSAircraftState) bool {
+		return slices.ContainsFunc(fa.InhibitMCIVolumes, func(vol AirspaceVolume) bool {
+			return vol.Inside(state.TrackPosition(), state.TrackAltitude())
+		})
+	}
+
+	var intruders, tracked []*Aircraft
+	for _, ac := range aircraft {
+		if isModeCIntruder(ac) {
+			if !inInhibitVolume(sp.Aircraft[ac.Callsign]) {
+				intruders = append(intruders, ac)
+			}
+		} else if ac.TrackingController != "" && ac.FlightPlan != nil && ac.FlightPlan.Rules == IFR {
+			tracked = append(tracked, ac)
+		}
+	}
+
+	conflicting := func(ac, intruder *Aircraft) bool {
+		sa, si := sp.Aircraft[ac.Callsign], sp.Aircraft[intruder.Callsign]
+		if sa.DisableCAWarnings {
+			return false
+		}
+		return nmdistance2ll(sa.TrackPosition(), si.TrackPosition()) <= ModeCIntruderLateralMinimum &&
+			/*small slop for fp error*/
+			abs(sa.TrackAltitude()-si.TrackAltitude()) <= ModeCIntruderVerticalMinimum-5 &&
+			!sp.diverging(ac, intruder)
+	}
+
+	// Find all of the current alerts.
+	var alerts []MCIAircraft
+	for _, intruder := range intruders {
+		for _, ac := range tracked {
+			if conflicting(ac, intruder) {
+				alerts = append(alerts, MCIAircraft{Callsign: ac.Callsign, Intruder: intruder.Callsign})
+			}
+		}
+
+		state := sp.Aircraft[intruder.Callsign]
+		for _, vol := range fa.MCIVolumes {
+			if vol.Inside(state.TrackPosition(), state.TrackAltitude()) {
+				alerts = append(alerts, MCIAircraft{Intruder: intruder.Callsign, Airspace: vol.Name})
+			}
+		}
+	}
+
+	same := func(a, b MCIAircraft) bool {
+		return a.Callsign == b.Callsign && a.Intruder == b.Intruder && a.Airspace == b.Airspace
+	}
+
+	// Remove the ones that are no longer active, keeping the rest in the
+	// order they were first detected along with whether they've been
+	// acknowledged, and then add the new ones.
+	sp.MCIAircraft = FilterSlice(sp.MCIAircraft, func(mci MCIAircraft) bool {
+		return slices.ContainsFunc(alerts, func(a MCIAircraft) bool { return same(a, mci) })
+	})
+	for _, a := range alerts {
+		if !slices.ContainsFunc(sp.MCIAircraft, func(mci MCIAircraft) bool { return same(a, mci) }) {
+			sp.MCIAircraft = append(sp.MCIAircraft, a)
+		}
+	}
+}
+
 func (sp *STARSPane) updateInTrailDistance(aircraft []*Aircraft, w *World) {
 	// Zero out the previous distance
 	for _, ac := range aircraft {
@@ -5931,56 +6227,19 @@ func getCwtCategory(ac *Aircraft) string {
 }
 
 func (sp *STARSPane) checkInTrailCwtSeparation(back, front *Aircraft) {
-	cwtClass := func(ac *Aircraft) int {
+	cwtCategory := func(ac *Aircraft) string {
 		perf, ok := database.AircraftPerformance[ac.FlightPlan.BaseType()]
 		if !ok {
 			lg.Errorf("%s: unable to get performance model for %s", ac.Callsign, ac.FlightPlan.BaseType())
-			return 9
+			return ""
 		}
 		wc := perf.Category.CWT
 		if len(wc) == 0 {
 			lg.Errorf("%s: no CWT category found for %s", ac.Callsign, ac.FlightPlan.BaseType())
-			return 9
-		}
-		switch wc[0] {
-		case 'I':
-			return 0
-		case 'H':
-			return 1
-		case 'G':
-			return 2
-		case 'F':
-			return 3
-		case 'E':
-			return 4
-		case 'D':
-			return 5
-		case 'C':
-			return 6
-		case 'B':
-			return 7
-		case 'A':
-			return 8
-		default:
-			lg.Errorf("%s: unexpected weight class \"%c\"", ac.Callsign, wc[0])
-			return 9
-		}
-	}
-	// 7110.126B TBL 5-5-2
-	// 0 value means minimum radar separation
-	cwtOnApproachLookUp := [10][10]float32{ // [front][back]
-		{0, 0, 0, 0, 0, 0, 0, 0, 0, 10},          // Behind I
-		{0, 0, 0, 0, 0, 0, 0, 0, 0, 10},          // Behind H
-		{0, 0, 0, 0, 0, 0, 0, 0, 0, 10},          // Behind G
-		{4, 0, 0, 0, 0, 0, 0, 0, 0, 10},          // Behind F
-		{4, 0, 0, 0, 0, 0, 0, 0, 0, 10},          // Behind E
-		{6, 6, 5, 5, 5, 4, 4, 3, 0, 10},          // Behind D
-		{6, 5, 3.5, 3.5, 3.5, 0, 0, 0, 0, 10},    // Behind C
-		{6, 5, 5, 5, 5, 4, 4, 3, 0, 10},          // Behind B
-		{8, 8, 7, 7, 7, 6, 6, 5, 0, 10},          // Behind A
-		{10, 10, 10, 10, 10, 10, 10, 10, 10, 10}, // Behind NOWGT (No weight: 7110.762)
-	}
-	cwtSeparation := cwtOnApproachLookUp[cwtClass(front)][cwtClass(back)]
+		}
+		return wc
+	}
+	cwtSeparation := CWTApproachSeparation(cwtCategory(front), cwtCategory(back))
 
 	state := sp.Aircraft[back.Callsign]
 	vol := back.ATPAVolume()
@@ -6076,6 +6335,8 @@ func (sp *STARSPane) getWarnings(ctx *PaneContext, ac *Aircraft) []string {
 	}
 	if ok, code := SquawkIsSPC(ac.Squawk); ok {
 		warnings[code] = nil
+	} else if ac.Squawk != Squawk(0o1200) && sp.duplicateBeacon(ctx, ac) {
+		warnings["DB"] = nil
 	}
 	for code := range ac.SPCOverrides {
 		warnings[code] = nil
@@ -6087,6 +6348,10 @@ func (sp *STARSPane) getWarnings(ctx *PaneContext, ac *Aircraft) []string {
 			}) {
 		warnings["CA"] = nil
 	}
+	if !ps.DisableMCIWarnings && !state.DisableCAWarnings &&
+		slices.ContainsFunc(sp.MCIAircraft, func(mci MCIAircraft) bool { return mci.Callsign == ac.Callsign }) {
+		warnings["MCI"] = nil
+	}
 	if alts, outside := sp.WarnOutsideAirspace(ctx, ac); outside {
 		altStrs := ""
 		for _, a := range alts {
@@ -6098,6 +6363,17 @@ func (sp *STARSPane) getWarnings(ctx *PaneContext, ac *Aircraft) []string {
 	return SortedMapKeys(warnings)
 }
 
+// duplicateBeacon returns whether another aircraft is squawking the same
+// beacon code as the given one.
+func (sp *STARSPane) duplicateBeacon(ctx *PaneContext, ac *Aircraft) bool {
+	for _, other := range ctx.world.Aircraft {
+		if other != ac && other.Mode != Standby && other.Squawk == ac.Squawk {
+			return true
+		}
+	}
+	return false
+}
+
 func (sp *STARSPane) formatDatablocks(ctx *PaneContext, ac *Aircraft) []STARSDatablock {
 	if ac.Mode == Standby {
 		return nil
@@ -7381,16 +7657,91 @@ func STARSDisabledButton(text string, flags int, buttonScale float32) {
 // amendFlightPlan is a useful utility function for changing an entry in
 // the flightplan; the provided callback function should make the update
 // and the rest of the details are handled here.
-func amendFlightPlan(w *World, callsign string, amend func(fp *FlightPlan)) error {
-	if ac := w.GetAircraft(callsign, false); ac == nil {
-		return ErrNoAircraftForCallsign
+func (sp *STARSPane) amendFlightPlan(ctx *PaneContext, callsign string, amend func(fp *FlightPlan) error) error {
+	if ac := ctx.world.GetAircraft(callsign, false); ac == nil {
+		return ErrSTARSNoFlight
+	} else if ac.FlightPlan == nil {
+		return ErrSTARSIllegalFlight
 	} else {
-		fp := Select(ac.FlightPlan != nil, ac.FlightPlan, &FlightPlan{})
-		amend(fp)
-		return w.AmendFlightPlan(callsign, *fp)
+		fp := *ac.FlightPlan
+		if err := amend(&fp); err != nil {
+			return err
+		}
+		ctx.world.AmendFlightPlan(callsign, fp, nil,
+			func(err error) {
+				sp.previewAreaOutput = GetSTARSError(err).Error()
+			})
+		return nil
 	}
 }
 
+// createFlightPlan creates a flight plan with the given rules for an
+// untracked, unassociated aircraft from the fields entered by the
+// controller; an aircraft type must be given.
+func (sp *STARSPane) createFlightPlan(ctx *PaneContext, callsign string, rules FlightRules, fields []string) error {
+	if ac := ctx.world.GetAircraft(callsign, false); ac == nil {
+		return ErrSTARSNoFlight
+	} else if ac.TrackingController != "" || ac.IsAssociated() {
+		return ErrSTARSIllegalFlight
+	}
+
+	fp := FlightPlan{Rules: rules}
+	if err := parseFlightPlanFields(fields, &fp); err != nil {
+		return err
+	} else if fp.AircraftType == "" {
+		return ErrSTARSCommandFormat
+	}
+	ctx.world.CreateFlightPlan(callsign, fp,
+		func(any) {
+			if state, ok := sp.Aircraft[callsign]; ok {
+				state.DatablockType = FullDatablock
+			}
+		},
+		func(err error) {
+			sp.previewAreaOutput = GetSTARSError(err).Error()
+		})
+	return nil
+}
+
+// parseFlightPlanFields updates the flight plan from fields entered by the
+// controller, each of which is identified by its format:
+//   - A three-digit requested altitude in hundreds of feet: "120".
+//   - A route of fixes, each preceded by a period: ".MERIT.HFD.CAM".
+//   - An equipment suffix: "/L".
+//   - An aircraft type, optionally with an equipment suffix: "B738/L".
+//   - A destination airport: "KBOS".
+//
+// A field that is both an aircraft type and an airport identifier is
+// taken to be an aircraft type.
+func parseFlightPlanFields(fields []string, fp *FlightPlan) error {
+	for _, f := range fields {
+		_, isType := database.AircraftPerformance[f]
+		_, isAirport := database.Airports[f]
+		if alt, err := strconv.Atoi(f); err == nil {
+			if len(f) != 3 {
+				return ErrSTARSIllegalValue
+			}
+			fp.Altitude = alt * 100
+		} else if f[0] == '.' {
+			fp.Route = strings.Join(strings.FieldsFunc(f, func(r rune) bool { return r == '.' }), " ")
+			if fp.Route == "" {
+				return ErrSTARSCommandFormat
+			}
+		} else if f[0] == '/' {
+			if len(f) != 2 {
+				return ErrSTARSIllegalParam
+			}
+			fp.SetAircraftType("", f[1:])
+		} else if isAirport && !isType {
+			fp.ArrivalAirport = f
+		} else {
+			actype := FlightPlan{AircraftType: f}
+			fp.SetAircraftType(actype.TypeWithoutSuffix(), actype.EquipmentSuffix())
+		}
+	}
+	return nil
+}
+
 func (sp *STARSPane) initializeFonts() {
 	init := func(fonts []*Font, name string, sizes []int) {
 		for i, sz := range sizes {
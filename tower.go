@@ -0,0 +1,242 @@
+// tower.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"log/slog"
+	"time"
+)
+
+// VirtualTower stands in for the tower controller at an airport. It
+// tracks which runways are occupied--by departures on their takeoff roll
+// and by arrivals that have landed but not yet exited--and sends arrivals
+// around if the runway won't be clear by the time they reach the
+// threshold or if they're too close behind the preceding arrival.
+type VirtualTower struct {
+	Airport string
+	Runways map[string]*RunwayState
+}
+
+type RunwayState struct {
+	// Most recent arrival to land and when it will have exited the
+	// runway.
+	LandingCallsign string
+	OccupiedUntil   time.Time
+}
+
+// When arrivals get within this distance of the threshold (in nm), the
+// tower makes sure that they're still good to land.
+const LandingClearanceDistance = 1
+
+func MakeVirtualTower(airport string) *VirtualTower {
+	return &VirtualTower{
+		Airport: airport,
+		Runways: make(map[string]*RunwayState),
+	}
+}
+
+func (vt *VirtualTower) runway(rwy string) *RunwayState {
+	rwy = cleanRunway(rwy)
+	if _, ok := vt.Runways[rwy]; !ok {
+		vt.Runways[rwy] = &RunwayState{}
+	}
+	return vt.Runways[rwy]
+}
+
+// RunwayOccupancyTime returns how long it takes an aircraft with the
+// given performance to roll out after landing and exit the runway. We
+// assume that it uses 60% of its landing distance to do so, averaging
+// half of its landing speed, and then needs a few more seconds to clear.
+func RunwayOccupancyTime(perf AircraftPerformance) time.Duration {
+	const exitTime = 10 * time.Second
+	if perf.Speed.Landing == 0 || perf.Runway.Landing == 0 {
+		return 45 * time.Second
+	}
+	hours := 0.6 * perf.Runway.Landing / (0.5 * perf.Speed.Landing)
+	return time.Duration(hours*3600)*time.Second + exitTime
+}
+
+// TakeoffRunwayOccupancyTime returns how long a departure occupies the
+// runway after it is released: it accelerates to V2 along the runway
+// and then needs a few more seconds to be safely airborne.
+func TakeoffRunwayOccupancyTime(nav *Nav) time.Duration {
+	const liftoffTime = 10 * time.Second
+	accel := nav.Perf.Rate.Accelerate / 2 // Accel is given in "per 2 seconds..."
+	if accel == 0 {
+		return 60 * time.Second
+	}
+	return time.Duration(nav.v2()/accel*float32(time.Second)) + liftoffTime
+}
+
+// Landed should be called when an arrival crosses the threshold; the
+// runway is then occupied until it has rolled out and exited. Any
+// aircraft following it to the same runway that are closer than the
+// required wake turbulence separation are returned so that they can be
+// sent around.
+func (vt *VirtualTower) Landed(w *World, ac *Aircraft, now time.Time) []*Aircraft {
+	rwy := ac.Nav.Approach.Assigned.Runway
+	rs := vt.runway(rwy)
+	rs.LandingCallsign = ac.Callsign
+	rs.OccupiedUntil = now.Add(RunwayOccupancyTime(ac.Nav.Perf))
+
+	var tooClose []*Aircraft
+	for _, follower := range w.Aircraft {
+		if follower == ac || !vt.isLandingOn(follower, rwy) {
+			continue
+		}
+		d, err := follower.Nav.distanceToEndOfApproach()
+		if err != nil {
+			continue
+		}
+
+		minSep := CWTApproachSeparation(ac.Nav.Perf.Category.CWT, follower.Nav.Perf.Category.CWT)
+		if minSep == 0 {
+			// Minimum radar separation; 2.5nm is allowed on final.
+			minSep = 2.5
+		}
+		if d < minSep {
+			tooClose = append(tooClose, follower)
+		}
+	}
+	return tooClose
+}
+
+// isLandingOn returns true if the aircraft is cleared for an approach to
+// the given runway at the tower's airport.
+func (vt *VirtualTower) isLandingOn(ac *Aircraft, rwy string) bool {
+	return !ac.IsDeparture() && ac.FlightPlan.ArrivalAirport == vt.Airport &&
+		ac.Nav.Approach.Cleared && ac.Nav.Approach.Assigned != nil &&
+		cleanRunway(ac.Nav.Approach.Assigned.Runway) == cleanRunway(rwy)
+}
+
+// CheckLanding is called for arrivals on short final. If the runway will
+// not be clear when the aircraft reaches the threshold, a reason for
+// sending it around is returned.
+func (vt *VirtualTower) CheckLanding(w *World, ac *Aircraft, now time.Time) (string, bool) {
+	d, err := ac.Nav.distanceToEndOfApproach()
+	if err != nil || d > LandingClearanceDistance {
+		return "", true
+	}
+	rwy := ac.Nav.Approach.Assigned.Runway
+	eta := now.Add(time.Duration(d / max(ac.GS(), 1) * float32(time.Hour)))
+
+	for id, rs := range vt.Runways {
+		if rs.OccupiedUntil.After(eta) && vt.runwaysConflict(w, id, rwy) {
+			return "traffic on the runway", false
+		}
+	}
+
+	for _, dep := range w.Aircraft {
+		if dep.IsDeparture() && dep.FlightPlan.DepartureAirport == vt.Airport &&
+			!dep.IsAirborne() && vt.runwaysConflict(w, dep.DepartureRunway, rwy) {
+			return "traffic departing the runway", false
+		}
+	}
+
+	return "", true
+}
+
+// ReadyForDeparture returns true if the given departure can be released:
+// its runway must be clear and it must be airborne before any arrival to
+// that runway or one that crosses it gets close enough that CheckLanding
+// would send it around.
+func (vt *VirtualTower) ReadyForDeparture(w *World, dep *Aircraft, now time.Time) bool {
+	rwy := dep.DepartureRunway
+	for id, rs := range vt.Runways {
+		if rs.OccupiedUntil.After(now) && vt.runwaysConflict(w, id, rwy) {
+			return false
+		}
+	}
+
+	for _, ac := range w.Aircraft {
+		if ac.IsDeparture() {
+			if ac.FlightPlan.DepartureAirport == vt.Airport && !ac.IsAirborne() &&
+				vt.runwaysConflict(w, ac.DepartureRunway, rwy) {
+				return false
+			}
+		} else if ac.FlightPlan.ArrivalAirport == vt.Airport && ac.Nav.Approach.Cleared &&
+			ac.Nav.Approach.Assigned != nil && vt.runwaysConflict(w, ac.Nav.Approach.Assigned.Runway, rwy) {
+			// Don't launch if an arrival will reach the point where the
+			// tower checks the runway before the departure is off of it.
+			if d, err := ac.Nav.distanceToEndOfApproach(); err == nil {
+				hours := (d - LandingClearanceDistance) / max(ac.GS(), 1)
+				if time.Duration(hours*float32(time.Hour)) < TakeoffRunwayOccupancyTime(&dep.Nav) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// runwaysConflict returns true if the two runways are the same physical
+// runway or if they cross.
+func (vt *VirtualTower) runwaysConflict(w *World, a, b string) bool {
+	a, b = cleanRunway(a), cleanRunway(b)
+	if a == "" || b == "" {
+		return false
+	} else if a == b {
+		return true
+	}
+
+	ra, oka := LookupRunway(vt.Airport, a)
+	rb, okb := LookupRunway(vt.Airport, b)
+	oppa, okoa := LookupOppositeRunway(vt.Airport, a)
+	oppb, okob := LookupOppositeRunway(vt.Airport, b)
+	if !oka || !okb || !okoa || !okob {
+		return false
+	}
+	if oppa.Id == rb.Id {
+		return true
+	}
+
+	// Check whether the two runway segments intersect.
+	nmPerLongitude := w.NmPerLongitude
+	p0, p1 := ll2nm(ra.Threshold, nmPerLongitude), ll2nm(oppa.Threshold, nmPerLongitude)
+	q0, q1 := ll2nm(rb.Threshold, nmPerLongitude), ll2nm(oppb.Threshold, nmPerLongitude)
+	orient := func(a, b, c [2]float32) float32 {
+		return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+	}
+	return orient(p0, p1, q0)*orient(p0, p1, q1) < 0 && orient(q0, q1, p0)*orient(q0, q1, p1) < 0
+}
+
+// virtualTower returns the VirtualTower for the given airport, creating
+// it if necessary.
+func (s *Sim) virtualTower(airport string) *VirtualTower {
+	if s.Towers == nil {
+		s.Towers = make(map[string]*VirtualTower)
+	}
+	if _, ok := s.Towers[airport]; !ok {
+		s.Towers[airport] = MakeVirtualTower(airport)
+	}
+	return s.Towers[airport]
+}
+
+// updateTowers is called after aircraft have been updated; landed holds
+// the arrivals that crossed the threshold during the update.
+func (s *Sim) updateTowers(landed []*Aircraft) {
+	now := s.SimTime
+
+	for _, ac := range landed {
+		vt := s.virtualTower(ac.FlightPlan.ArrivalAirport)
+		for _, follower := range vt.Landed(s.World, ac, now) {
+			s.lg.Info("tower: too close behind landing aircraft", slog.String("callsign", follower.Callsign),
+				slog.String("leader", ac.Callsign))
+			follower.SentAround(s.World, s, "too close behind the preceding arrival")
+		}
+	}
+
+	for _, ac := range s.World.Aircraft {
+		if ac.IsDeparture() || !ac.Nav.Approach.Cleared || ac.Nav.Approach.Assigned == nil {
+			continue
+		}
+		vt := s.virtualTower(ac.FlightPlan.ArrivalAirport)
+		if reason, ok := vt.CheckLanding(s.World, ac, now); !ok {
+			s.lg.Info("tower: sending arrival around", slog.String("callsign", ac.Callsign),
+				slog.String("reason", reason))
+			ac.SentAround(s.World, s, reason)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// tower_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadyForDeparture(t *testing.T) {
+	threshold := Point2LL{-75, 40}
+
+	dep := &Aircraft{Callsign: "DEP1", DepartureRunway: "27", FlightPlan: &FlightPlan{DepartureAirport: "KTST"}}
+	dep.Nav.FlightState.IsDeparture = true
+	dep.Nav.Perf.Rate.Accelerate = 5 // 2.5 knots per second
+	dep.Nav.Perf.Speed.V2 = 150
+	if rot := TakeoffRunwayOccupancyTime(&dep.Nav); rot != 70*time.Second {
+		t.Errorf("got takeoff runway occupancy time %s, expected 70s", rot)
+	}
+
+	arr := &Aircraft{Callsign: "ARR1", FlightPlan: &FlightPlan{ArrivalAirport: "KTST"}}
+	arr.Nav.Approach.Assigned = &Approach{Runway: "27"}
+	arr.Nav.Approach.Cleared = true
+	arr.Nav.Waypoints = []Waypoint{Waypoint{Fix: "_RWY27", Location: threshold}}
+	arr.Nav.FlightState.GS = 140
+
+	w := &World{Aircraft: map[string]*Aircraft{arr.Callsign: arr}}
+	vt := MakeVirtualTower("KTST")
+	now := time.Now()
+
+	for _, test := range []struct {
+		dist  float32 // nm
+		ready bool
+	}{
+		// 28 seconds until the tower checks the runway for the arrival.
+		{dist: 2.1, ready: false},
+		// 77 seconds.
+		{dist: 4, ready: true},
+	} {
+		arr.Nav.FlightState.Position = Point2LL{threshold[0], threshold[1] + test.dist/60}
+		if ready := vt.ReadyForDeparture(w, dep, now); ready != test.ready {
+			t.Errorf("arrival at %.1fnm: got ready %v, expected %v", test.dist, ready, test.ready)
+		}
+	}
+
+	// An occupied runway blocks departures regardless of arrivals.
+	delete(w.Aircraft, arr.Callsign)
+	vt.runway("27").OccupiedUntil = now.Add(time.Minute)
+	if vt.ReadyForDeparture(w, dep, now) {
+		t.Errorf("departure released onto an occupied runway")
+	}
+}
@@ -0,0 +1,261 @@
+// traffic.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// Traffic advisories: the controller describes the traffic by its clock
+// position, distance, and (optionally) altitude, the pilot looks for it,
+// and the aircraft that the description actually matches is found from
+// the aircraft's positions. Whether the pilot can see it depends on the
+// range, the weather, and whether it's day or night.
+
+const (
+	// How long pilots keep looking for traffic before reporting
+	// "negative contact".
+	trafficLookoutTime = 45 * time.Second
+	// Assumed thickness of the layer at the ceiling; aircraft in it or
+	// on opposite sides of it can't see each other.
+	cloudLayerThickness         = 1500
+	statuteMilesToNauticalMiles = 0.868976
+)
+
+// TrafficLookout records a traffic advisory that the pilot is looking
+// for.
+type TrafficLookout struct {
+	Traffic    string // the aircraft that matches the advisory, if any
+	Controller string
+	Until      time.Time // sim time
+}
+
+// trafficClock returns the clock position of the traffic relative to the
+// aircraft's heading.
+func trafficClock(ac, traffic *Aircraft) int {
+	hdg := headingp2ll(ac.Position(), traffic.Position(), ac.NmPerLongitude(), ac.MagneticVariation())
+	clock := int(NormalizeHeading(hdg-ac.Heading())/30+0.5) % 12
+	return Select(clock == 0, 12, clock)
+}
+
+// trafficDirections are the directions of flight that may be given in a
+// traffic advisory.
+var trafficDirections = []string{"OPP", "SAME", "N", "S", "E", "W"}
+
+// trafficDirectionMatches returns whether the traffic is flying in the
+// given direction: "OPP" or "SAME" for the opposite or same direction as
+// the aircraft, or "N", "S", "E", or "W" for northbound and so forth. Any
+// direction matches if none is given.
+func trafficDirectionMatches(ac, traffic *Aircraft, dir string) bool {
+	hdg := traffic.Heading()
+	switch dir {
+	case "OPP":
+		return headingDifference(hdg, ac.Heading()) >= 135
+	case "SAME":
+		return headingDifference(hdg, ac.Heading()) <= 45
+	case "N":
+		return headingDifference(hdg, 360) <= 45
+	case "S":
+		return headingDifference(hdg, 180) <= 45
+	case "E":
+		return headingDifference(hdg, 90) <= 45
+	case "W":
+		return headingDifference(hdg, 270) <= 45
+	default:
+		return true
+	}
+}
+
+// matchTraffic returns the aircraft that best matches the given traffic
+// advisory, if any. The altitude is in feet and is ignored if zero; it's
+// compared to the traffic's altitude as the controller sees it on the
+// radar. The direction is as for trafficDirectionMatches.
+func matchTraffic(w *World, ac *Aircraft, clock int, dist, alt float32, dir string) *Aircraft {
+	var best *Aircraft
+	bestErr := float32(0)
+	for _, t := range w.Aircraft {
+		if t == ac {
+			continue
+		}
+		if dc := abs(trafficClock(ac, t) - clock); min(dc, 12-dc) > 1 {
+			continue
+		}
+		if alt != 0 && abs(w.ModeCAltitude(t)-alt) > 1000 {
+			continue
+		}
+		if !trafficDirectionMatches(ac, t, dir) {
+			continue
+		}
+		derr := abs(nmdistance2ll(ac.Position(), t.Position()) - dist)
+		if derr > max(2, dist/2) {
+			continue
+		}
+		if best == nil || derr < bestErr {
+			best, bestErr = t, derr
+		}
+	}
+	return best
+}
+
+// trafficVisibility returns the range in nm at which the pilot of the
+// aircraft could spot the traffic and whether clouds block the view
+// entirely.
+func trafficVisibility(w *World, ac, traffic *Aircraft, now time.Time) (float32, bool) {
+	night := sunElevation(ac.Position(), now) < -6 // civil twilight
+	r := float32(Select(night, 4, 5))
+	if wc := traffic.Nav.Perf.WeightClass; wc == "H" || wc == "J" {
+		r += 2
+	}
+
+	icao := ""
+	if ac.FlightPlan != nil {
+		icao = Select(ac.IsDeparture(), ac.FlightPlan.DepartureAirport, ac.FlightPlan.ArrivalAirport)
+	}
+	if m := w.GetMETAR(icao); m != nil {
+		r = min(r, m.Visibility()*statuteMilesToNauticalMiles)
+
+		if ceiling, ok := m.Ceiling(); ok {
+			elevation := 0
+			if ap, ok := database.Airports[icao]; ok {
+				elevation = ap.Elevation
+			}
+			base := float32(elevation + ceiling)
+			inCloud := func(alt float32) bool { return alt >= base && alt <= base+cloudLayerThickness }
+			a0, a1 := ac.Altitude(), traffic.Altitude()
+			if inCloud(a0) || inCloud(a1) || (a0 < base) != (a1 < base) {
+				return r, true
+			}
+		}
+	}
+	return r, false
+}
+
+// canSightTraffic returns whether the pilot is able to spot the traffic
+// now; pilots can't see traffic behind them.
+func canSightTraffic(w *World, ac, traffic *Aircraft, now time.Time) bool {
+	if clock := trafficClock(ac, traffic); clock >= 5 && clock <= 7 {
+		return false
+	}
+	r, obscured := trafficVisibility(w, ac, traffic, now)
+	return !obscured && nmdistance2ll(ac.Position(), traffic.Position()) <= r
+}
+
+// sunElevation returns the elevation of the sun in degrees above the
+// horizon at the given point and time.
+func sunElevation(p Point2LL, t time.Time) float32 {
+	// https://aa.usno.navy.mil/faq/sun_approx
+	rad := func(d float64) float64 { return d / 180 * math.Pi }
+	d := t.Sub(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)).Hours() / 24
+	g := rad(357.529 + 0.98560028*d)
+	q := 280.459 + 0.98564736*d
+	l := rad(q + 1.915*math.Sin(g) + 0.020*math.Sin(2*g))
+	e := rad(23.439 - 0.00000036*d)
+	ra := math.Atan2(math.Cos(e)*math.Sin(l), math.Cos(l))
+	dec := math.Asin(math.Sin(e) * math.Sin(l))
+	gmst := rad(15 * (18.697374558 + 24.06570982441908*d))
+	ha := gmst + rad(float64(p[0])) - ra
+	lat := rad(float64(p[1]))
+	return float32(math.Asin(math.Sin(lat)*math.Sin(dec)+math.Cos(lat)*math.Cos(dec)*math.Cos(ha)) * 180 / math.Pi)
+}
+
+func (ac *Aircraft) addTrafficInSight(callsign string) {
+	if !slices.Contains(ac.TrafficInSight, callsign) {
+		ac.TrafficInSight = append(ac.TrafficInSight, callsign)
+	}
+}
+
+// TrafficAdvisory issues a traffic advisory to the aircraft; the altitude
+// is in hundreds of feet and is ignored if zero, and the direction is as
+// for trafficDirectionMatches.
+func (s *Sim) TrafficAdvisory(token, callsign string, clock, dist, alt int, dir string) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	return s.dispatchControllingCommand(token, callsign,
+		func(ctrl *Controller, ac *Aircraft) []RadioTransmission {
+			lo := &TrafficLookout{Controller: ctrl.Callsign, Until: s.SimTime.Add(trafficLookoutTime)}
+			if t := matchTraffic(s.World, ac, clock, float32(dist), float32(100*alt), dir); t != nil {
+				lo.Traffic = t.Callsign
+				// Sometimes it's right where the controller said it was.
+				if canSightTraffic(s.World, ac, t, s.SimTime) && rand.Float32() < 0.3 {
+					ac.addTrafficInSight(t.Callsign)
+					ac.TrafficLookout = nil
+					return ac.readback("traffic in sight")
+				}
+			}
+			ac.TrafficLookout = lo
+			return ac.readback(Sample("looking", "looking for the traffic", "we'll look"))
+		})
+}
+
+// MaintainVisualSeparation instructs the aircraft to maintain visual
+// separation from the traffic it most recently reported in sight. This is
+// only recorded and shown in the aircraft's route information; the
+// aircraft doesn't maneuver to remain clear of the traffic. The sim has
+// no separation checks of its own and, as with the real system, STARS
+// doesn't stop issuing conflict alerts for visually-separated aircraft;
+// the controller inhibits them for the pair with [CA][SLEW] if needed.
+func (s *Sim) MaintainVisualSeparation(token, callsign string) error {
+	s.mu.Lock(s.lg)
+	defer s.mu.Unlock(s.lg)
+
+	return s.dispatchControllingCommand(token, callsign,
+		func(ctrl *Controller, ac *Aircraft) []RadioTransmission {
+			if len(ac.TrafficInSight) == 0 {
+				return ac.readbackUnexpected("unable, we don't have the traffic in sight")
+			}
+			ac.VisualSeparation = ac.TrafficInSight[len(ac.TrafficInSight)-1]
+			return ac.readback("maintain visual separation")
+		})
+}
+
+// updateTraffic is called once a second for each aircraft; the pilot
+// looks for traffic they've been told about and loses sight of traffic
+// that's obscured or has moved away.
+func (s *Sim) updateTraffic(ac *Aircraft) {
+	ac.TrafficInSight = FilterSlice(ac.TrafficInSight, func(callsign string) bool {
+		t, ok := s.World.Aircraft[callsign]
+		if !ok {
+			return false
+		}
+		r, obscured := trafficVisibility(s.World, ac, t, s.SimTime)
+		return !obscured && nmdistance2ll(ac.Position(), t.Position()) <= 2*r
+	})
+	if ac.VisualSeparation != "" && !slices.Contains(ac.TrafficInSight, ac.VisualSeparation) {
+		if _, ok := s.World.Aircraft[ac.VisualSeparation]; ok {
+			PostRadioEvents(ac.Callsign, []RadioTransmission{RadioTransmission{
+				Controller: ac.ControllingController,
+				Message:    "we've lost sight of the traffic",
+				Type:       RadioTransmissionUnexpected,
+			}}, s)
+		}
+		ac.VisualSeparation = ""
+	}
+
+	lo := ac.TrafficLookout
+	if lo == nil {
+		return
+	}
+	report := func(msg string) {
+		ac.TrafficLookout = nil
+		if ac.ControllingController == lo.Controller {
+			PostRadioEvents(ac.Callsign, []RadioTransmission{RadioTransmission{
+				Controller: lo.Controller,
+				Message:    msg,
+				Type:       RadioTransmissionContact,
+			}}, s)
+		}
+	}
+
+	if t, ok := s.World.Aircraft[lo.Traffic]; ok && canSightTraffic(s.World, ac, t, s.SimTime) &&
+		rand.Float32() < 0.15 {
+		ac.addTrafficInSight(t.Callsign)
+		report("traffic in sight")
+	} else if !s.SimTime.Before(lo.Until) {
+		report("negative contact")
+	}
+}
@@ -0,0 +1,68 @@
+// traffic_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+)
+
+func TestMatchTraffic(t *testing.T) {
+	const nmPerLongitude = 45
+	ll := func(x, y float32) Point2LL {
+		return nm2ll([2]float32{x, y}, nmPerLongitude)
+	}
+	aircraft := func(callsign string, p Point2LL, heading float32) *Aircraft {
+		ac := &Aircraft{Callsign: callsign}
+		ac.Nav.FlightState.NmPerLongitude = nmPerLongitude
+		ac.Nav.FlightState.Position = p
+		ac.Nav.FlightState.Heading = heading
+		ac.Nav.FlightState.Altitude = 3000
+		return ac
+	}
+
+	// Northbound, with southbound traffic at its 2 o'clock and 5 miles
+	// and northbound traffic at its 10 o'clock and 5 miles.
+	ac := aircraft("AAL1", ll(0, 0), 360)
+	w := &World{Aircraft: map[string]*Aircraft{
+		"AAL1": ac,
+		"OPP1": aircraft("OPP1", ll(4.33, 2.5), 180),
+		"SAM1": aircraft("SAM1", ll(-4.33, 2.5), 10),
+	}}
+
+	for _, test := range []struct {
+		clock int
+		dir   string
+		match string
+	}{
+		{clock: 2, match: "OPP1"},
+		{clock: 2, dir: "OPP", match: "OPP1"},
+		{clock: 2, dir: "S", match: "OPP1"},
+		{clock: 2, dir: "SAME"},
+		{clock: 2, dir: "N"},
+		{clock: 10, dir: "SAME", match: "SAM1"},
+		{clock: 10, dir: "N", match: "SAM1"},
+		{clock: 10, dir: "OPP"},
+		{clock: 8, dir: "OPP"},
+		{clock: 4, dir: "E"},
+	} {
+		match := ""
+		if tr := matchTraffic(w, ac, test.clock, 5, 3000, test.dir); tr != nil {
+			match = tr.Callsign
+		}
+		if match != test.match {
+			t.Errorf("%d o'clock %q: got %q, expected %q", test.clock, test.dir, match, test.match)
+		}
+	}
+
+	// Traffic at FL240 with a low local altimeter setting is well below
+	// 24,000', but the controller sees FL240 on the radar.
+	hi := aircraft("HI1", ll(0, 5), 180)
+	hi.Nav.FlightState.Altitude = 24000
+	hi.Nav.FlightState.LocalAltimeter, hi.Nav.FlightState.Altimeter = 28.80, 29.92
+	w.Aircraft[hi.Callsign] = hi
+	if tr := matchTraffic(w, ac, 12, 5, 24000, ""); tr != hi {
+		t.Errorf("12 o'clock FL240: didn't match HI1")
+	}
+}
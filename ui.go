@@ -8,6 +8,7 @@ import (
 	"bytes"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image/png"
 	"log/slog"
@@ -43,6 +44,8 @@ var (
 		activeModalDialogs []*ModalDialogBox
 
 		newReleaseDialogChan chan *NewReleaseModalClient
+
+		scenarioEditorDialog *FileSelectDialogBox
 	}
 
 	//go:embed icons/tower-256x256.png
@@ -283,6 +286,9 @@ func drawUI(p Platform, r Renderer, w *World, eventStream *EventStream, stats *S
 		imgui.PushStyleColor(imgui.StyleColorButton, imgui.CurrentStyle().Color(imgui.StyleColorMenuBarBg))
 
 		if w != nil && w.Connected() {
+			// Only the instructor controls the sim if there is one.
+			disablePause := w.HaveInstructor && !w.Instructor
+			uiStartDisable(disablePause)
 			if w.SimIsPaused {
 				if imgui.Button(FontAwesomeIconPlayCircle) {
 					w.ToggleSimPause()
@@ -298,6 +304,7 @@ func drawUI(p Platform, r Renderer, w *World, eventStream *EventStream, stats *S
 					imgui.SetTooltip("Pause simulation")
 				}
 			}
+			uiEndDisable(disablePause)
 		}
 
 		if imgui.Button(FontAwesomeIconRedo) {
@@ -321,6 +328,21 @@ func drawUI(p Platform, r Renderer, w *World, eventStream *EventStream, stats *S
 			if imgui.IsItemHovered() {
 				imgui.SetTooltip("Show available departures, arrivals, and approaches")
 			}
+
+			if imgui.Button(FontAwesomeIconDrawPolygon) && wm.scenarioEditor == nil {
+				ui.scenarioEditorDialog = NewFileSelectDialogBox("Edit Scenario", []string{".json"}, *scenarioFilename,
+					func(filename string) {
+						if ep, err := NewScenarioEditorPane(filename); err != nil {
+							ShowErrorDialog("%s: %v", filename, err)
+						} else {
+							wm.scenarioEditor = ep
+						}
+					})
+				ui.scenarioEditorDialog.Activate()
+			}
+			if imgui.IsItemHovered() {
+				imgui.SetTooltip("Edit a scenario file")
+			}
 		}
 
 		if imgui.Button(FontAwesomeIconKeyboard) {
@@ -381,7 +403,26 @@ func drawUI(p Platform, r Renderer, w *World, eventStream *EventStream, stats *S
 
 		w.DrawScenarioInfoWindow()
 
-		w.DrawMissingPrimaryDialog()
+		if ui.scenarioEditorDialog != nil {
+			ui.scenarioEditorDialog.Draw()
+		}
+		if wm.scenarioEditor != nil && !wm.scenarioEditor.DrawWindow() {
+			wm.scenarioEditor = nil
+		}
+
+		if w.Instructor {
+			if w.instructorWindow == nil {
+				w.instructorWindow = MakeInstructorWindow(w)
+			}
+			w.instructorWindow.Draw(w)
+		} else if w.PseudoPilot {
+			if w.pseudoPilotWindow == nil {
+				w.pseudoPilotWindow = MakePseudoPilotWindow()
+			}
+			w.pseudoPilotWindow.Draw(w)
+		} else {
+			w.DrawMissingPrimaryDialog()
+		}
 
 		if w.LaunchConfig.Controller == w.Callsign {
 			if w.launchControlWindow == nil {
@@ -1526,6 +1567,7 @@ type LaunchControlWindow struct {
 	w          *World
 	departures []*LaunchDeparture
 	arrivals   []*LaunchArrival
+	err        error
 }
 
 type LaunchDeparture struct {
@@ -1590,24 +1632,40 @@ func MakeLaunchControlWindow(w *World) *LaunchControlWindow {
 	return lc
 }
 
+// spawnDeparture and spawnArrival create the next aircraft to be
+// launched; they return nil and record the error if they're unable to,
+// e.g. because no beacon codes are available.
 func (lc *LaunchControlWindow) spawnDeparture(airport, rwy, category string) *Aircraft {
+	var err error
 	for i := 0; i < 100; i++ {
-		if ac, _, err := lc.w.CreateDeparture(airport, rwy, category, 0, nil); err == nil {
+		var ac *Aircraft
+		if ac, _, err = lc.w.CreateDeparture(airport, rwy, category, 0, nil); err == nil {
 			return ac
+		} else if errors.Is(err, ErrNoBeaconCodesAvailable) {
+			break
 		}
 	}
-	panic("unable to spawn a departure")
+	lc.err = err
+	return nil
 }
 
 func (lc *LaunchControlWindow) spawnArrival(group, airport string) *Aircraft {
+	var err error
 	for i := 0; i < 100; i++ {
-		goAround := rand.Float32() < lc.w.LaunchConfig.GoAroundRate
-
-		if ac, err := lc.w.CreateArrival(group, airport, goAround); err == nil {
+		var ac *Aircraft
+		if ac, err = lc.w.CreateArrival(group, airport); err == nil {
 			return ac
+		} else if errors.Is(err, ErrNoBeaconCodesAvailable) {
+			break
 		}
 	}
-	panic("unable to spawn an arrival")
+	lc.err = err
+	return nil
+}
+
+func (lc *LaunchControlWindow) launch(ac *Aircraft) {
+	lc.err = nil
+	lc.w.LaunchAircraft(*ac, func(err error) { lc.err = err })
 }
 
 func (lc *LaunchControlWindow) Draw(w *World, eventStream *EventStream) {
@@ -1720,30 +1778,38 @@ func (lc *LaunchControlWindow) Draw(w *World, eventStream *EventStream) {
 				imgui.TableNextColumn()
 				imgui.Text(strconv.Itoa(dep.TotalLaunches))
 
-				imgui.TableNextColumn()
-				imgui.Text(dep.Aircraft.Callsign)
+				if dep.Aircraft == nil {
+					// Leave the row empty except for the respawn button.
+					for i := 0; i < 6; i++ {
+						imgui.TableNextColumn()
+					}
+				} else {
+					imgui.TableNextColumn()
+					imgui.Text(dep.Aircraft.Callsign)
 
-				imgui.TableNextColumn()
-				imgui.Text(dep.Aircraft.FlightPlan.TypeWithoutSuffix())
+					imgui.TableNextColumn()
+					imgui.Text(dep.Aircraft.FlightPlan.TypeWithoutSuffix())
 
-				imgui.TableNextColumn()
-				imgui.Text(dep.Aircraft.Scratchpad)
+					imgui.TableNextColumn()
+					imgui.Text(dep.Aircraft.Scratchpad)
 
-				mitAndTime(dep.Aircraft, dep.Aircraft.Position(), dep.LastLaunchCallsign,
-					dep.LastLaunchTime)
+					mitAndTime(dep.Aircraft, dep.Aircraft.Position(), dep.LastLaunchCallsign,
+						dep.LastLaunchTime)
 
-				imgui.TableNextColumn()
-				if imgui.Button(FontAwesomeIconPlaneDeparture) {
-					lc.w.LaunchAircraft(*dep.Aircraft)
-					dep.LastLaunchCallsign = dep.Aircraft.Callsign
-					dep.LastLaunchTime = lc.w.CurrentTime()
-					dep.TotalLaunches++
+					imgui.TableNextColumn()
+					if imgui.Button(FontAwesomeIconPlaneDeparture) {
+						lc.launch(dep.Aircraft)
+						dep.LastLaunchCallsign = dep.Aircraft.Callsign
+						dep.LastLaunchTime = lc.w.CurrentTime()
+						dep.TotalLaunches++
 
-					dep.Aircraft = lc.spawnDeparture(dep.Airport, dep.Runway, dep.Category)
+						dep.Aircraft = lc.spawnDeparture(dep.Airport, dep.Runway, dep.Category)
+					}
 				}
 
 				imgui.TableNextColumn()
 				if imgui.Button(FontAwesomeIconRedo) {
+					lc.err = nil
 					dep.Aircraft = lc.spawnDeparture(dep.Airport, dep.Runway, dep.Category)
 				}
 
@@ -1784,27 +1850,34 @@ func (lc *LaunchControlWindow) Draw(w *World, eventStream *EventStream) {
 				imgui.TableNextColumn()
 				imgui.Text(arr.Airport)
 
-				imgui.TableNextColumn()
-				imgui.Text(arr.Aircraft.Callsign)
+				if arr.Aircraft == nil {
+					for i := 0; i < 5; i++ {
+						imgui.TableNextColumn()
+					}
+				} else {
+					imgui.TableNextColumn()
+					imgui.Text(arr.Aircraft.Callsign)
 
-				imgui.TableNextColumn()
-				imgui.Text(arr.Aircraft.FlightPlan.TypeWithoutSuffix())
+					imgui.TableNextColumn()
+					imgui.Text(arr.Aircraft.FlightPlan.TypeWithoutSuffix())
 
-				mitAndTime(arr.Aircraft, arr.Aircraft.Position(), arr.LastLaunchCallsign,
-					arr.LastLaunchTime)
+					mitAndTime(arr.Aircraft, arr.Aircraft.Position(), arr.LastLaunchCallsign,
+						arr.LastLaunchTime)
 
-				imgui.TableNextColumn()
-				if imgui.Button(FontAwesomeIconPlaneDeparture) {
-					lc.w.LaunchAircraft(*arr.Aircraft)
-					arr.LastLaunchCallsign = arr.Aircraft.Callsign
-					arr.LastLaunchTime = lc.w.CurrentTime()
-					arr.TotalLaunches++
+					imgui.TableNextColumn()
+					if imgui.Button(FontAwesomeIconPlaneDeparture) {
+						lc.launch(arr.Aircraft)
+						arr.LastLaunchCallsign = arr.Aircraft.Callsign
+						arr.LastLaunchTime = lc.w.CurrentTime()
+						arr.TotalLaunches++
 
-					arr.Aircraft = lc.spawnArrival(arr.Group, arr.Airport)
+						arr.Aircraft = lc.spawnArrival(arr.Group, arr.Airport)
+					}
 				}
 
 				imgui.TableNextColumn()
 				if imgui.Button(FontAwesomeIconRedo) {
+					lc.err = nil
 					arr.Aircraft = lc.spawnArrival(arr.Group, arr.Airport)
 				}
 
@@ -1828,6 +1901,12 @@ func (lc *LaunchControlWindow) Draw(w *World, eventStream *EventStream) {
 		}
 	}
 
+	if lc.err != nil {
+		imgui.PushStyleColor(imgui.StyleColorText, imgui.Vec4{1, .5, .5, 1})
+		imgui.Text(lc.err.Error())
+		imgui.PopStyleColor()
+	}
+
 	imgui.End()
 
 	if !showLaunchControls {
@@ -1881,6 +1960,7 @@ Either one or both of *A* and *S* may be specified.`, "*CCAMRN/A110+*"},
 	[3]string{"*CSI_appr", `"Cleared straight-in _appr_ approach.`, "*CSII6*"},
 	[3]string{"*I*", `"Intercept the localizer."`, "*I*"},
 	[3]string{"*ID*", `"Ident."`, "*ID*"},
+	[3]string{"*ALT_setting", `"Altimeter _setting_."`, "*ALT2992*"},
 	[3]string{"*CVS*", `"Climb via the SID"`, "*CVS*"},
 	[3]string{"*DVS*", `"Descend via the STAR"`, "*CVS*"},
 }
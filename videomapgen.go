@@ -0,0 +1,210 @@
+// videomapgen.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Generation of basic video maps for an airport from the CIFP: runways
+// with extended centerlines, final approach courses, SIDs, STARs, and the
+// fixes and navaids around the airport. These, along with the MVAs, are
+// enough to work a new facility before proper video maps are available.
+
+const (
+	videoMapFinalLength       = 10 // nm, for extended runway centerlines
+	videoMapLocalizerLength   = 15 // nm
+	videoMapTickLength        = 0.15
+	videoMapLongTickLength    = 0.3 // every 5nm
+	videoMapFixSize           = 0.6
+	videoMapNavaidSize        = 0.75
+	videoMapNavaidMaxDistance = 40
+)
+
+// videoMapBuilder accumulates line segments, working in nm coordinates.
+type videoMapBuilder struct {
+	nmPerLongitude float32
+	segs           []Point2LL
+}
+
+func (vb *videoMapBuilder) line(p0, p1 [2]float32) {
+	vb.segs = append(vb.segs, nm2ll(p0, vb.nmPerLongitude), nm2ll(p1, vb.nmPerLongitude))
+}
+
+func (vb *videoMapBuilder) polyline(pts []Point2LL) {
+	vb.segs = addPolyline(vb.segs, pts)
+}
+
+// polygon draws a regular polygon with the given number of sides around p.
+func (vb *videoMapBuilder) polygon(p Point2LL, radius float32, sides int) {
+	pc := ll2nm(p, vb.nmPerLongitude)
+	vertex := func(i int) [2]float32 {
+		a := radians(float32(i) * 360 / float32(sides))
+		return add2f(pc, [2]float32{radius * sin(a), radius * cos(a)})
+	}
+	for i := 0; i < sides; i++ {
+		vb.line(vertex(i), vertex(i+1))
+	}
+}
+
+func (vb *videoMapBuilder) triangle(p Point2LL, height float32) {
+	pc := ll2nm(p, vb.nmPerLongitude)
+	v := EquilateralTriangleVertices(height)
+	for i := range v {
+		vb.line(add2f(pc, v[i]), add2f(pc, v[(i+1)%3]))
+	}
+}
+
+// GenerateVideoMaps returns video maps for the given airport, generated
+// from the CIFP.
+func GenerateVideoMaps(icao string) ([]ImportedVideoMap, error) {
+	ap, ok := database.Airports[icao]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAirport, icao)
+	}
+
+	nmPerLongitude := nmPerLatitude * cos(radians(ap.Location[1]))
+	newBuilder := func() *videoMapBuilder { return &videoMapBuilder{nmPerLongitude: nmPerLongitude} }
+
+	// Runway fixes in procedures are given as e.g. "RW04R".
+	locate := func(fix string) (Point2LL, bool) {
+		if rwy, ok := strings.CutPrefix(fix, "RW"); ok {
+			if r, ok := LookupRunway(icao, strings.TrimPrefix(rwy, "0")); ok {
+				return r.Threshold, true
+			}
+		}
+		return database.LookupWaypoint(fix)
+	}
+	fixes := make(map[string]Point2LL)
+	route := func(wps WaypointArray) []Point2LL {
+		var pts []Point2LL
+		for _, wp := range wps {
+			p := wp.Location
+			if p.IsZero() {
+				var ok bool
+				if p, ok = locate(wp.Fix); !ok {
+					continue
+				}
+			}
+			if !strings.HasPrefix(wp.Fix, "RW") {
+				fixes[wp.Fix] = p
+			}
+			pts = append(pts, p)
+		}
+		return pts
+	}
+
+	// Runways: each is drawn once, from the end whose id sorts first as a
+	// string (so "22L" before "4R"), and gets extended centerlines with
+	// tick marks every mile past both ends.
+	runways := newBuilder()
+	for _, rwy := range ap.Runways {
+		opp, ok := oppositeRunway(ap.Runways, rwy.Id)
+		if !ok {
+			continue
+		}
+		p0, p1 := ll2nm(rwy.Threshold, nmPerLongitude), ll2nm(opp.Threshold, nmPerLongitude)
+		if rwy.Id < opp.Id {
+			runways.line(p0, p1)
+		}
+
+		dir := normalize2f(sub2f(p0, p1)) // outbound from the threshold
+		perp := [2]float32{-dir[1], dir[0]}
+		runways.line(p0, add2f(p0, scale2f(dir, videoMapFinalLength)))
+		for d := 1; d <= videoMapFinalLength; d++ {
+			l := float32(Select(d%5 == 0, videoMapLongTickLength, videoMapTickLength))
+			pt := add2f(p0, scale2f(dir, float32(d)))
+			runways.line(add2f(pt, scale2f(perp, l)), sub2f(pt, scale2f(perp, l)))
+		}
+	}
+
+	// Final approach courses: localizers for runways that have them and
+	// the final segment of the approaches to the others.
+	finals := newBuilder()
+	for _, rwy := range SortedMapKeys(ap.Localizers) {
+		loc := ap.Localizers[rwy]
+		thr, ok := LookupRunway(icao, rwy)
+		if !ok {
+			continue
+		}
+		// As in Approach.Line(), start at the point abeam the threshold.
+		dir := [2]float32{sin(radians(loc.TrueCourse)), cos(radians(loc.TrueCourse))}
+		p := ll2nm(loc.Location, nmPerLongitude)
+		p1 := add2f(p, scale2f(dir, dot(sub2f(ll2nm(thr.Threshold, nmPerLongitude), p), dir)))
+		finals.line(p1, sub2f(p1, scale2f(dir, videoMapLocalizerLength)))
+	}
+	for _, name := range SortedMapKeys(ap.Approaches) {
+		for _, wps := range ap.Approaches[name] {
+			n := len(wps)
+			if n < 2 || !strings.HasPrefix(wps[n-1].Fix, "RW") {
+				continue
+			}
+			rwy := strings.TrimPrefix(strings.TrimPrefix(wps[n-1].Fix, "RW"), "0")
+			if _, ok := ap.Localizers[rwy]; ok {
+				continue
+			}
+			finals.polyline(route(wps[n-2:]))
+		}
+	}
+
+	sids, stars := newBuilder(), newBuilder()
+	for _, name := range SortedMapKeys(ap.SIDs) {
+		sid := ap.SIDs[name]
+		for _, tr := range SortedMapKeys(sid.Transitions) {
+			sids.polyline(route(sid.Transitions[tr]))
+		}
+	}
+	for _, name := range SortedMapKeys(ap.STARs) {
+		star := ap.STARs[name]
+		for _, tr := range SortedMapKeys(star.Transitions) {
+			stars.polyline(route(star.Transitions[tr]))
+		}
+		for _, rwy := range SortedMapKeys(star.RunwayWaypoints) {
+			stars.polyline(route(star.RunwayWaypoints[rwy]))
+		}
+	}
+
+	// Fixes are the ones along the procedures drawn above.
+	fixesMap := newBuilder()
+	for _, fix := range SortedMapKeys(fixes) {
+		fixesMap.triangle(fixes[fix], videoMapFixSize)
+	}
+
+	navaids := newBuilder()
+	for _, id := range SortedMapKeys(database.Navaids) {
+		if n := database.Navaids[id]; nmdistance2ll(ap.Location, n.Location) <= videoMapNavaidMaxDistance {
+			navaids.polygon(n.Location, videoMapNavaidSize, 6)
+		}
+	}
+
+	// Labels are limited to 8 characters in the DCB.
+	short := strings.TrimPrefix(icao, "K")
+	vm := func(name, label, group string, vb *videoMapBuilder) ImportedVideoMap {
+		label = short + " " + label
+		if len(label) > 8 {
+			label = label[:8]
+		}
+		return makeImportedVideoMap(icao+" "+name, label, group, 0, vb.segs)
+	}
+	return []ImportedVideoMap{
+		vm("RUNWAYS", "RWY", "A", runways),
+		vm("FINALS", "FAC", "A", finals),
+		vm("SIDS", "SID", "B", sids),
+		vm("STARS", "STAR", "B", stars),
+		vm("FIXES", "FIX", "B", fixesMap),
+		vm("NAVAIDS", "NAV", "B", navaids),
+	}, nil
+}
+
+// GenerateVideoMapFile writes the video maps generated for the airport to
+// the given file, printing the "stars_maps" entries for them.
+func GenerateVideoMapFile(icao, filename string) error {
+	maps, err := GenerateVideoMaps(icao)
+	if err != nil {
+		return err
+	}
+	return saveVideoMaps(filename, maps)
+}
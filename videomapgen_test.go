@@ -0,0 +1,73 @@
+// videomapgen_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+)
+
+func TestGenerateVideoMaps(t *testing.T) {
+	saved := database
+	defer func() { database = saved }()
+
+	database = &StaticDatabase{
+		Airports: map[string]FAAAirport{
+			"KJFK": FAAAirport{
+				Location: Point2LL{-73.78, 40.64},
+				Runways: []Runway{
+					Runway{Id: "4R", Threshold: Point2LL{-73.79, 40.62}},
+					Runway{Id: "22L", Threshold: Point2LL{-73.77, 40.65}},
+				},
+				Approaches: map[string][]WaypointArray{
+					"R22L": []WaypointArray{WaypointArray{Waypoint{Fix: "KARRS"}, Waypoint{Fix: "RW22L"}}},
+				},
+				STARs: map[string]STAR{"CAMRN5": STAR{Transitions: map[string]WaypointArray{
+					"": WaypointArray{Waypoint{Fix: "CAMRN"}, Waypoint{Fix: "KARRS"}, Waypoint{Fix: "UNKNOWN"}},
+				}}},
+			},
+		},
+		Navaids: map[string]Navaid{
+			"JFK": Navaid{Id: "JFK", Location: Point2LL{-73.80, 40.63}},
+			"BOS": Navaid{Id: "BOS", Location: Point2LL{-70.99, 42.36}},
+		},
+		Fixes: map[string]Fix{
+			"CAMRN": Fix{Id: "CAMRN", Location: Point2LL{-73.86, 40.02}},
+			"KARRS": Fix{Id: "KARRS", Location: Point2LL{-73.88, 40.35}},
+		},
+	}
+
+	if _, err := GenerateVideoMaps("KBOS"); err == nil {
+		t.Errorf("expected error for unknown airport")
+	}
+
+	maps, err := GenerateVideoMaps("KJFK")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nsegs := make(map[string]int)
+	for _, m := range maps {
+		if len(m.Label) > 8 {
+			t.Errorf("%s: label %q is too long", m.Name, m.Label)
+		}
+		if len(m.Segments)%2 != 0 {
+			t.Errorf("%s: odd number of segment vertices", m.Name)
+		}
+		nsegs[m.Name] = len(m.Segments) / 2
+	}
+
+	expected := map[string]int{
+		"KJFK RUNWAYS": 1 + 2*(1+videoMapFinalLength), // runway, two extended centerlines with ticks
+		"KJFK FINALS":  1,
+		"KJFK SIDS":    0,
+		"KJFK STARS":   1,
+		"KJFK FIXES":   2 * 3,
+		"KJFK NAVAIDS": 6, // BOS is too far away
+	}
+	for name, n := range expected {
+		if nsegs[name] != n {
+			t.Errorf("%s: got %d segments, expected %d", name, nsegs[name], n)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+// videomapimport.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Importers for video maps in GeoJSON, ESRI shapefile, and the XML format
+// used by vSTARS and CRC; they are converted to vice's video map format
+// along with the "stars_maps" entries for them.
+
+// ImportedVideoMap is a video map read from another format. Segments
+// holds pairs of points, each of which gives a line to be drawn, as in
+// vice's video map files.
+type ImportedVideoMap struct {
+	STARSMap
+	Id       int // STARS map number, if known
+	Segments []Point2LL
+}
+
+var ErrUnknownVideoMapFormat = errors.New("Unknown video map file format")
+
+// ImportVideoMaps reads the video maps in the given file, using its
+// extension to determine its format. GeoJSON files and shapefiles hold a
+// single map, named after the file; XML files may have many.
+func ImportVideoMaps(filename string) ([]ImportedVideoMap, error) {
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".geojson", ".json":
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		return importGeoJSONVideoMap(b, name)
+
+	case ".shp":
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		segs, err := parseShapefile(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		return []ImportedVideoMap{makeImportedVideoMap(name, "", "", 0, segs)}, nil
+
+	case ".xml":
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		return importXMLVideoMaps(b)
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownVideoMapFormat, filename)
+	}
+}
+
+// makeImportedVideoMap fills in defaults for the label and brightness
+// group if they aren't specified.
+func makeImportedVideoMap(name, label, group string, id int, segs []Point2LL) ImportedVideoMap {
+	if label == "" {
+		label = strings.ToUpper(name)
+		if len(label) > 8 {
+			label = label[:8]
+		}
+	}
+	return ImportedVideoMap{
+		STARSMap: STARSMap{
+			Name:  name,
+			Label: label,
+			Group: Select(strings.EqualFold(group, "B"), 1, 0),
+		},
+		Id:       id,
+		Segments: segs,
+	}
+}
+
+// addPolyline adds the line segments along the polyline to segs.
+func addPolyline(segs []Point2LL, pts []Point2LL) []Point2LL {
+	for i := 0; i+1 < len(pts); i++ {
+		segs = append(segs, pts[i], pts[i+1])
+	}
+	return segs
+}
+
+///////////////////////////////////////////////////////////////////////////
+// GeoJSON
+
+// importGeoJSONVideoMap converts the lines and polygons in a GeoJSON
+// FeatureCollection to a video map; points are ignored. The map's label,
+// brightness category, and STARS id may be given in the collection's
+// "properties" using the names that CRC uses.
+func importGeoJSONVideoMap(b []byte, name string) ([]ImportedVideoMap, error) {
+	type geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	}
+	var fc struct {
+		Type       string `json:"type"`
+		Properties struct {
+			Name                    string `json:"name"`
+			ShortName               string `json:"shortName"`
+			StarsBrightnessCategory string `json:"starsBrightnessCategory"`
+			StarsId                 int    `json:"starsId"`
+		} `json:"properties"`
+		Features []struct {
+			Geometry geometry `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(b, &fc); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if fc.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("%s: expected a GeoJSON FeatureCollection", name)
+	}
+
+	toLL := func(c [][]float64) []Point2LL {
+		return MapSlice(c, func(p []float64) Point2LL {
+			if len(p) < 2 {
+				return Point2LL{}
+			}
+			return Point2LL{float32(p[0]), float32(p[1])}
+		})
+	}
+
+	var segs []Point2LL
+	for _, f := range fc.Features {
+		g := f.Geometry
+		var err error
+		switch g.Type {
+		case "LineString":
+			var c [][]float64
+			if err = json.Unmarshal(g.Coordinates, &c); err == nil {
+				segs = addPolyline(segs, toLL(c))
+			}
+		case "MultiLineString", "Polygon":
+			var c [][][]float64
+			if err = json.Unmarshal(g.Coordinates, &c); err == nil {
+				for _, l := range c {
+					segs = addPolyline(segs, toLL(l))
+				}
+			}
+		case "MultiPolygon":
+			var c [][][][]float64
+			if err = json.Unmarshal(g.Coordinates, &c); err == nil {
+				for _, poly := range c {
+					for _, l := range poly {
+						segs = addPolyline(segs, toLL(l))
+					}
+				}
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", name, g.Type, err)
+		}
+	}
+
+	if fc.Properties.Name != "" {
+		name = fc.Properties.Name
+	}
+	p := fc.Properties
+	return []ImportedVideoMap{makeImportedVideoMap(name, p.ShortName, p.StarsBrightnessCategory, p.StarsId, segs)}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////
+// Shapefiles
+
+// parseShapefile returns the line segments for the polylines and
+// polygons in an ESRI shapefile (the .shp file); other shapes are
+// ignored. Coordinates must be in longitude-latitude.
+// https://www.esri.com/content/dam/esrisites/sitecore-archive/Files/Pdfs/library/whitepapers/pdfs/shapefile.pdf
+func parseShapefile(b []byte) ([]Point2LL, error) {
+	if len(b) < 100 || binary.BigEndian.Uint32(b[0:4]) != 9994 {
+		return nil, errors.New("not a shapefile")
+	}
+
+	var segs []Point2LL
+	for pos := 100; pos+8 <= len(b); {
+		// Record header; the length is in 16-bit words.
+		length := 2 * int(binary.BigEndian.Uint32(b[pos+4:pos+8]))
+		rec := b[pos+8:]
+		if len(rec) < length || length < 4 {
+			return nil, errors.New("truncated shapefile")
+		}
+		rec = rec[:length]
+		pos += 8 + length
+
+		switch binary.LittleEndian.Uint32(rec[0:4]) {
+		case 3, 5, 13, 15, 23, 25: // PolyLine, Polygon, and their Z and M variants
+			if len(rec) < 44 {
+				return nil, errors.New("truncated shapefile record")
+			}
+			nparts := int(binary.LittleEndian.Uint32(rec[36:40]))
+			npoints := int(binary.LittleEndian.Uint32(rec[40:44]))
+			partsStart, pointsStart := 44, 44+4*nparts
+			if len(rec) < pointsStart+16*npoints {
+				return nil, errors.New("truncated shapefile record")
+			}
+
+			point := func(i int) Point2LL {
+				p := rec[pointsStart+16*i:]
+				x := math.Float64frombits(binary.LittleEndian.Uint64(p[0:8]))
+				y := math.Float64frombits(binary.LittleEndian.Uint64(p[8:16]))
+				return Point2LL{float32(x), float32(y)}
+			}
+			for part := 0; part < nparts; part++ {
+				start := int(binary.LittleEndian.Uint32(rec[partsStart+4*part:]))
+				end := npoints
+				if part+1 < nparts {
+					end = int(binary.LittleEndian.Uint32(rec[partsStart+4*(part+1):]))
+				}
+				if start < 0 || end > npoints || start > end {
+					return nil, errors.New("invalid shapefile part")
+				}
+				for i := start; i+1 < end; i++ {
+					segs = append(segs, point(i), point(i+1))
+				}
+			}
+		}
+	}
+	return segs, nil
+}
+
+///////////////////////////////////////////////////////////////////////////
+// XML
+
+// importXMLVideoMaps converts the video maps in a vSTARS/CRC XML video map
+// file; only line elements are converted.
+func importXMLVideoMaps(b []byte) ([]ImportedVideoMap, error) {
+	var doc struct {
+		VideoMaps []struct {
+			ShortName  string `xml:"ShortName,attr"`
+			LongName   string `xml:"LongName,attr"`
+			STARSGroup string `xml:"STARSGroup,attr"`
+			STARSId    string `xml:"STARSId,attr"`
+			Elements   []struct {
+				Type     string `xml:"type,attr"`
+				StartLat string `xml:"StartLat,attr"`
+				StartLon string `xml:"StartLon,attr"`
+				EndLat   string `xml:"EndLat,attr"`
+				EndLon   string `xml:"EndLon,attr"`
+			} `xml:"Elements>Element"`
+		} `xml:"VideoMap"`
+	}
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	var maps []ImportedVideoMap
+	for _, vm := range doc.VideoMaps {
+		name := Select(vm.LongName != "", vm.LongName, vm.ShortName)
+		var segs []Point2LL
+		for _, e := range vm.Elements {
+			if e.Type != "Line" {
+				continue
+			}
+			var ll [4]float64
+			for i, s := range []string{e.StartLat, e.StartLon, e.EndLat, e.EndLon} {
+				v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", name, err)
+				}
+				ll[i] = v
+			}
+			segs = append(segs, Point2LL{float32(ll[1]), float32(ll[0])}, Point2LL{float32(ll[3]), float32(ll[2])})
+		}
+		id, _ := strconv.Atoi(vm.STARSId)
+		maps = append(maps, makeImportedVideoMap(name, vm.ShortName, vm.STARSGroup, id, segs))
+	}
+	return maps, nil
+}
+
+///////////////////////////////////////////////////////////////////////////
+
+// WriteVideoMapFile writes the maps in vice's video map format; the file
+// is compressed if its name ends in ".zst". The "stars_maps" entries for
+// the maps, ordered by their STARS map numbers, are returned.
+func WriteVideoMapFile(filename string, maps []ImportedVideoMap) ([]STARSMap, error) {
+	vm := make(map[string][]Point2LL)
+	for _, m := range maps {
+		if _, ok := vm[m.Name]; ok {
+			return nil, fmt.Errorf("%s: video map name is used more than once", m.Name)
+		}
+		vm[m.Name] = m.Segments
+	}
+
+	b, err := json.MarshalIndent(vm, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(strings.ToLower(filename), ".zst") {
+		var buf bytes.Buffer
+		zw, err := zstd.NewWriter(&buf)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := zw.Write(b); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		b = buf.Bytes()
+	}
+	if err := os.WriteFile(filename, b, 0o644); err != nil {
+		return nil, err
+	}
+
+	// Maps with STARS ids come first, in order, so that their numbering
+	// in the DCB matches.
+	sorted := DuplicateSlice(maps)
+	slices.SortStableFunc(sorted, func(a, b ImportedVideoMap) int {
+		if a.Id == 0 || b.Id == 0 {
+			return Select(a.Id == b.Id, 0, Select(a.Id == 0, 1, -1))
+		}
+		return a.Id - b.Id
+	})
+	return MapSlice(sorted, func(m ImportedVideoMap) STARSMap { return m.STARSMap }), nil
+}
+
+// ConvertVideoMaps reads video maps from the given files and writes them
+// to a vice video map file, printing the "stars_maps" entries for them.
+func ConvertVideoMaps(filename string, inputs []string) error {
+	var maps []ImportedVideoMap
+	for _, in := range inputs {
+		m, err := ImportVideoMaps(in)
+		if err != nil {
+			return err
+		}
+		maps = append(maps, m...)
+	}
+	return saveVideoMaps(filename, maps)
+}
+
+// saveVideoMaps writes the maps to the given file and prints their
+// "stars_maps" entries.
+func saveVideoMaps(filename string, maps []ImportedVideoMap) error {
+	sm, err := WriteVideoMapFile(filename, maps)
+	if err != nil {
+		return err
+	}
+	// Only print the fields that are specified in scenario files.
+	type starsMap struct {
+		Label string `json:"label"`
+		Group int    `json:"group"`
+		Name  string `json:"name"`
+	}
+	b, err := json.MarshalIndent(struct {
+		Maps          []starsMap `json:"stars_maps"`
+		VideoMapsFile string     `json:"video_map_file"`
+	}{
+		Maps:          MapSlice(sm, func(m STARSMap) starsMap { return starsMap{m.Label, m.Group, m.Name} }),
+		VideoMapsFile: filepath.Base(filename),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
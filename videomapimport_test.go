@@ -0,0 +1,199 @@
+// videomapimport_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestImportGeoJSONVideoMap(t *testing.T) {
+	gj := `{ "type": "FeatureCollection",
+  "properties": { "name": "PHL RWYS", "shortName": "RWYS", "starsBrightnessCategory": "B", "starsId": 12 },
+  "features": [
+    { "type": "Feature", "geometry": { "type": "Point", "coordinates": [-75, 40] } },
+    { "type": "Feature", "geometry": { "type": "LineString", "coordinates": [[-75, 40], [-75.5, 40], [-75.5, 40.5]] } },
+    { "type": "Feature", "geometry": { "type": "MultiPolygon", "coordinates": [[[[-74, 39], [-74, 38], [-74, 39]]]] } }
+  ] }`
+
+	maps, err := importGeoJSONVideoMap([]byte(gj), "phl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(maps) != 1 {
+		t.Fatalf("expected 1 map, got %d", len(maps))
+	}
+	m := maps[0]
+	if m.Name != "PHL RWYS" || m.Label != "RWYS" || m.Group != 1 || m.Id != 12 {
+		t.Errorf("unexpected map metadata %+v", m.STARSMap)
+	}
+	expected := []Point2LL{{-75, 40}, {-75.5, 40}, {-75.5, 40}, {-75.5, 40.5},
+		{-74, 39}, {-74, 38}, {-74, 38}, {-74, 39}}
+	if !slices.Equal(m.Segments, expected) {
+		t.Errorf("got segments %v, expected %v", m.Segments, expected)
+	}
+
+	if _, err := importGeoJSONVideoMap([]byte(`{"type": "Feature"}`), "x"); err == nil {
+		t.Errorf("expected error for non-FeatureCollection")
+	}
+}
+
+func TestImportXMLVideoMaps(t *testing.T) {
+	x := `<?xml version="1.0"?>
+<VideoMaps xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <VideoMap ShortName="MVA" LongName="PHL MVA" STARSGroup="A" STARSId="4">
+    <Elements>
+      <Element xsi:type="Line" StartLat="40.1" StartLon="-75.2" EndLat="40.3" EndLon="-75.4" />
+      <Element xsi:type="Text" Lat="40.1" Lon="-75.2" Lines="30" />
+    </Elements>
+  </VideoMap>
+  <VideoMap ShortName="GEO" STARSGroup="B">
+    <Elements />
+  </VideoMap>
+</VideoMaps>`
+
+	maps, err := importXMLVideoMaps([]byte(x))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(maps) != 2 {
+		t.Fatalf("expected 2 maps, got %d", len(maps))
+	}
+	if m := maps[0]; m.Name != "PHL MVA" || m.Label != "MVA" || m.Group != 0 || m.Id != 4 {
+		t.Errorf("unexpected map metadata %+v", m.STARSMap)
+	} else if expected := []Point2LL{{-75.2, 40.1}, {-75.4, 40.3}}; !slices.Equal(m.Segments, expected) {
+		t.Errorf("got segments %v, expected %v", m.Segments, expected)
+	}
+	if m := maps[1]; m.Name != "GEO" || m.Group != 1 || len(m.Segments) != 0 {
+		t.Errorf("unexpected second map %+v", m)
+	}
+}
+
+func TestParseShapefile(t *testing.T) {
+	// A polyline with two parts: a two-point line and a three-point line.
+	pts := [][2]float64{{-75, 40}, {-75.5, 40}, {-74, 39}, {-74, 38}, {-73, 38}}
+	parts := []int32{0, 2}
+
+	var rec bytes.Buffer
+	le := func(v any) { binary.Write(&rec, binary.LittleEndian, v) }
+	le(int32(3))     // shape type
+	le([4]float64{}) // bounding box
+	le(int32(len(parts)))
+	le(int32(len(pts)))
+	le(parts)
+	for _, p := range pts {
+		le(math.Float64bits(p[0]))
+		le(math.Float64bits(p[1]))
+	}
+
+	var shp bytes.Buffer
+	header := make([]byte, 100)
+	binary.BigEndian.PutUint32(header[0:4], 9994)
+	shp.Write(header)
+	binary.Write(&shp, binary.BigEndian, [2]int32{1, int32(rec.Len() / 2)})
+	shp.Write(rec.Bytes())
+
+	segs, err := parseShapefile(shp.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Point2LL{{-75, 40}, {-75.5, 40}, {-74, 39}, {-74, 38}, {-74, 38}, {-73, 38}}
+	if !slices.Equal(segs, expected) {
+		t.Errorf("got segments %v, expected %v", segs, expected)
+	}
+
+	if _, err := parseShapefile(shp.Bytes()[:120]); err == nil {
+		t.Errorf("expected error for truncated shapefile")
+	}
+}
+
+func TestWriteVideoMapFile(t *testing.T) {
+	maps := []ImportedVideoMap{
+		ImportedVideoMap{STARSMap: STARSMap{Name: "GEO", Label: "GEO", Group: 1},
+			Segments: []Point2LL{{-75.25, 39.875}, {-75.5, 40.125}}},
+		ImportedVideoMap{STARSMap: STARSMap{Name: "PHL RWYS", Label: "RWYS"}, Id: 12,
+			Segments: []Point2LL{{-75, 40}, {-75.5, 40}, {-75.5, 40}, {-75.5, 40.5}}},
+		ImportedVideoMap{STARSMap: STARSMap{Name: "PHL MVA", Label: "MVA"}, Id: 4},
+	}
+
+	for _, filename := range []string{"maps.json", "maps.json.zst"} {
+		path := filepath.Join(t.TempDir(), filename)
+		sm, err := WriteVideoMapFile(path, maps)
+		if err != nil {
+			t.Fatalf("%s: %v", filename, err)
+		}
+		if names := MapSlice(sm, func(m STARSMap) string { return m.Name }); !slices.Equal(names, []string{"PHL MVA", "PHL RWYS", "GEO"}) {
+			t.Errorf("%s: got maps %v, expected those with ids first", filename, names)
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasSuffix(filename, ".zst") {
+			zr, err := zstd.NewReader(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err = zr.DecodeAll(b, nil)
+			zr.Close()
+			if err != nil {
+				t.Fatalf("%s: %v", filename, err)
+			}
+		}
+
+		// The maps should be read back by the scenario loader the same
+		// as they're read by encoding/json, with the points close to the
+		// ones written.
+		referenced := map[string]interface{}{"GEO": nil, "PHL RWYS": nil, "PHL MVA": nil}
+		cbs, err := loadVideoMapFile(bytes.NewReader(b), referenced)
+		if err != nil {
+			t.Fatalf("%s: %v", filename, err)
+		}
+		var segs map[string][]Point2LL
+		if err := json.Unmarshal(b, &segs); err != nil {
+			t.Fatalf("%s: %v", filename, err)
+		}
+		for _, m := range maps {
+			if len(segs[m.Name]) != len(m.Segments) {
+				t.Errorf("%s: %s: got %d points, expected %d", filename, m.Name, len(segs[m.Name]), len(m.Segments))
+				continue
+			}
+			for i, p := range segs[m.Name] {
+				if abs(p[0]-m.Segments[i][0]) > 1e-5 || abs(p[1]-m.Segments[i][1]) > 1e-5 {
+					t.Errorf("%s: %s: got point %v, expected %v", filename, m.Name, p, m.Segments[i])
+				}
+			}
+
+			ld := GetLinesDrawBuilder()
+			for i := 0; i < len(segs[m.Name])/2; i++ {
+				ld.AddLine(segs[m.Name][2*i], segs[m.Name][2*i+1])
+			}
+			var cb CommandBuffer
+			ld.GenerateCommands(&cb)
+			ReturnLinesDrawBuilder(ld)
+
+			if loaded, ok := cbs[m.Name]; !ok {
+				t.Errorf("%s: %s: not loaded", filename, m.Name)
+			} else if !slices.Equal(loaded.Buf, cb.Buf) {
+				t.Errorf("%s: %s: loaded map differs from the one written", filename, m.Name)
+			}
+		}
+	}
+
+	dup := append(DuplicateSlice(maps), maps[0])
+	if _, err := WriteVideoMapFile(filepath.Join(t.TempDir(), "dup.json"), dup); err == nil {
+		t.Errorf("expected error for duplicate map names")
+	}
+}
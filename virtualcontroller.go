@@ -0,0 +1,375 @@
+// virtualcontroller.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// VirtualControllerBehavior describes how a virtual controller interacts
+// with the human controllers: how quickly it accepts handoffs and point
+// outs, which handoffs it refuses because they don't meet the terms of
+// the LOA, which aircraft it points out on its own, and which
+// restrictions it issues to aircraft once it has control of them. If a
+// controller doesn't specify a behavior, it accepts everything after a
+// short delay.
+type VirtualControllerBehavior struct {
+	// Range of delays, in seconds, before handoffs and point outs are
+	// accepted.
+	AcceptDelay [2]int `json:"accept_delay"`
+	// Probability that a handoff is instead accepted after a delay
+	// sampled from LateAcceptDelay.
+	LateAcceptProbability float32 `json:"late_accept_probability"`
+	LateAcceptDelay       [2]int  `json:"late_accept_delay"`
+	// Probability that a point out is rejected.
+	PointOutRejectProbability float32 `json:"pointout_reject_probability"`
+
+	HandoffRules  []HandoffRule           `json:"handoff_rules"`
+	PointOutRules []PointOutRule          `json:"pointout_rules"`
+	Restrictions  []ControllerRestriction `json:"restrictions"`
+}
+
+// AircraftFilter selects aircraft by airport and route; empty fields match
+// all aircraft.
+type AircraftFilter struct {
+	// Departure or arrival airport
+	Airports []string `json:"airports"`
+	// Fixes in the route; for departures, the exit fix is also checked.
+	Fixes []string `json:"fixes"`
+}
+
+// HandoffRule specifies the altitude and speed at which matching aircraft
+// must be when they are handed off to the controller.
+type HandoffRule struct {
+	AircraftFilter
+	Altitude [2]int `json:"altitude"`
+	Speed    int    `json:"speed"`
+	// "reject" (the default) or "pointout", in which case the controller
+	// asks for a point out instead.
+	Action string `json:"action"`
+}
+
+// PointOutRule has the virtual controller point out matching aircraft
+// that it is tracking to another controller when they come within
+// Distance nm of one of the rule's fixes.
+type PointOutRule struct {
+	AircraftFilter
+	ToController string  `json:"to"`
+	Distance     float32 `json:"distance"`
+	Altitude     [2]int  `json:"altitude"`
+}
+
+// ControllerRestriction is an altitude and/or speed that the virtual
+// controller assigns to matching aircraft when it takes control of them.
+type ControllerRestriction struct {
+	AircraftFilter
+	Altitude int `json:"altitude"`
+	Speed    int `json:"speed"`
+}
+
+func (f *AircraftFilter) Match(ac *Aircraft) bool {
+	if len(f.Airports) > 0 && ac.FlightPlan != nil &&
+		!slices.Contains(f.Airports, ac.FlightPlan.DepartureAirport) &&
+		!slices.Contains(f.Airports, ac.FlightPlan.ArrivalAirport) {
+		return false
+	}
+	if len(f.Fixes) > 0 {
+		return slices.ContainsFunc(f.Fixes, func(fix string) bool {
+			return ac.Exit == fix || slices.ContainsFunc(ac.Nav.Waypoints, func(wp Waypoint) bool { return wp.Fix == fix }) ||
+				(ac.FlightPlan != nil && slices.Contains(strings.Fields(ac.FlightPlan.Route), fix))
+		})
+	}
+	return true
+}
+
+func (f *AircraftFilter) PostDeserialize(sg *ScenarioGroup, e *ErrorLogger) {
+	for _, ap := range f.Airports {
+		if _, ok := sg.Airports[ap]; !ok {
+			e.ErrorString("airport \"%s\" not found in scenario", ap)
+		}
+	}
+	for _, fix := range f.Fixes {
+		if _, ok := sg.locate(fix); !ok {
+			e.ErrorString("fix \"%s\" unknown", fix)
+		}
+	}
+}
+
+func (b *VirtualControllerBehavior) PostDeserialize(sg *ScenarioGroup, e *ErrorLogger) {
+	e.Push("virtual_behavior")
+	defer e.Pop()
+
+	checkRange := func(r [2]int, name string) {
+		if r[0] < 0 || r[0] > r[1] {
+			e.ErrorString("invalid \"%s\" range [%d, %d]", name, r[0], r[1])
+		}
+	}
+
+	if b.AcceptDelay == [2]int{} {
+		b.AcceptDelay = [2]int{4, 14}
+	}
+	checkRange(b.AcceptDelay, "accept_delay")
+	if b.LateAcceptProbability < 0 || b.LateAcceptProbability > 1 {
+		e.ErrorString("\"late_accept_probability\" must be between 0 and 1")
+	}
+	if b.LateAcceptProbability > 0 {
+		checkRange(b.LateAcceptDelay, "late_accept_delay")
+		if b.LateAcceptDelay[1] == 0 {
+			e.ErrorString("must specify \"late_accept_delay\" with \"late_accept_probability\"")
+		}
+	}
+	if b.PointOutRejectProbability < 0 || b.PointOutRejectProbability > 1 {
+		e.ErrorString("\"pointout_reject_probability\" must be between 0 and 1")
+	}
+
+	for i := range b.HandoffRules {
+		r := &b.HandoffRules[i]
+		e.Push(fmt.Sprintf("handoff_rules[%d]", i))
+		r.AircraftFilter.PostDeserialize(sg, e)
+		checkRange(r.Altitude, "altitude")
+		if r.Altitude[1] == 0 && r.Speed == 0 {
+			e.ErrorString("must specify \"altitude\" and/or \"speed\"")
+		}
+		if r.Action == "" {
+			r.Action = "reject"
+		} else if r.Action != "reject" && r.Action != "pointout" {
+			e.ErrorString("\"action\" must be \"reject\" or \"pointout\"")
+		}
+		e.Pop()
+	}
+
+	for i := range b.PointOutRules {
+		r := &b.PointOutRules[i]
+		e.Push(fmt.Sprintf("pointout_rules[%d]", i))
+		r.AircraftFilter.PostDeserialize(sg, e)
+		checkRange(r.Altitude, "altitude")
+		if len(r.Fixes) == 0 {
+			e.ErrorString("must specify \"fixes\"")
+		}
+		if _, ok := sg.ControlPositions[r.ToController]; !ok {
+			e.ErrorString("controller \"%s\" unknown", r.ToController)
+		}
+		if r.Distance == 0 {
+			r.Distance = 5
+		}
+		e.Pop()
+	}
+
+	for i := range b.Restrictions {
+		r := &b.Restrictions[i]
+		e.Push(fmt.Sprintf("restrictions[%d]", i))
+		r.AircraftFilter.PostDeserialize(sg, e)
+		if r.Altitude == 0 && r.Speed == 0 {
+			e.ErrorString("must specify \"altitude\" and/or \"speed\"")
+		}
+		e.Pop()
+	}
+}
+
+// inRange returns true if v is inside the given range; an all-zero range
+// matches all values.
+func inRange(r [2]int, v float32) bool {
+	return r == [2]int{} || (v >= float32(r[0]) && v <= float32(r[1]))
+}
+
+// CheckHandoff returns a reason that the controller won't accept the
+// handoff of the given aircraft and the rule's action, if it violates one
+// of the controller's handoff rules.
+func (b *VirtualControllerBehavior) CheckHandoff(ac *Aircraft) (reason string, action string) {
+	for _, r := range b.HandoffRules {
+		if !r.Match(ac) {
+			continue
+		}
+
+		// Allow aircraft that have been assigned the right altitude but
+		// haven't quite gotten there yet.
+		alt := ac.IndicatedAltitude()
+		if ac.Nav.Altitude.Assigned != nil {
+			alt = *ac.Nav.Altitude.Assigned
+		}
+		if !inRange(r.Altitude, alt) {
+			if r.Altitude[0] == r.Altitude[1] {
+				return fmt.Sprintf("not at %d", r.Altitude[0]), r.Action
+			}
+			return fmt.Sprintf("not between %d and %d", r.Altitude[0], r.Altitude[1]), r.Action
+		}
+		if r.Speed != 0 && ac.IAS() > float32(r.Speed)+5 {
+			return fmt.Sprintf("not at or below %d knots", r.Speed), r.Action
+		}
+	}
+	return "", ""
+}
+
+// Restrict assigns the first matching restriction to the aircraft and
+// returns the pilot's readbacks.
+func (b *VirtualControllerBehavior) Restrict(ac *Aircraft) []RadioTransmission {
+	var rt []RadioTransmission
+	for _, r := range b.Restrictions {
+		if r.Match(ac) {
+			if r.Altitude != 0 {
+				rt = append(rt, ac.AssignAltitude(r.Altitude, false)...)
+			}
+			if r.Speed != 0 {
+				rt = append(rt, ac.AssignSpeed(r.Speed, false)...)
+			}
+			break
+		}
+	}
+	return rt
+}
+
+func sampleDelay(r [2]int) time.Duration {
+	return time.Duration(r[0]+rand.Intn(r[1]-r[0]+1)) * time.Second
+}
+
+// virtualControllerBehavior returns the behavior for the given virtual
+// controller; controllers that don't have one specified in the scenario
+// get the default of accepting everything after a short delay.
+func (s *Sim) virtualControllerBehavior(callsign string) *VirtualControllerBehavior {
+	if ctrl := s.World.GetController(callsign); ctrl != nil && ctrl.Behavior != nil {
+		return ctrl.Behavior
+	}
+	return &VirtualControllerBehavior{AcceptDelay: [2]int{4, 14}}
+}
+
+// handoffAcceptTime returns when the given controller will respond to a
+// handoff offered to it.
+func (s *Sim) handoffAcceptTime(callsign string) time.Time {
+	b := s.virtualControllerBehavior(callsign)
+	if b.LateAcceptProbability > 0 && rand.Float32() < b.LateAcceptProbability {
+		return s.SimTime.Add(sampleDelay(b.LateAcceptDelay))
+	}
+	return s.SimTime.Add(sampleDelay(b.AcceptDelay))
+}
+
+func (s *Sim) pointOutAcceptTime(callsign string) time.Time {
+	return s.SimTime.Add(sampleDelay(s.virtualControllerBehavior(callsign).AcceptDelay))
+}
+
+// respondToHandoff is called when a virtual controller gets around to a
+// handoff that was offered to it; it either accepts it or rejects it,
+// possibly taking it as a point out instead.
+func (s *Sim) respondToHandoff(ac *Aircraft) {
+	to := ac.HandoffTrackController
+	if reason, action := s.virtualControllerBehavior(to).CheckHandoff(ac); reason != "" {
+		msg := fmt.Sprintf("%s: unable handoff %s, %s", to, ac.Callsign, reason)
+		if action == "pointout" {
+			msg += ", point out approved"
+		}
+		s.eventStream.Post(Event{
+			Type:           RejectedHandoffEvent,
+			FromController: ac.TrackingController,
+			ToController:   to,
+			Callsign:       ac.Callsign,
+			Message:        msg,
+		})
+		s.eventStream.Post(Event{
+			Type:    StatusMessageEvent,
+			Message: msg,
+		})
+		s.lg.Info("virtual handoff reject", slog.String("callsign", ac.Callsign),
+			slog.String("from", ac.TrackingController), slog.String("to", to),
+			slog.String("reason", reason))
+
+		ac.HandoffTrackController = ""
+
+		if action == "pointout" {
+			s.pointOut(ac, ac.TrackingController, to)
+		}
+		return
+	}
+
+	s.eventStream.Post(Event{
+		Type:           AcceptedHandoffEvent,
+		FromController: ac.TrackingController,
+		ToController:   to,
+		Callsign:       ac.Callsign,
+	})
+	s.lg.Info("automatic handoff accept", slog.String("callsign", ac.Callsign),
+		slog.String("from", ac.TrackingController), slog.String("to", to))
+
+	ac.TrackingController = to
+	ac.HandoffTrackController = ""
+}
+
+// respondToPointOut is called when a virtual controller gets around to a
+// point out made to it.
+func (s *Sim) respondToPointOut(ac *Aircraft, toController string, po PointOut) {
+	// Note that "to" and "from" are swapped in the event, since the
+	// response is coming from the "to" controller of the original point
+	// out.
+	if p := s.virtualControllerBehavior(toController).PointOutRejectProbability; p > 0 && rand.Float32() < p {
+		s.eventStream.Post(Event{
+			Type:           RejectedPointOutEvent,
+			FromController: toController,
+			ToController:   po.FromController,
+			Callsign:       ac.Callsign,
+		})
+		s.lg.Info("automatic pointout reject", slog.String("callsign", ac.Callsign),
+			slog.String("by", toController), slog.String("to", po.FromController))
+	} else {
+		s.eventStream.Post(Event{
+			Type:           AcknowledgedPointOutEvent,
+			FromController: toController,
+			ToController:   po.FromController,
+			Callsign:       ac.Callsign,
+		})
+		s.lg.Info("automatic pointout accept", slog.String("callsign", ac.Callsign),
+			slog.String("by", toController), slog.String("to", po.FromController))
+	}
+}
+
+// initiatePointOuts has virtual controllers point out the aircraft they
+// are tracking according to their point out rules.
+func (s *Sim) initiatePointOuts() {
+	for _, ac := range s.World.Aircraft {
+		if ac.TrackingController == "" || s.controllerIsSignedIn(ac.TrackingController) {
+			continue
+		}
+		ctrl := s.World.GetController(ac.TrackingController)
+		if ctrl == nil || ctrl.Behavior == nil {
+			continue
+		}
+
+		for _, r := range ctrl.Behavior.PointOutRules {
+			to := s.ResolveController(r.ToController)
+			if to == ac.TrackingController || slices.Contains(ac.VirtualPointOuts, to) ||
+				!s.controllerIsSignedIn(to) || !r.Match(ac) || !inRange(r.Altitude, ac.IndicatedAltitude()) {
+				continue
+			}
+
+			if !slices.ContainsFunc(r.Fixes, func(fix string) bool {
+				p, ok := s.World.Locate(fix)
+				return ok && nmdistance2ll(ac.Position(), p) < r.Distance
+			}) {
+				continue
+			}
+
+			s.eventStream.Post(Event{
+				Type:           PointOutEvent,
+				FromController: ac.TrackingController,
+				ToController:   to,
+				Callsign:       ac.Callsign,
+			})
+			s.lg.Info("virtual pointout", slog.String("callsign", ac.Callsign),
+				slog.String("from", ac.TrackingController), slog.String("to", to))
+
+			if s.PointOuts[ac.Callsign] == nil {
+				s.PointOuts[ac.Callsign] = make(map[string]PointOut)
+			}
+			s.PointOuts[ac.Callsign][to] = PointOut{
+				FromController: ac.TrackingController,
+				// If the controller signs off, it'll be acknowledged
+				// automatically.
+				AcceptTime: s.SimTime,
+			}
+			ac.VirtualPointOuts = append(ac.VirtualPointOuts, to)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// virtualcontroller_test.go
+// Copyright(c) 2024 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestVirtualControllerHandoffPointOut(t *testing.T) {
+	behavior := &VirtualControllerBehavior{
+		AcceptDelay:  [2]int{4, 14},
+		HandoffRules: []HandoffRule{HandoffRule{Altitude: [2]int{5000, 5000}, Action: "pointout"}},
+	}
+	s := &Sim{
+		World: &World{
+			Controllers: map[string]*Controller{"2B": &Controller{Callsign: "2B", Behavior: behavior}},
+		},
+		eventStream: NewEventStream(),
+		PointOuts:   make(map[string]map[string]PointOut),
+		SimTime:     time.Now(),
+	}
+	sub := s.eventStream.Subscribe()
+
+	ac := &Aircraft{Callsign: "AAL1", TrackingController: "1A", HandoffTrackController: "2B"}
+	ac.Nav.FlightState.Altitude = 8000
+
+	// Not at 5000, so the handoff is rejected and the aircraft is pointed
+	// out instead.
+	s.respondToHandoff(ac)
+	if ac.TrackingController != "1A" || ac.HandoffTrackController != "" {
+		t.Errorf("handoff not rejected: tracking %q, handoff %q", ac.TrackingController, ac.HandoffTrackController)
+	}
+	if po, ok := s.PointOuts["AAL1"]["2B"]; !ok {
+		t.Errorf("no point out to 2B after rejected handoff")
+	} else if po.FromController != "1A" || !po.AcceptTime.After(s.SimTime) {
+		t.Errorf("unexpected point out %+v", po)
+	}
+	events := sub.Get()
+	for _, ty := range []EventType{RejectedHandoffEvent, PointOutEvent} {
+		if !slices.ContainsFunc(events, func(e Event) bool {
+			return e.Type == ty && e.Callsign == "AAL1" && e.FromController == "1A" && e.ToController == "2B"
+		}) {
+			t.Errorf("didn't find %v event in %+v", ty, events)
+		}
+	}
+
+	// At 5000, it's accepted, even though with its altimeter set 0.2"
+	// high it's really at 4800.
+	delete(s.PointOuts, "AAL1")
+	ac.HandoffTrackController = "2B"
+	ac.Nav.FlightState.Altitude = 5000
+	ac.Nav.FlightState.LocalAltimeter, ac.Nav.FlightState.Altimeter = 29.80, 30.00
+	s.respondToHandoff(ac)
+	if ac.TrackingController != "2B" || len(s.PointOuts["AAL1"]) != 0 {
+		t.Errorf("handoff not accepted: tracking %q, point outs %+v", ac.TrackingController, s.PointOuts["AAL1"])
+	}
+}
+
+func TestVirtualControllerRestrict(t *testing.T) {
+	b := VirtualControllerBehavior{
+		Restrictions: []ControllerRestriction{
+			ControllerRestriction{AircraftFilter: AircraftFilter{Airports: []string{"KJFK"}}, Altitude: 7000},
+			ControllerRestriction{Altitude: 9000, Speed: 250},
+		},
+	}
+
+	ac := &Aircraft{
+		Callsign:              "AAL1",
+		ControllingController: "2B",
+		FlightPlan:            &FlightPlan{DepartureAirport: "KBOS", ArrivalAirport: "KLGA"},
+	}
+	ac.Nav.Perf.Ceiling = 40000
+	ac.Nav.Perf.Speed.MaxTAS = 500
+	ac.Nav.Perf.Speed.Landing = 130
+	ac.Nav.FlightState.Altitude = 11000
+	ac.Nav.FlightState.IAS = 280
+
+	rt := b.Restrict(ac)
+	if len(rt) != 2 {
+		t.Fatalf("expected readbacks for altitude and speed; got %+v", rt)
+	}
+	for _, r := range rt {
+		if r.Controller != "2B" || r.Type != RadioTransmissionReadback {
+			t.Errorf("unexpected transmission %+v", r)
+		}
+	}
+	if a := ac.Nav.Altitude.Assigned; a == nil || *a != 9000 {
+		t.Errorf("expected 9000 to be assigned; got %v", a)
+	}
+	if s := ac.Nav.Speed.Assigned; s == nil || *s != 250 {
+		t.Errorf("expected 250 knots to be assigned; got %v", s)
+	}
+}
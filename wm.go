@@ -41,6 +41,10 @@ var (
 		keyboardFocusStack []Pane
 
 		lastAircraftResponse string
+
+		// When a scenario is being edited, its editor takes the place of
+		// the regular Panes.
+		scenarioEditor *ScenarioEditorPane
 	}
 )
 
@@ -446,6 +450,9 @@ func wmDrawPanes(p Platform, r Renderer, w *World, stats *Stats) {
 		}
 	}
 	root := filter(globalConfig.DisplayRoot)
+	if wm.scenarioEditor != nil {
+		root = &DisplayNode{Pane: wm.scenarioEditor}
+	}
 
 	if !wmPaneIsPresent(wm.keyboardFocusPane, root) {
 		// It was deleted in the config editor or a new config was loaded.
@@ -40,6 +40,8 @@ type World struct {
 	showScenarioInfo  bool
 
 	launchControlWindow *LaunchControlWindow
+	instructorWindow    *InstructorWindow
+	pseudoPilotWindow   *PseudoPilotWindow
 
 	pendingCalls []*PendingCall
 
@@ -76,6 +78,10 @@ type World struct {
 	InhibitCAVolumes        []AirspaceVolume
 	Wind                    Wind
 	Callsign                string
+	Instructor              bool // Callsign is InstructorCallsign
+	HaveInstructor          bool // Someone is signed in as the instructor
+	PseudoPilot             bool // Callsign is PseudoPilotCallsign
+	PilotInstructions       []PilotInstruction
 	ApproachAirspace        []ControllerAirspaceVolume
 	DepartureAirspace       []ControllerAirspaceVolume
 	DepartureRunways        []ScenarioGroupDepartureRunway
@@ -127,6 +133,10 @@ func (w *World) Assign(other *World) {
 	w.InhibitCAVolumes = other.InhibitCAVolumes
 	w.Wind = other.Wind
 	w.Callsign = other.Callsign
+	w.Instructor = other.Instructor
+	w.HaveInstructor = other.HaveInstructor
+	w.PseudoPilot = other.PseudoPilot
+	w.PilotInstructions = other.PilotInstructions
 	w.ApproachAirspace = other.ApproachAirspace
 	w.DepartureAirspace = other.DepartureAirspace
 	w.DepartureRunways = other.DepartureRunways
@@ -192,12 +202,24 @@ func (w *World) AllAirports() map[string]*Airport {
 	return all
 }
 
-func (w *World) SetSquawk(callsign string, squawk Squawk) error {
-	return nil // UNIMPLEMENTED
+func (w *World) SetSquawk(callsign string, squawk Squawk, success func(any), err func(error)) {
+	w.pendingCalls = append(w.pendingCalls,
+		&PendingCall{
+			Call:      w.simProxy.SetSquawk(callsign, squawk),
+			IssueTime: time.Now(),
+			OnSuccess: success,
+			OnErr:     err,
+		})
 }
 
-func (w *World) SetSquawkAutomatic(callsign string) error {
-	return nil // UNIMPLEMENTED
+func (w *World) SetSquawkAutomatic(callsign string, success func(any), err func(error)) {
+	w.pendingCalls = append(w.pendingCalls,
+		&PendingCall{
+			Call:      w.simProxy.SetSquawkAutomatic(callsign),
+			IssueTime: time.Now(),
+			OnSuccess: success,
+			OnErr:     err,
+		})
 }
 
 func (w *World) TakeOrReturnLaunchControl(eventStream *EventStream) {
@@ -214,11 +236,72 @@ func (w *World) TakeOrReturnLaunchControl(eventStream *EventStream) {
 		})
 }
 
-func (w *World) LaunchAircraft(ac Aircraft) {
+func (w *World) LaunchAircraft(ac Aircraft, onErr func(error)) {
 	w.pendingCalls = append(w.pendingCalls,
 		&PendingCall{
 			Call:      w.simProxy.LaunchAircraft(ac),
 			IssueTime: time.Now(),
+			OnErr:     onErr,
+		})
+}
+
+func (w *World) TogglePseudoPilot(callsign string, onErr func(error)) {
+	if ac, ok := w.Aircraft[callsign]; ok {
+		ac.PseudoPilot = !ac.PseudoPilot // so the UI updates right away
+	}
+
+	w.pendingCalls = append(w.pendingCalls,
+		&PendingCall{
+			Call:      w.simProxy.TogglePseudoPilot(callsign),
+			IssueTime: time.Now(),
+			OnErr:     onErr,
+		})
+}
+
+func (w *World) RespondToPilotInstruction(id int, commands, readback string, onErr func(error)) {
+	w.PilotInstructions = FilterSlice(w.PilotInstructions, func(pi PilotInstruction) bool { return pi.Id != id })
+
+	w.pendingCalls = append(w.pendingCalls,
+		&PendingCall{
+			Call:      w.simProxy.RespondToPilotInstruction(id, commands, readback, globalConfig.Macros.ForTRACON(w.TRACON)),
+			IssueTime: time.Now(),
+			OnErr:     onErr,
+		})
+}
+
+func (w *World) DeclareEmergency(callsign string, onErr func(error)) {
+	w.pendingCalls = append(w.pendingCalls,
+		&PendingCall{
+			Call:      w.simProxy.DeclareEmergency(callsign),
+			IssueTime: time.Now(),
+			OnErr:     onErr,
+		})
+}
+
+func (w *World) ToggleNORDO(callsign string, onErr func(error)) {
+	w.pendingCalls = append(w.pendingCalls,
+		&PendingCall{
+			Call:      w.simProxy.ToggleNORDO(callsign),
+			IssueTime: time.Now(),
+			OnErr:     onErr,
+		})
+}
+
+func (w *World) SetWind(wind Wind, onErr func(error)) {
+	w.pendingCalls = append(w.pendingCalls,
+		&PendingCall{
+			Call:      w.simProxy.SetWind(wind),
+			IssueTime: time.Now(),
+			OnErr:     onErr,
+		})
+}
+
+func (w *World) SendPrivateMessage(controller, message string, onErr func(error)) {
+	w.pendingCalls = append(w.pendingCalls,
+		&PendingCall{
+			Call:      w.simProxy.SendPrivateMessage(controller, message),
+			IssueTime: time.Now(),
+			OnErr:     onErr,
 		})
 }
 
@@ -264,8 +347,24 @@ func (w *World) SetTemporaryAltitude(callsign string, alt int, success func(any)
 		})
 }
 
-func (w *World) AmendFlightPlan(callsign string, fp FlightPlan) error {
-	return nil // UNIMPLEMENTED
+func (w *World) CreateFlightPlan(callsign string, fp FlightPlan, success func(any), err func(error)) {
+	w.pendingCalls = append(w.pendingCalls,
+		&PendingCall{
+			Call:      w.simProxy.CreateFlightPlan(callsign, fp),
+			IssueTime: time.Now(),
+			OnSuccess: success,
+			OnErr:     err,
+		})
+}
+
+func (w *World) AmendFlightPlan(callsign string, fp FlightPlan, success func(any), err func(error)) {
+	w.pendingCalls = append(w.pendingCalls,
+		&PendingCall{
+			Call:      w.simProxy.AmendFlightPlan(callsign, fp),
+			IssueTime: time.Now(),
+			OnSuccess: success,
+			OnErr:     err,
+		})
 }
 
 func (w *World) SetGlobalLeaderLine(callsign string, dir *CardinalOrdinalDirection, success func(any), err func(error)) {
@@ -515,6 +614,20 @@ func (w *World) GetMETAR(location string) *METAR {
 	return w.METAR[location]
 }
 
+// ModeCAltitude returns the altitude that radar displays for an aircraft
+// given its Mode C report. As in the real system, reports below the
+// transition altitude are corrected using the altimeter setting at the
+// primary airport; above it, pressure altitude is shown directly.
+func (w *World) ModeCAltitude(ac *Aircraft) float32 {
+	alt := ac.PressureAltitude()
+	if alt < TransitionAltitude {
+		if metar := w.GetMETAR(w.PrimaryAirport); metar != nil {
+			alt = IndicatedAltitude(alt, metar.AltimeterSetting())
+		}
+	}
+	return alt
+}
+
 func (w *World) GetAirportATIS(airport string) []ATIS {
 	// UNIMPLEMENTED
 	return nil
@@ -707,7 +820,7 @@ func (w *World) DeleteAircraft(ac *Aircraft, onErr func(err error)) {
 func (w *World) RunAircraftCommands(ac *Aircraft, cmds string, onErr func(err error)) {
 	w.pendingCalls = append(w.pendingCalls,
 		&PendingCall{
-			Call:      w.simProxy.RunAircraftCommands(ac.Callsign, cmds),
+			Call:      w.simProxy.RunAircraftCommands(ac.Callsign, cmds, globalConfig.Macros.ForTRACON(w.TRACON)),
 			IssueTime: time.Now(),
 			OnErr:     onErr,
 		})
@@ -819,25 +932,37 @@ func (w *World) sampleAircraft(icao, fleet string) (*Aircraft, string) {
 		}
 	}
 
-	squawk := Squawk(rand.Intn(0o7000))
+	return &Aircraft{
+		Callsign: callsign,
+		Mode:     Charlie,
+	}, flightPlanAircraftType(aircraft, perf)
+}
 
-	acType := aircraft
-	if perf.WeightClass == "H" {
-		acType = "H/" + acType
-	}
-	if perf.WeightClass == "J" {
-		acType = "J/" + acType
+// assignInitialSquawk allocates a beacon code for a newly-created
+// aircraft's flight plan.
+func (w *World) assignInitialSquawk(ac *Aircraft) error {
+	sq, err := w.AllocateSquawk(beaconCodeCategory(ac))
+	if err != nil {
+		return err
 	}
+	ac.AssignedSquawk, ac.Squawk = sq, sq
+	return nil
+}
 
-	return &Aircraft{
-		Callsign:       callsign,
-		AssignedSquawk: squawk,
-		Squawk:         squawk,
-		Mode:           Charlie,
-	}, acType
+// flightPlanAircraftType returns the aircraft type as it should appear in
+// a flight plan, with the weight class prefix for heavies and supers.
+func flightPlanAircraftType(icao string, perf AircraftPerformance) string {
+	switch perf.WeightClass {
+	case "H":
+		return "H/" + icao
+	case "J":
+		return "J/" + icao
+	default:
+		return icao
+	}
 }
 
-func (w *World) CreateArrival(arrivalGroup string, arrivalAirport string, goAround bool) (*Aircraft, error) {
+func (w *World) CreateArrival(arrivalGroup string, arrivalAirport string) (*Aircraft, error) {
 	arrivals := w.ArrivalGroups[arrivalGroup]
 	// Randomly sample from the arrivals that have a route to this airport.
 	idx := SampleFiltered(arrivals, func(ar Arrival) bool {
@@ -857,6 +982,9 @@ func (w *World) CreateArrival(arrivalGroup string, arrivalAirport string, goArou
 	}
 
 	ac.FlightPlan = NewFlightPlan(IFR, acType, airline.Airport, arrivalAirport)
+	if err := w.assignInitialSquawk(ac); err != nil {
+		return nil, err
+	}
 
 	// Figure out which controller will (for starters) get the arrival
 	// handoff. For single-user, it's easy.  Otherwise, figure out which
@@ -872,7 +1000,7 @@ func (w *World) CreateArrival(arrivalGroup string, arrivalAirport string, goArou
 		}
 	}
 
-	if err := ac.InitializeArrival(w, arrivalGroup, idx, arrivalController, goAround); err != nil {
+	if err := ac.InitializeArrival(w, arrivalGroup, idx, arrivalController); err != nil {
 		return nil, err
 	}
 
@@ -936,6 +1064,9 @@ func (w *World) CreateDeparture(departureAirport, runway, category string, chall
 	}
 
 	ac.FlightPlan = NewFlightPlan(IFR, acType, departureAirport, dep.Destination)
+	if err := w.assignInitialSquawk(ac); err != nil {
+		return nil, nil, err
+	}
 	exitRoute := rwy.ExitRoutes[dep.Exit]
 	if err := ac.InitializeDeparture(w, ap, departureAirport, dep, runway, exitRoute); err != nil {
 		return nil, nil, err
@@ -1641,9 +1772,11 @@ func (w *World) DrawSettingsWindow() {
 
 	imgui.BeginV("Settings", &w.showSettings, imgui.WindowFlagsAlwaysAutoResize)
 
+	uiStartDisable(w.HaveInstructor && !w.Instructor)
 	if imgui.SliderFloatV("Simulation speed", &w.SimRate, 1, 20, "%.1f", 0) {
 		w.SetSimRate(w.SimRate)
 	}
+	uiEndDisable(w.HaveInstructor && !w.Instructor)
 
 	update := !globalConfig.InhibitDiscordActivity.Load()
 	imgui.Checkbox("Update Discord activity status", &update)
@@ -1685,6 +1818,11 @@ func (w *World) DrawSettingsWindow() {
 
 	if imgui.CollapsingHeader("Audio") {
 		globalConfig.Audio.DrawUI()
+		imgui.Separator()
+		globalConfig.Speech.DrawUI()
+	}
+	if imgui.CollapsingHeader("Command Macros") {
+		globalConfig.Macros.DrawUI(w.TRACON)
 	}
 	if fsp != nil && imgui.CollapsingHeader("Flight Strips") {
 		fsp.DrawUI()